@@ -15,12 +15,13 @@
 package main
 
 import (
+	"github.com/openimsdk/openim-project-template/internal/rpc/user"
 	"github.com/openimsdk/openim-project-template/pkg/common/cmd"
 	"github.com/openimsdk/tools/system/program"
 )
 
 func main() {
-	if err := cmd.NewUserRpcCmd().Exec(); err != nil {
+	if err := cmd.NewRpcCmd(user.NewService()).Exec(); err != nil {
 		program.ExitWithError(err)
 	}
 }
@@ -0,0 +1,32 @@
+// Copyright © 2024 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/openimsdk/openim-project-template/pkg/protocol/audit"
+	"github.com/openimsdk/openim-project-template/pkg/rpcclient"
+	"github.com/openimsdk/tools/a2r"
+)
+
+type AuditApi rpcclient.Audit
+
+func NewAuditApi(client rpcclient.Audit) AuditApi {
+	return AuditApi(client)
+}
+
+func (a *AuditApi) SearchAuditLogs(c *gin.Context) {
+	a2r.Call(audit.AuditClient.SearchAuditLogs, a.Client, c)
+}
@@ -0,0 +1,91 @@
+// Copyright © 2024 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/openimsdk/tools/discovery"
+	"github.com/openimsdk/tools/errs"
+	"github.com/openimsdk/tools/mcontext"
+	"google.golang.org/grpc/health/grpc_health_v1"
+)
+
+const readinessTimeout = 3 * time.Second
+
+// HealthApi serves the liveness and readiness endpoints of the API server.
+type HealthApi struct {
+	disCov       discovery.SvcDiscoveryRegistry
+	dependencies []string
+	shuttingDown atomic.Bool
+}
+
+// NewHealthApi creates a HealthApi whose readiness requires every rpcRegisterName in dependencies to be reachable.
+func NewHealthApi(disCov discovery.SvcDiscoveryRegistry, dependencies ...string) *HealthApi {
+	return &HealthApi{disCov: disCov, dependencies: dependencies}
+}
+
+// Shutdown makes readiness fail so load balancers stop sending new requests.
+func (h *HealthApi) Shutdown() {
+	h.shuttingDown.Store(true)
+}
+
+// Healthz reports that the process is alive.
+func (h *HealthApi) Healthz(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+// Readyz reports whether the server accepts traffic and every RPC dependency is serving.
+func (h *HealthApi) Readyz(c *gin.Context) {
+	if h.shuttingDown.Load() {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"status": "shutting down"})
+		return
+	}
+	ctx, cancel := context.WithTimeout(c, readinessTimeout)
+	defer cancel()
+	// Probes carry no operationID, the rpc client interceptor requires one.
+	ctx = mcontext.SetOperationID(ctx, "readyz_"+strconv.FormatInt(time.Now().UnixMilli(), 10))
+	failed := make(map[string]string)
+	for _, name := range h.dependencies {
+		if err := h.checkRpc(ctx, name); err != nil {
+			failed[name] = err.Error()
+		}
+	}
+	if len(failed) > 0 {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"status": "unavailable", "dependencies": failed})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+func (h *HealthApi) checkRpc(ctx context.Context, name string) error {
+	conn, err := h.disCov.GetConn(ctx, name)
+	if err != nil {
+		return err
+	}
+	resp, err := grpc_health_v1.NewHealthClient(conn).Check(ctx, &grpc_health_v1.HealthCheckRequest{})
+	if err != nil {
+		return err
+	}
+	if resp.Status != grpc_health_v1.HealthCheckResponse_SERVING {
+		return errs.New("rpc is not serving", "status", resp.Status.String())
+	}
+	return nil
+}
@@ -17,24 +17,28 @@ package api
 import (
 	"context"
 	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/openimsdk/openim-project-template/pkg/common/audit"
 	"github.com/openimsdk/openim-project-template/pkg/common/config"
 	"github.com/openimsdk/tools/utils/datautil"
 	"github.com/openimsdk/tools/utils/network"
 	"net"
 	"net/http"
-	"os"
-	"os/signal"
 	"strconv"
-	"syscall"
-	"time"
 
 	kdisc "github.com/openimsdk/openim-project-template/pkg/common/discoveryregister"
 	ginprom "github.com/openimsdk/openim-project-template/pkg/common/ginprometheus"
 	"github.com/openimsdk/openim-project-template/pkg/common/prommetrics"
+	"github.com/openimsdk/openim-project-template/pkg/common/startrpc"
+	"github.com/openimsdk/openim-project-template/pkg/common/tracing"
+	"github.com/openimsdk/openim-project-template/pkg/rpcclient"
 	"github.com/openimsdk/tools/discovery"
 	"github.com/openimsdk/tools/errs"
 	"github.com/openimsdk/tools/log"
+	"github.com/openimsdk/tools/mw"
 	"github.com/openimsdk/tools/system/program"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
 )
 
 type Config struct {
@@ -44,14 +48,17 @@ type Config struct {
 }
 
 func Start(ctx context.Context, index int, config *Config) error {
-	apiPort, err := datautil.GetElemByIndex(config.API.Api.Ports, index)
-	if err != nil {
+	lifecycle := startrpc.NewLifecycle(&config.Share.Shutdown)
+	defer lifecycle.Stop(ctx)
+
+	if err := prommetrics.Init(program.GetProcessName(), index); err != nil {
 		return err
 	}
-	prometheusPort, err := datautil.GetElemByIndex(config.API.Prometheus.Ports, index)
+	shutdownTracing, err := tracing.Init(ctx, &config.Share.Tracing, program.GetProcessName())
 	if err != nil {
 		return err
 	}
+	lifecycle.OnStop(startrpc.PhaseFlush, "tracing", shutdownTracing)
 
 	var client discovery.SvcDiscoveryRegistry
 
@@ -60,20 +67,73 @@ func Start(ctx context.Context, index int, config *Config) error {
 	if err != nil {
 		return errs.WrapMsg(err, "failed to register discovery service")
 	}
+	lifecycle.OnStop(startrpc.PhaseClose, "discovery", func(ctx context.Context) error {
+		client.Close()
+		return nil
+	})
+	client.AddOption(mw.GrpcClient(), rpcclient.GrpcStreamClient(), tracing.DialOption(), grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithDefaultServiceConfig(fmt.Sprintf(`{"LoadBalancingPolicy": "%s"}`, "round_robin")))
+	client.AddOption(prommetrics.GrpcClientDialOptions()...)
+	client.AddOption(audit.DialOption())
+
+	netErr := make(chan error, 2)
+	if err := Serve(ctx, lifecycle, index, config, client, netErr); err != nil {
+		return err
+	}
+	return lifecycle.Run(ctx, netErr)
+}
 
-	var (
-		netDone = make(chan struct{}, 1)
-		netErr  error
-	)
+// Serve starts the API server on the instance selected by index, using a discovery client
+// whose dial options are already set. Serving errors are sent to errCh and the server is
+// stopped by lifecycle.
+func Serve(ctx context.Context, lifecycle *startrpc.Lifecycle, index int, config *Config, client discovery.SvcDiscoveryRegistry, errCh chan<- error) error {
+	apiPort, err := datautil.GetElemByIndex(config.API.Api.Ports, index)
+	if err != nil {
+		return err
+	}
+	prometheusPort, err := datautil.GetElemByIndex(config.API.Prometheus.Ports, index)
+	if err != nil {
+		return err
+	}
 
-	router := newGinRouter(client, config)
+	health := NewHealthApi(client, config.Share.RpcRegisterName.User)
+	lifecycle.OnStop(startrpc.PhaseUnhealthy, "api", func(ctx context.Context) error {
+		health.Shutdown()
+		return nil
+	})
+	var p *ginprom.Prometheus
+	var middleware []gin.HandlerFunc
 	if config.API.Prometheus.Enable {
+		registry, err := prommetrics.Registry()
+		if err != nil {
+			return err
+		}
+		registerer, err := prommetrics.Registerer()
+		if err != nil {
+			return err
+		}
+		p = ginprom.NewPrometheusWithRegistry("app", registerer, registry)
+		custom, err := prommetrics.GinMetrics(config.API.Prometheus.CustomMetrics)
+		if err != nil {
+			return err
+		}
+		// Gin only applies middlewares to the routes registered after them.
+		middleware = append(middleware, p.HandlerFunc(), custom)
+	}
+	router := newGinRouter(client, config, health, middleware...)
+	if p != nil {
+		p.MetricsPath = prommetrics.MetricsPath(config.API.Prometheus.Path)
+		p.SetListenAddress(net.JoinHostPort(network.GetListenIP(config.API.Prometheus.ListenIP), strconv.Itoa(prometheusPort)))
+		if pushGateway := &config.API.Prometheus.PushGateway; pushGateway.URL != "" {
+			p.SetPushGatewayJob(pushGateway.Job)
+			p.SetPushGatewayGrouping(prommetrics.PushGrouping(pushGateway, index))
+			p.SetPushGateway(pushGateway.URL, prommetrics.PushInterval(pushGateway))
+			// Pushes the metrics of the drain as a final batch.
+			lifecycle.OnStop(startrpc.PhaseFlush, "api pushgateway", p.StopPushGateway)
+		}
 		go func() {
-			p := ginprom.NewPrometheus("app", prommetrics.GetGinCusMetrics("Api"))
-			p.SetListenAddress(fmt.Sprintf(":%d", prometheusPort))
-			if err = p.Use(router); err != nil && err != http.ErrServerClosed {
-				netErr = errs.WrapMsg(err, fmt.Sprintf("prometheus start err: %d", prometheusPort))
-				netDone <- struct{}{}
+			if err := p.SetMetricsPathWithMiddleware(router, prommetrics.GinAuth(&config.API.Prometheus.Auth)...); err != nil && err != http.ErrServerClosed {
+				errCh <- errs.WrapMsg(err, fmt.Sprintf("prometheus start err: %d", prometheusPort))
 			}
 		}()
 
@@ -82,30 +142,12 @@ func Start(ctx context.Context, index int, config *Config) error {
 
 	server := http.Server{Addr: address, Handler: router}
 	log.CInfo(ctx, "API server is initializing", "address", address, "apiPort", apiPort, "prometheusPort", prometheusPort)
+	lifecycle.OnStop(startrpc.PhaseDrain, "api", server.Shutdown)
 	go func() {
-		err = server.ListenAndServe()
+		err := server.ListenAndServe()
 		if err != nil && err != http.ErrServerClosed {
-			netErr = errs.WrapMsg(err, fmt.Sprintf("api start err: %s", server.Addr))
-			netDone <- struct{}{}
-
+			errCh <- errs.WrapMsg(err, fmt.Sprintf("api start err: %s", server.Addr))
 		}
 	}()
-
-	sigs := make(chan os.Signal, 1)
-	signal.Notify(sigs, syscall.SIGTERM)
-
-	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
-	defer cancel()
-	select {
-	case <-sigs:
-		program.SIGTERMExit()
-		err := server.Shutdown(ctx)
-		if err != nil {
-			return errs.WrapMsg(err, "shutdown err")
-		}
-	case <-netDone:
-		close(netDone)
-		return netErr
-	}
 	return nil
 }
@@ -0,0 +1,74 @@
+// Copyright © 2024 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/openimsdk/openim-project-template/pkg/common/config"
+	"github.com/openimsdk/openim-project-template/pkg/common/openapi"
+	"github.com/openimsdk/openim-project-template/pkg/common/servererrs"
+	"github.com/openimsdk/tools/utils/datautil"
+)
+
+// Routes returns the routes of the API served with a2r.Call, in registration order.
+func Routes() []openapi.Route {
+	return datautil.Slice(apiRoutes(nil, nil), func(r apiRoute) openapi.Route { return r.Route })
+}
+
+// OpenAPI returns the OpenAPI document of the routes of the API.
+func OpenAPI() *openapi.Document {
+	version := config.Version
+	if version == "" {
+		version = "unknown"
+	}
+	return openapi.New(openapi.Info{Title: "OpenIM API", Version: version}, Routes(), servererrs.Predefined())
+}
+
+// registerOpenAPI serves the OpenAPI document at /openapi.json and, with swaggerUI, a page browsing it at /swagger.
+func registerOpenAPI(r *gin.Engine, swaggerUI bool) {
+	doc, err := json.Marshal(OpenAPI())
+	if err != nil {
+		// The document only holds strings, maps and slices.
+		panic(err)
+	}
+	r.GET("/openapi.json", func(c *gin.Context) {
+		c.Data(http.StatusOK, "application/json", doc)
+	})
+	if swaggerUI {
+		r.GET("/swagger", func(c *gin.Context) {
+			c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(swaggerPage))
+		})
+	}
+}
+
+const swaggerPage = `<!DOCTYPE html>
+<html>
+<head>
+  <meta charset="utf-8">
+  <title>OpenIM API</title>
+  <link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@5/swagger-ui.css">
+</head>
+<body>
+<div id="swagger-ui"></div>
+<script src="https://unpkg.com/swagger-ui-dist@5/swagger-ui-bundle.js"></script>
+<script>
+  window.ui = SwaggerUIBundle({url: "openapi.json", dom_id: "#swagger-ui"});
+</script>
+</body>
+</html>
+`
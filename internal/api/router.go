@@ -1,14 +1,19 @@
 package api
 
 import (
-	"fmt"
+	"net/http"
+
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v4"
+	"github.com/openimsdk/openim-project-template/pkg/common/audit"
+	"github.com/openimsdk/openim-project-template/pkg/common/openapi"
+	"github.com/openimsdk/openim-project-template/pkg/common/tracing"
+	pbaudit "github.com/openimsdk/openim-project-template/pkg/protocol/audit"
+	"github.com/openimsdk/openim-project-template/pkg/protocol/user"
 	"github.com/openimsdk/openim-project-template/pkg/rpcclient"
 	"github.com/openimsdk/tools/discovery"
 	"github.com/openimsdk/tools/mw"
-	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials/insecure"
+	"github.com/openimsdk/tools/system/program"
 )
 
 // Whitelist api not parse token
@@ -22,20 +27,60 @@ func secretKey(secret string) jwt.Keyfunc {
 	}
 }
 
-func newGinRouter(disCov discovery.SvcDiscoveryRegistry, config *Config) *gin.Engine {
-	disCov.AddOption(mw.GrpcClient(), grpc.WithTransportCredentials(insecure.NewCredentials()),
-		grpc.WithDefaultServiceConfig(fmt.Sprintf(`{"LoadBalancingPolicy": "%s"}`, "round_robin")))
+// newGinRouter builds the API router, middleware is installed before the routes after the common middlewares.
+func newGinRouter(disCov discovery.SvcDiscoveryRegistry, config *Config, health *HealthApi, middleware ...gin.HandlerFunc) *gin.Engine {
 	gin.SetMode(gin.ReleaseMode)
 	r := gin.New()
-	r.Use(gin.Recovery(), mw.CorsHandler(), mw.GinParseOperationID(), mw.GinParseToken(secretKey(config.API.Secret), whitelist))
+	// Handlers pass the gin.Context to RPC clients, it must expose the span of the request context.
+	r.ContextWithFallback = true
+	// Probes are registered before the middlewares, they carry neither operationID nor token
+	r.GET("/healthz", health.Healthz)
+	r.GET("/readyz", health.Readyz)
+	registerOpenAPI(r, config.API.Debug.SwaggerUI)
+	r.Use(gin.Recovery(), mw.CorsHandler(), mw.GinParseOperationID(), audit.GinClientIP())
+	r.Use(middleware...)
+	r.Use(tracing.GinMiddlewares(program.GetProcessName())...)
+	r.Use(mw.GinParseToken(secretKey(config.API.Secret), whitelist))
 	// init rpc client here
 	userRpc := rpcclient.NewUser(disCov, config.Share.RpcRegisterName.User)
 
 	u := NewUserApi(*userRpc)
-	userRouterGroup := r.Group("/user")
-	{
-		userRouterGroup.POST("/user_register", u.UserRegister)
-		userRouterGroup.POST("/get_users_info", u.GetUsersPublicInfo)
+	a := NewAuditApi(*rpcclient.NewAudit(disCov, config.Share.RpcRegisterName.Audit))
+	for _, route := range apiRoutes(&u, &a) {
+		r.Handle(route.Method, route.Path, route.handler)
 	}
 	return r
 }
+
+// apiRoute is a route served with a2r.Call, or streaming NDJSON, described by the messages of the RPC it calls.
+type apiRoute struct {
+	openapi.Route
+	handler gin.HandlerFunc
+}
+
+// apiRoutes lists the routes of newGinRouter. The OpenAPI document is built from the routes of nil APIs,
+// whose handlers are never called.
+func apiRoutes(u *UserApi, a *AuditApi) []apiRoute {
+	return []apiRoute{
+		{
+			Route: openapi.Route{Method: http.MethodPost, Path: "/user/user_register", Summary: "Register users",
+				Req: &user.UserRegisterReq{}, Resp: &user.UserRegisterResp{}},
+			handler: u.UserRegister,
+		},
+		{
+			Route: openapi.Route{Method: http.MethodPost, Path: "/user/get_users_info", Summary: "Get the information of users",
+				Req: &user.GetDesignateUsersReq{}, Resp: &user.GetDesignateUsersResp{}},
+			handler: u.GetUsersPublicInfo,
+		},
+		{
+			Route: openapi.Route{Method: http.MethodPost, Path: "/user/stream_users", Summary: "Stream the users of an ID list or a filter as NDJSON",
+				Req: &user.StreamUsersReq{}, Resp: &user.UserInfo{}, Stream: true},
+			handler: u.StreamUsers,
+		},
+		{
+			Route: openapi.Route{Method: http.MethodPost, Path: "/audit/search", Summary: "Search the audit log by actor, target and time range, newest first",
+				Req: &pbaudit.SearchAuditLogsReq{}, Resp: &pbaudit.SearchAuditLogsResp{}},
+			handler: a.SearchAuditLogs,
+		},
+	}
+}
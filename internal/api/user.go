@@ -15,12 +15,20 @@
 package api
 
 import (
+	"encoding/json"
+	"net/http"
+
 	"github.com/gin-gonic/gin"
 	"github.com/openimsdk/openim-project-template/pkg/protocol/user"
 	"github.com/openimsdk/openim-project-template/pkg/rpcclient"
 	"github.com/openimsdk/tools/a2r"
+	"github.com/openimsdk/tools/apiresp"
+	"github.com/openimsdk/tools/errs"
+	"github.com/openimsdk/tools/mw/specialerror"
 )
 
+const ndjsonContentType = "application/x-ndjson"
+
 type UserApi rpcclient.User
 
 func NewUserApi(client rpcclient.User) UserApi {
@@ -34,3 +42,47 @@ func (u *UserApi) UserRegister(c *gin.Context) {
 func (u *UserApi) GetUsersPublicInfo(c *gin.Context) {
 	a2r.Call(user.UserClient.GetDesignateUsers, u.Client, c)
 }
+
+// StreamUsers writes the users of the request as NDJSON, a UserInfo per line, flushed for each chunk of the
+// RPC stream so that exports are not held in memory. An error before the first user is answered with the
+// envelope, a later error ends the stream with the envelope as its last line.
+func (u *UserApi) StreamUsers(c *gin.Context) {
+	var req user.StreamUsersReq
+	if err := c.BindJSON(&req); err != nil {
+		apiresp.GinError(c, errs.ErrArgs.WrapMsg(err.Error()))
+		return
+	}
+	client := rpcclient.UserRpcClient(*u)
+	encoder := json.NewEncoder(c.Writer)
+	var started bool
+	start := func() {
+		if !started {
+			started = true
+			c.Header("Content-Type", ndjsonContentType)
+			c.Status(http.StatusOK)
+			c.Writer.WriteHeaderNow()
+		}
+	}
+	err := client.StreamUsers(c, &req, func(users []*user.UserInfo) error {
+		start()
+		for _, userInfo := range users {
+			if err := encoder.Encode(userInfo); err != nil {
+				return errs.WrapMsg(err, "write users failed")
+			}
+		}
+		c.Writer.Flush()
+		return nil
+	})
+	switch {
+	case err == nil:
+		start()
+	case !started:
+		apiresp.GinError(c, err)
+	default:
+		resp := apiresp.ApiResponse{ErrCode: errs.ServerInternalError, ErrMsg: err.Error()}
+		if code := specialerror.ErrCode(errs.Unwrap(err)); code != nil {
+			resp = apiresp.ApiResponse{ErrCode: code.Code(), ErrMsg: code.Msg(), ErrDlt: err.Error()}
+		}
+		_ = encoder.Encode(resp)
+	}
+}
@@ -0,0 +1,98 @@
+// Copyright © 2024 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package audit
+
+import (
+	"context"
+	"time"
+
+	"github.com/openimsdk/openim-project-template/pkg/common/cmd"
+	"github.com/openimsdk/openim-project-template/pkg/common/config"
+	"github.com/openimsdk/openim-project-template/pkg/common/convert"
+	"github.com/openimsdk/openim-project-template/pkg/common/startrpc"
+	"github.com/openimsdk/openim-project-template/pkg/common/storage/database"
+	"github.com/openimsdk/openim-project-template/pkg/common/storage/database/mgo"
+	pbaudit "github.com/openimsdk/openim-project-template/pkg/protocol/audit"
+	"github.com/openimsdk/tools/errs"
+	"github.com/openimsdk/tools/mcontext"
+	"github.com/openimsdk/tools/utils/datautil"
+	"google.golang.org/grpc"
+)
+
+type auditServer struct {
+	db    database.Audit
+	share *config.Share
+}
+
+type Config struct {
+	Rpc config.AuditRPC
+}
+
+// NewService describes the audit RPC service for startrpc, it serves the audit log to admins.
+func NewService() *startrpc.Service {
+	var conf Config
+	return &startrpc.Service{
+		Name: "audit",
+		ConfigFiles: map[string]any{
+			cmd.OpenIMRPCAuditCfgFileName: &conf.Rpc,
+		},
+		RPC:        &conf.Rpc.RPC,
+		Prometheus: &conf.Rpc.Prometheus,
+		RegisterName: func(share *config.Share) string {
+			return share.RpcRegisterName.Audit
+		},
+		Needs:     startrpc.Needs{Mongo: true},
+		MongoOnly: true,
+		Start: func(ctx context.Context, deps *startrpc.Dependencies, server *grpc.Server) error {
+			return Start(ctx, deps, server)
+		},
+	}
+}
+
+func Start(ctx context.Context, deps *startrpc.Dependencies, server *grpc.Server) error {
+	if deps.Mongo == nil {
+		return errs.New("audit service needs mongo, it is unavailable in memory mode").Wrap()
+	}
+	auditDB, err := mgo.NewAuditMongo(deps.Mongo.GetDB())
+	if err != nil {
+		return err
+	}
+	pbaudit.RegisterAuditServer(server, &auditServer{
+		db:    auditDB,
+		share: deps.Share,
+	})
+	return nil
+}
+
+func (s *auditServer) SearchAuditLogs(ctx context.Context, req *pbaudit.SearchAuditLogsReq) (*pbaudit.SearchAuditLogsResp, error) {
+	if !datautil.Contain(mcontext.GetOpUserID(ctx), s.share.IMAdminUserID...) {
+		return nil, errs.ErrNoPermission.WrapMsg("only admins can search the audit log")
+	}
+	var start, end time.Time
+	if req.StartTime > 0 {
+		start = time.UnixMilli(req.StartTime)
+	}
+	if req.EndTime > 0 {
+		end = time.UnixMilli(req.EndTime)
+	}
+	total, logs, err := s.db.Search(ctx, req.ActorUserID, req.TargetID, start, end, req.Pagination)
+	if err != nil {
+		return nil, err
+	}
+	return &pbaudit.SearchAuditLogsResp{
+		Total: total,
+		Logs:  convert.AuditLogsDB2Pb(logs),
+	}, nil
+}
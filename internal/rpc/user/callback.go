@@ -0,0 +1,99 @@
+// Copyright © 2024 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package user
+
+import (
+	"context"
+
+	"github.com/openimsdk/openim-project-template/pkg/callbackstruct"
+	"github.com/openimsdk/openim-project-template/pkg/common/config"
+	pbuser "github.com/openimsdk/openim-project-template/pkg/protocol/user"
+	"github.com/openimsdk/tools/mcontext"
+	"github.com/openimsdk/tools/utils/datautil"
+)
+
+// webhookBeforeUserRegister lets the webhook reject the registration of req.Users or rewrite their nicknames.
+func (s *userServer) webhookBeforeUserRegister(ctx context.Context, before *config.BeforeConfig, req *pbuser.UserRegisterReq) error {
+	cbReq := &callbackstruct.CallbackBeforeUserRegisterReq{
+		CommonCallbackReq: callbackstruct.CommonCallbackReq{
+			CallbackCommand: callbackstruct.CallbackBeforeUserRegisterCommand,
+			OperationID:     mcontext.GetOperationID(ctx),
+		},
+		Users: callbackUsers(req.Users),
+	}
+	resp := &callbackstruct.CallbackBeforeUserRegisterResp{}
+	if err := s.webhookClient.SyncPost(ctx, cbReq.GetCallbackCommand(), cbReq, resp, before); err != nil {
+		return err
+	}
+	rewrites := datautil.SliceToMap(resp.Users, func(e *callbackstruct.UserInfo) string { return e.UserID })
+	for _, user := range req.Users {
+		if rewrite, ok := rewrites[user.UserID]; ok && rewrite.Nickname != nil {
+			user.Nickname = *rewrite.Nickname
+		}
+	}
+	return nil
+}
+
+// webhookAfterUserRegister notifies the webhook of the registration of req.Users.
+func (s *userServer) webhookAfterUserRegister(ctx context.Context, after *config.AfterConfig, req *pbuser.UserRegisterReq) {
+	cbReq := &callbackstruct.CallbackAfterUserRegisterReq{
+		CommonCallbackReq: callbackstruct.CommonCallbackReq{
+			CallbackCommand: callbackstruct.CallbackAfterUserRegisterCommand,
+			OperationID:     mcontext.GetOperationID(ctx),
+		},
+		Users: callbackUsers(req.Users),
+	}
+	s.webhookClient.AsyncPost(ctx, cbReq.GetCallbackCommand(), cbReq, &callbackstruct.CallbackAfterUserRegisterResp{}, after)
+}
+
+// webhookBeforeUpdateUserInfo lets the webhook reject the update of req.UserInfo or rewrite its nickname.
+func (s *userServer) webhookBeforeUpdateUserInfo(ctx context.Context, before *config.BeforeConfig, req *pbuser.UpdateUserInfoReq) error {
+	cbReq := &callbackstruct.CallbackBeforeUpdateUserInfoReq{
+		CommonCallbackReq: callbackstruct.CommonCallbackReq{
+			CallbackCommand: callbackstruct.CallbackBeforeUpdateUserInfoCommand,
+			OperationID:     mcontext.GetOperationID(ctx),
+		},
+		User: callbackUser(req.UserInfo),
+	}
+	resp := &callbackstruct.CallbackBeforeUpdateUserInfoResp{}
+	if err := s.webhookClient.SyncPost(ctx, cbReq.GetCallbackCommand(), cbReq, resp, before); err != nil {
+		return err
+	}
+	if resp.Nickname != nil {
+		req.UserInfo.Nickname = *resp.Nickname
+	}
+	return nil
+}
+
+// webhookAfterUpdateUserInfo notifies the webhook of the update of req.UserInfo.
+func (s *userServer) webhookAfterUpdateUserInfo(ctx context.Context, after *config.AfterConfig, req *pbuser.UpdateUserInfoReq) {
+	cbReq := &callbackstruct.CallbackAfterUpdateUserInfoReq{
+		CommonCallbackReq: callbackstruct.CommonCallbackReq{
+			CallbackCommand: callbackstruct.CallbackAfterUpdateUserInfoCommand,
+			OperationID:     mcontext.GetOperationID(ctx),
+		},
+		User: callbackUser(req.UserInfo),
+	}
+	s.webhookClient.AsyncPost(ctx, cbReq.GetCallbackCommand(), cbReq, &callbackstruct.CallbackAfterUpdateUserInfoResp{}, after)
+}
+
+func callbackUsers(users []*pbuser.UserInfo) []*callbackstruct.UserInfo {
+	return datautil.Slice(users, callbackUser)
+}
+
+func callbackUser(user *pbuser.UserInfo) *callbackstruct.UserInfo {
+	nickname := user.Nickname
+	return &callbackstruct.UserInfo{UserID: user.UserID, Nickname: &nickname}
+}
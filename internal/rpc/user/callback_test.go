@@ -0,0 +1,70 @@
+// Copyright © 2024 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package user
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/openimsdk/openim-project-template/pkg/common/config"
+	"github.com/openimsdk/openim-project-template/pkg/common/webhook"
+	pbuser "github.com/openimsdk/openim-project-template/pkg/protocol/user"
+)
+
+// newWebhookServer returns a user server whose webhooks are answered with answer.
+func newWebhookServer(t *testing.T, answer string) *userServer {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(answer))
+	}))
+	t.Cleanup(srv.Close)
+	client := webhook.NewWebhookClient(&config.Webhooks{URL: srv.URL})
+	t.Cleanup(func() { _ = client.Close(context.Background()) })
+	return &userServer{webhookClient: client}
+}
+
+func TestWebhookBeforeUserRegisterRewritesNicknames(t *testing.T) {
+	s := newWebhookServer(t, `{"users":[{"userID":"u1","nickname":"renamed"},{"userID":"u2"},{"userID":"u3","nickname":""}]}`)
+	req := &pbuser.UserRegisterReq{Users: []*pbuser.UserInfo{
+		{UserID: "u1", Nickname: "one"},
+		{UserID: "u2", Nickname: "two"},
+		{UserID: "u3", Nickname: "three"},
+		{UserID: "u4", Nickname: "four"},
+	}}
+	if err := s.webhookBeforeUserRegister(context.Background(), &config.BeforeConfig{Enable: true}, req); err != nil {
+		t.Fatal(err)
+	}
+	// Only the nicknames set in the answer are rewritten, an empty one included.
+	want := []string{"renamed", "two", "", "four"}
+	for i, user := range req.Users {
+		if user.Nickname != want[i] {
+			t.Errorf("nickname of %s is %q, want %q", user.UserID, user.Nickname, want[i])
+		}
+	}
+}
+
+func TestWebhookBeforeUpdateUserInfoRewritesNickname(t *testing.T) {
+	for answer, want := range map[string]string{`{"nickname":"renamed"}`: "renamed", `{}`: "one"} {
+		s := newWebhookServer(t, answer)
+		req := &pbuser.UpdateUserInfoReq{UserInfo: &pbuser.UserInfo{UserID: "u1", Nickname: "one"}}
+		if err := s.webhookBeforeUpdateUserInfo(context.Background(), &config.BeforeConfig{Enable: true}, req); err != nil {
+			t.Fatal(err)
+		}
+		if req.UserInfo.Nickname != want {
+			t.Errorf("answer %s: nickname %q, want %q", answer, req.UserInfo.Nickname, want)
+		}
+	}
+}
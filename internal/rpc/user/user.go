@@ -16,53 +16,167 @@ package user
 
 import (
 	"context"
+	"github.com/openimsdk/openim-project-template/pkg/common/audit"
+	"github.com/openimsdk/openim-project-template/pkg/common/cmd"
 	"github.com/openimsdk/openim-project-template/pkg/common/config"
 	"github.com/openimsdk/openim-project-template/pkg/common/convert"
+	"github.com/openimsdk/openim-project-template/pkg/common/outbox"
 	"github.com/openimsdk/openim-project-template/pkg/common/prommetrics"
+	"github.com/openimsdk/openim-project-template/pkg/common/startrpc"
+	"github.com/openimsdk/openim-project-template/pkg/common/storage/cache"
+	"github.com/openimsdk/openim-project-template/pkg/common/storage/cache/passthrough"
 	"github.com/openimsdk/openim-project-template/pkg/common/storage/cache/redis"
 	"github.com/openimsdk/openim-project-template/pkg/common/storage/controller"
+	"github.com/openimsdk/openim-project-template/pkg/common/storage/database"
+	"github.com/openimsdk/openim-project-template/pkg/common/storage/database/memory"
 	"github.com/openimsdk/openim-project-template/pkg/common/storage/database/mgo"
 	"github.com/openimsdk/openim-project-template/pkg/common/storage/model"
+	"github.com/openimsdk/openim-project-template/pkg/common/webhook"
 	pbuser "github.com/openimsdk/openim-project-template/pkg/protocol/user"
-	"github.com/openimsdk/tools/db/mongoutil"
 	registry "github.com/openimsdk/tools/discovery"
 	"github.com/openimsdk/tools/errs"
+	"github.com/openimsdk/tools/mcontext"
+	"github.com/openimsdk/tools/mw/specialerror"
 	"github.com/openimsdk/tools/utils/datautil"
 	"google.golang.org/grpc"
+	"strconv"
 	"strings"
+	"time"
+)
+
+const (
+	defaultStreamChunkSize = 500
+	maxStreamChunkSize     = 5000
 )
 
 type userServer struct {
 	userStorageHandler controller.User
 	RegisterCenter     registry.SvcDiscoveryRegistry
 	config             *Config
+	webhookClient      *webhook.Client
+	share              *config.Share
 }
 
 type Config struct {
-	Rpc       config.User
-	Mongo     config.Mongo
-	Discovery config.Discovery
-	Share     config.Share
+	Rpc      config.User
+	Webhooks config.Webhooks
+}
+
+// NewService describes the user RPC service for startrpc.
+func NewService() *startrpc.Service {
+	var conf Config
+	return &startrpc.Service{
+		Name: "user",
+		ConfigFiles: map[string]any{
+			cmd.OpenIMRPCUserCfgFileName: &conf.Rpc,
+			cmd.WebhooksConfigFileName:   &conf.Webhooks,
+		},
+		RPC:        &conf.Rpc.RPC,
+		Prometheus: &conf.Rpc.Prometheus,
+		RegisterName: func(share *config.Share) string {
+			return share.RpcRegisterName.User
+		},
+		Needs: startrpc.Needs{Mongo: true, RedisCache: true, Audit: true},
+		Audit: map[string]audit.Method{
+			"/openim.user.user/UserRegister": {
+				Action: "user.register",
+				Targets: func(req any) []string {
+					return datautil.Slice(req.(*pbuser.UserRegisterReq).Users, func(e *pbuser.UserInfo) string { return e.UserID })
+				},
+			},
+			"/openim.user.user/UpdateUserInfo": {
+				Action: "user.update",
+				Targets: func(req any) []string {
+					return []string{req.(*pbuser.UpdateUserInfoReq).GetUserInfo().GetUserID()}
+				},
+			},
+		},
+		Start: func(ctx context.Context, deps *startrpc.Dependencies, server *grpc.Server) error {
+			return Start(ctx, &conf, deps, server)
+		},
+	}
 }
 
-func Start(ctx context.Context, config *Config, client registry.SvcDiscoveryRegistry, server *grpc.Server) error {
-	mgoCli, err := mongoutil.NewMongoDB(ctx, config.Mongo.Build())
+func Start(ctx context.Context, config *Config, deps *startrpc.Dependencies, server *grpc.Server) error {
+	if deps.Mongo == nil {
+		return startInMemory(ctx, config, deps, server)
+	}
+	userDB, err := mgo.NewUserMongo(deps.Mongo.GetDB())
 	if err != nil {
 		return err
 	}
-
-	userDB, err := mgo.NewUserMongo(mgoCli.GetDB())
+	userCache := redis.NewUser(deps.Redis, userDB, redis.GetRocksCacheOptions())
+	events, err := startOutbox(ctx, &config.Rpc.Outbox, deps)
 	if err != nil {
 		return err
 	}
-	userCache := redis.NewUser(userDB)
-	database := controller.NewUser(userDB, userCache, mgoCli.GetTx())
+	if err := startCacheWatcher(ctx, &config.Rpc.CacheWatcher, deps, userCache, events); err != nil {
+		return err
+	}
+	register(ctx, config, deps, server, controller.NewUser(userDB, userCache, events, deps.Mongo.GetTx()))
+	return nil
+}
+
+// startInMemory serves the users from process memory for the memory mode of mongodb.yml. The outbox and the
+// cache watcher are built on Mongo and are refused.
+func startInMemory(ctx context.Context, config *Config, deps *startrpc.Dependencies, server *grpc.Server) error {
+	if config.Rpc.Outbox.Enable {
+		return errs.New("outbox needs mongo, disable it in memory mode").Wrap()
+	}
+	if config.Rpc.CacheWatcher.Enable {
+		return errs.New("cache watcher needs mongo, disable it in memory mode").Wrap()
+	}
+	userDB := memory.NewUser()
+	register(ctx, config, deps, server, controller.NewUser(userDB, passthrough.NewUser(userDB), nil, memory.NewTx()))
+	return nil
+}
+
+func register(ctx context.Context, config *Config, deps *startrpc.Dependencies, server *grpc.Server, database controller.User) {
+	webhookClient := webhook.NewWebhookClient(&config.Webhooks)
+	startrpc.RegisterStopHook(ctx, startrpc.PhaseFlush, "webhook client", webhookClient.Close)
 	u := &userServer{
 		userStorageHandler: database,
-		RegisterCenter:     client,
+		RegisterCenter:     deps.Discovery,
 		config:             config,
+		webhookClient:      webhookClient,
+		share:              deps.Share,
 	}
 	pbuser.RegisterUserServer(server, u)
+}
+
+// startOutbox starts the relay of the user events when the outbox is enabled and returns the outbox to write them to.
+// It returns nil when the outbox is disabled.
+func startOutbox(ctx context.Context, conf *config.Outbox, deps *startrpc.Dependencies) (database.Outbox, error) {
+	if !conf.Enable {
+		return nil, nil
+	}
+	events, err := mgo.NewOutboxMongo(deps.Mongo.GetDB())
+	if err != nil {
+		return nil, err
+	}
+	sinks, err := outbox.NewSinks(conf, deps.Redis)
+	if err != nil {
+		return nil, err
+	}
+	relay := outbox.NewRelay(conf, events, sinks...)
+	relay.Start()
+	startrpc.RegisterStopHook(ctx, startrpc.PhaseFlush, "outbox relay", relay.Stop)
+	return events, nil
+}
+
+// startCacheWatcher starts following the changes of the user collection when enabled, so that the cache entries
+// of users changed around the RPC are deleted. It stops before the outbox relay since it writes events.
+func startCacheWatcher(ctx context.Context, conf *config.CacheWatcher, deps *startrpc.Dependencies, userCache cache.User, events database.Outbox) error {
+	if !conf.Enable {
+		return nil
+	}
+	tokens, err := mgo.NewChangeStreamTokenMongo(deps.Mongo.GetDB())
+	if err != nil {
+		return err
+	}
+	watcher := mgo.NewUserWatcher(deps.Mongo.GetDB(), tokens, time.Duration(conf.LeaseTTL)*time.Second, userChangeHandler(userCache, events))
+	watcher.Start()
+	startrpc.RegisterStopHook(ctx, startrpc.PhaseDrain, "user cache watcher", watcher.Stop)
 	return nil
 }
 
@@ -79,6 +193,11 @@ func (s *userServer) GetDesignateUsers(ctx context.Context, req *pbuser.GetDesig
 
 func (s *userServer) UserRegister(ctx context.Context, req *pbuser.UserRegisterReq) (resp *pbuser.UserRegisterResp, err error) {
 	resp = &pbuser.UserRegisterResp{}
+	defer func() {
+		if err != nil {
+			prommetrics.UserRegisterFailedCounter.WithLabelValues(registerErrCode(err)).Inc()
+		}
+	}()
 	if len(req.Users) == 0 {
 		return nil, errs.ErrArgs.WrapMsg("users is empty")
 	}
@@ -96,6 +215,9 @@ func (s *userServer) UserRegister(ctx context.Context, req *pbuser.UserRegisterR
 		}
 		userIDs = append(userIDs, user.UserID)
 	}
+	if err := s.webhookBeforeUserRegister(ctx, &s.config.Webhooks.BeforeUserRegister, req); err != nil {
+		return nil, err
+	}
 	users := make([]*model.User, 0, len(req.Users))
 	for _, user := range req.Users {
 		users = append(users, &model.User{
@@ -108,6 +230,85 @@ func (s *userServer) UserRegister(ctx context.Context, req *pbuser.UserRegisterR
 	}
 
 	prommetrics.UserRegisterCounter.Inc()
+	s.webhookAfterUserRegister(ctx, &s.config.Webhooks.AfterUserRegister, req)
 
 	return resp, nil
 }
+
+func (s *userServer) UpdateUserInfo(ctx context.Context, req *pbuser.UpdateUserInfoReq) (*pbuser.UpdateUserInfoResp, error) {
+	if req.UserInfo == nil || req.UserInfo.UserID == "" {
+		return nil, errs.ErrArgs.WrapMsg("userID is empty")
+	}
+	if err := s.webhookBeforeUpdateUserInfo(ctx, &s.config.Webhooks.BeforeUpdateUserInfo, req); err != nil {
+		return nil, err
+	}
+	if err := s.userStorageHandler.Update(ctx, &model.User{UserID: req.UserInfo.UserID, Nickname: req.UserInfo.Nickname}); err != nil {
+		return nil, err
+	}
+	s.webhookAfterUpdateUserInfo(ctx, &s.config.Webhooks.AfterUpdateUserInfo, req)
+	return &pbuser.UpdateUserInfoResp{}, nil
+}
+
+// StreamUsers sends the users of the ID list, or of the filter when it is empty, in messages of the chunk size.
+// The users of a filter are read through a cursor, only a chunk is held in memory. Only admins can stream
+// the users of a filter, which exports the user base.
+func (s *userServer) StreamUsers(req *pbuser.StreamUsersReq, stream pbuser.User_StreamUsersServer) error {
+	chunkSize := int(req.ChunkSize)
+	switch {
+	case chunkSize < 0 || chunkSize > maxStreamChunkSize:
+		return errs.ErrArgs.WrapMsg("chunkSize out of range", "chunkSize", chunkSize, "max", maxStreamChunkSize)
+	case chunkSize == 0:
+		chunkSize = defaultStreamChunkSize
+	}
+	ctx := stream.Context()
+	if len(req.UserIDs) > 0 {
+		if req.UserIDPrefix != "" || req.Nickname != "" {
+			return errs.ErrArgs.WrapMsg("userIDs and filter are exclusive")
+		}
+		userIDs := datautil.Distinct(req.UserIDs)
+		for i := 0; i < len(userIDs); i += chunkSize {
+			users, err := s.userStorageHandler.Find(ctx, userIDs[i:min(i+chunkSize, len(userIDs))])
+			if err != nil {
+				return err
+			}
+			if len(users) == 0 {
+				continue
+			}
+			if err := stream.Send(&pbuser.StreamUsersResp{UsersInfo: convert.UsersDB2Pb(users)}); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if !datautil.Contain(mcontext.GetOpUserID(ctx), s.share.IMAdminUserID...) {
+		return errs.ErrNoPermission.WrapMsg("only admins can stream the users of a filter")
+	}
+	chunk := make([]*model.User, 0, chunkSize)
+	send := func() error {
+		if len(chunk) == 0 {
+			return nil
+		}
+		err := stream.Send(&pbuser.StreamUsersResp{UsersInfo: convert.UsersDB2Pb(chunk)})
+		chunk = chunk[:0]
+		return err
+	}
+	filter := &database.UserFilter{UserIDPrefix: req.UserIDPrefix, Nickname: req.Nickname}
+	if err := s.userStorageHandler.Scan(ctx, filter, func(user *model.User) error {
+		chunk = append(chunk, user)
+		if len(chunk) < chunkSize {
+			return nil
+		}
+		return send()
+	}); err != nil {
+		return err
+	}
+	return send()
+}
+
+// registerErrCode returns the error code of err as a metric label, errors without a code count as internal errors.
+func registerErrCode(err error) string {
+	if code := specialerror.ErrCode(errs.Unwrap(err)); code != nil {
+		return strconv.Itoa(code.Code())
+	}
+	return strconv.Itoa(errs.ServerInternalError)
+}
@@ -0,0 +1,51 @@
+// Copyright © 2024 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package user
+
+import (
+	"context"
+
+	"github.com/openimsdk/openim-project-template/pkg/common/outbox"
+	"github.com/openimsdk/openim-project-template/pkg/common/storage/cache"
+	"github.com/openimsdk/openim-project-template/pkg/common/storage/database"
+	"github.com/openimsdk/openim-project-template/pkg/common/storage/model"
+)
+
+// userChangeHandler deletes the cache entry of a changed user and, when events is not nil, publishes the
+// updates and deletions made outside the controller, in a transaction or not. Registrations and the changes
+// written with outbox events are published by the controller.
+func userChangeHandler(userCache cache.User, events database.Outbox) func(ctx context.Context, change *model.UserChange) error {
+	return func(ctx context.Context, change *model.UserChange) error {
+		if err := userCache.DelUsersInfo(change.UserID).ChainExecDel(ctx); err != nil {
+			return err
+		}
+		if events == nil || change.Operation == model.ChangeInsert || change.Published {
+			return nil
+		}
+		typ := outbox.UserUpdated
+		if change.Operation == model.ChangeDelete {
+			typ = outbox.UserDeleted
+		}
+		payload := &outbox.UserPayload{UserID: change.UserID}
+		if change.User != nil {
+			payload.Nickname = change.User.Nickname
+		}
+		event, err := outbox.NewEvent(typ, change.UserID, payload)
+		if err != nil {
+			return err
+		}
+		return events.Create(ctx, []*model.OutboxEvent{event})
+	}
+}
@@ -30,6 +30,9 @@ func Stop() {
 
 func Check() {
 	mageutil.CheckAndReportBinariesStatus()
+	if !checkHealth() {
+		os.Exit(1)
+	}
 }
 
 func Protocol() {
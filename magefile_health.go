@@ -0,0 +1,104 @@
+//go:build mage
+// +build mage
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/openimsdk/gomake/mageutil"
+	"github.com/openimsdk/openim-project-template/pkg/common/cmd"
+	"github.com/openimsdk/openim-project-template/pkg/common/config"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health/grpc_health_v1"
+)
+
+const (
+	healthConfigDir = "config"
+	healthTimeout   = 3 * time.Second
+)
+
+// checkHealth probes the readiness endpoint of every API instance and the gRPC health service of every RPC instance.
+func checkHealth() bool {
+	var (
+		apiConfig   config.API
+		userConfig  config.User
+		auditConfig config.AuditRPC
+	)
+	if err := config.Load(healthConfigDir, cmd.OpenIMAPICfgFileName, cmd.ConfigEnvPrefixMap[cmd.OpenIMAPICfgFileName], &apiConfig); err != nil {
+		mageutil.PrintRed("load api config failed " + err.Error())
+		return false
+	}
+	rpcs := []struct {
+		name     string
+		fileName string
+		rpc      *config.RPC
+		conf     any
+	}{
+		{"openim-rpc-user", cmd.OpenIMRPCUserCfgFileName, &userConfig.RPC, &userConfig},
+		{"openim-rpc-audit", cmd.OpenIMRPCAuditCfgFileName, &auditConfig.RPC, &auditConfig},
+	}
+	for _, r := range rpcs {
+		if err := config.Load(healthConfigDir, r.fileName, cmd.ConfigEnvPrefixMap[r.fileName], r.conf); err != nil {
+			mageutil.PrintRed(fmt.Sprintf("load %s config failed %s", r.name, err))
+			return false
+		}
+	}
+	healthy := true
+	for _, port := range apiConfig.Api.Ports {
+		if err := checkHTTPReady(port); err != nil {
+			mageutil.PrintRed(fmt.Sprintf("openim-api port %d is not ready: %s", port, err))
+			healthy = false
+			continue
+		}
+		mageutil.PrintGreen(fmt.Sprintf("openim-api port %d is ready", port))
+	}
+	for _, r := range rpcs {
+		for _, port := range r.rpc.Ports {
+			if err := checkGrpcServing(port); err != nil {
+				mageutil.PrintRed(fmt.Sprintf("%s port %d is not serving: %s", r.name, port, err))
+				healthy = false
+				continue
+			}
+			mageutil.PrintGreen(fmt.Sprintf("%s port %d is serving", r.name, port))
+		}
+	}
+	return healthy
+}
+
+func checkHTTPReady(port int) error {
+	client := http.Client{Timeout: healthTimeout}
+	resp, err := client.Get(fmt.Sprintf("http://%s/readyz", net.JoinHostPort("127.0.0.1", strconv.Itoa(port))))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("status %s", resp.Status)
+	}
+	return nil
+}
+
+func checkGrpcServing(port int) error {
+	ctx, cancel := context.WithTimeout(context.Background(), healthTimeout)
+	defer cancel()
+	conn, err := grpc.DialContext(ctx, net.JoinHostPort("127.0.0.1", strconv.Itoa(port)), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	resp, err := grpc_health_v1.NewHealthClient(conn).Check(ctx, &grpc_health_v1.HealthCheckRequest{})
+	if err != nil {
+		return err
+	}
+	if resp.Status != grpc_health_v1.HealthCheckResponse_SERVING {
+		return fmt.Errorf("status %s", resp.Status)
+	}
+	return nil
+}
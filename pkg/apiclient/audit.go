@@ -0,0 +1,30 @@
+// Copyright © 2024 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apiclient
+
+import (
+	"context"
+
+	pbaudit "github.com/openimsdk/openim-project-template/pkg/protocol/audit"
+)
+
+// SearchAuditLogs calls /audit/search.
+func (c *Client) SearchAuditLogs(ctx context.Context, req *pbaudit.SearchAuditLogsReq) (*pbaudit.SearchAuditLogsResp, error) {
+	resp := &pbaudit.SearchAuditLogsResp{}
+	if err := c.callIdempotent(ctx, "/audit/search", req, resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
@@ -0,0 +1,309 @@
+// Copyright © 2024 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apiclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptrace"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/openimsdk/openim-project-template/pkg/common/servererrs"
+	"github.com/openimsdk/tools/errs"
+	"github.com/openimsdk/tools/mcontext"
+)
+
+const (
+	headerOperationID = "operationID"
+	headerToken       = "token"
+)
+
+// TokenSource returns a token for the requests. It is called again after the API rejected the last token.
+type TokenSource func(ctx context.Context) (string, error)
+
+// Retry retries the requests that failed before they were sent. The requests of idempotent methods, such as
+// the queries, are also retried when they failed before the API answered, or it answered with 502, 503 or 504.
+// The attempts of a request share its operationID.
+type Retry struct {
+	// MaxAttempts includes the first attempt, 1 or less disables the retries.
+	MaxAttempts int
+	// MinBackoff is the wait before the first retry, doubled for every following one.
+	MinBackoff time.Duration
+	// MaxBackoff caps the wait between retries.
+	MaxBackoff time.Duration
+}
+
+// Option configures a Client.
+type Option func(*Client)
+
+// WithHTTPClient sends the requests with client instead of http.DefaultClient.
+func WithHTTPClient(client *http.Client) Option {
+	return func(c *Client) {
+		c.http = client
+	}
+}
+
+// WithToken sends a fixed token.
+func WithToken(token string) Option {
+	return WithTokenSource(func(ctx context.Context) (string, error) {
+		return token, nil
+	})
+}
+
+// WithTokenSource obtains the token from source, once and again when the API rejects it.
+func WithTokenSource(source TokenSource) Option {
+	return func(c *Client) {
+		c.tokenSource = source
+	}
+}
+
+// WithRetry retries the requests as described by retry.
+func WithRetry(retry Retry) Option {
+	return func(c *Client) {
+		c.retry = retry
+	}
+}
+
+// WithOperationID generates the operationID of the requests whose context carries none.
+func WithOperationID(fn func() string) Option {
+	return func(c *Client) {
+		c.operationID = fn
+	}
+}
+
+// Client calls the HTTP API, it is safe for concurrent use.
+type Client struct {
+	baseURL     string
+	http        *http.Client
+	tokenSource TokenSource
+	retry       Retry
+	operationID func() string
+
+	lock  sync.Mutex
+	token string
+}
+
+// New returns a client of the API served at baseURL, for example http://127.0.0.1:10002.
+func New(baseURL string, options ...Option) *Client {
+	c := &Client{
+		baseURL:     strings.TrimRight(baseURL, "/"),
+		http:        http.DefaultClient,
+		operationID: newOperationID,
+	}
+	for _, option := range options {
+		option(c)
+	}
+	return c
+}
+
+var operationSeq atomic.Int64
+
+func newOperationID() string {
+	return fmt.Sprintf("%d%d", time.Now().UnixMilli(), operationSeq.Add(1))
+}
+
+// envelope is the body of every API response.
+type envelope struct {
+	ErrCode int             `json:"errCode"`
+	ErrMsg  string          `json:"errMsg"`
+	ErrDlt  string          `json:"errDlt"`
+	Data    json.RawMessage `json:"data"`
+}
+
+// call posts req to path and decodes the data of the response into resp. The operationID of ctx is
+// sent when it has one. A rejected token is replaced once by the token source.
+// The request is only retried when it was not sent, as the API may have handled it otherwise.
+func (c *Client) call(ctx context.Context, path string, req, resp any) error {
+	return c.do(ctx, path, req, resp, false)
+}
+
+// callIdempotent is call for the methods whose request may be handled more than once, it is retried as
+// long as the API did not answer.
+func (c *Client) callIdempotent(ctx context.Context, path string, req, resp any) error {
+	return c.do(ctx, path, req, resp, true)
+}
+
+func (c *Client) do(ctx context.Context, path string, req, resp any, idempotent bool) error {
+	_, env, err := c.send(ctx, path, req, idempotent, false)
+	if err != nil {
+		return err
+	}
+	if resp == nil || len(env.Data) == 0 || string(env.Data) == "null" {
+		return nil
+	}
+	if err := json.Unmarshal(env.Data, resp); err != nil {
+		return errs.WrapMsg(err, "decode response data failed", "path", path)
+	}
+	return nil
+}
+
+// stream posts req to path and returns the body of the streamed response, the caller closes it.
+// An error answered before the stream started is returned as by call. The request is idempotent.
+func (c *Client) stream(ctx context.Context, path string, req any) (io.ReadCloser, error) {
+	httpResp, _, err := c.send(ctx, path, req, true, true)
+	if err != nil {
+		return nil, err
+	}
+	return httpResp.Body, nil
+}
+
+// send posts req to path and returns the envelope of the response, or with stream the response itself
+// when it is not an envelope. The operationID of ctx is sent when it has one. A rejected token is replaced
+// once by the token source.
+func (c *Client) send(ctx context.Context, path string, req any, idempotent bool, stream bool) (*http.Response, *envelope, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, nil, errs.WrapMsg(err, "marshal request failed", "path", path)
+	}
+	operationID := mcontext.GetOperationID(ctx)
+	if operationID == "" {
+		operationID = c.operationID()
+	}
+	token, err := c.getToken(ctx, "")
+	if err != nil {
+		return nil, nil, err
+	}
+	for refreshed := false; ; refreshed = true {
+		httpResp, err := c.post(ctx, path, operationID, token, body, idempotent)
+		if err != nil {
+			return nil, nil, err
+		}
+		if stream && !strings.HasPrefix(httpResp.Header.Get("Content-Type"), "application/json") {
+			return httpResp, nil, nil
+		}
+		env, err := decodeEnvelope(httpResp, path)
+		if err != nil {
+			return nil, nil, err
+		}
+		if isTokenError(env.ErrCode) && c.tokenSource != nil && !refreshed {
+			if token, err = c.getToken(ctx, token); err != nil {
+				return nil, nil, err
+			}
+			continue
+		}
+		if env.ErrCode != 0 {
+			return nil, nil, servererrs.FromCode(env.ErrCode, env.ErrMsg, env.ErrDlt)
+		}
+		return nil, env, nil
+	}
+}
+
+// getToken returns the cached token, obtaining a new one when there is none or it is rejected.
+func (c *Client) getToken(ctx context.Context, rejected string) (string, error) {
+	if c.tokenSource == nil {
+		return "", nil
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.token != "" && c.token != rejected {
+		return c.token, nil
+	}
+	token, err := c.tokenSource(ctx)
+	if err != nil {
+		return "", errs.WrapMsg(err, "get token failed")
+	}
+	c.token = token
+	return token, nil
+}
+
+// post sends the request with the retries of c and returns the response of the API, the caller closes its body.
+func (c *Client) post(ctx context.Context, path string, operationID string, token string, body []byte, idempotent bool) (*http.Response, error) {
+	backoff := c.retry.MinBackoff
+	for attempt := 1; ; attempt++ {
+		httpResp, err := c.postOnce(ctx, path, operationID, token, body)
+		var retryable *retryableError
+		if err == nil || !errors.As(err, &retryable) || (retryable.sent && !idempotent) || attempt >= c.retry.MaxAttempts {
+			return httpResp, err
+		}
+		select {
+		case <-ctx.Done():
+			return nil, errs.WrapMsg(ctx.Err(), "retry canceled", "path", path, "lastError", err.Error())
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+		if c.retry.MaxBackoff > 0 && backoff > c.retry.MaxBackoff {
+			backoff = c.retry.MaxBackoff
+		}
+	}
+}
+
+// retryableError is a failure a later attempt may not have.
+type retryableError struct {
+	err error
+	// sent tells whether the request may have reached the API.
+	sent bool
+}
+
+func (e *retryableError) Error() string { return e.err.Error() }
+
+func (e *retryableError) Unwrap() error { return e.err }
+
+func (c *Client) postOnce(ctx context.Context, path string, operationID string, token string, body []byte) (*http.Response, error) {
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+path, bytes.NewReader(body))
+	if err != nil {
+		return nil, errs.WrapMsg(err, "new request failed", "path", path)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set(headerOperationID, operationID)
+	if token != "" {
+		httpReq.Header.Set(headerToken, token)
+	}
+	// The API can not handle a request before its headers are written.
+	var sent atomic.Bool
+	httpReq = httpReq.WithContext(httptrace.WithClientTrace(ctx, &httptrace.ClientTrace{
+		WroteHeaders: func() { sent.Store(true) },
+	}))
+	httpResp, err := c.http.Do(httpReq)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, errs.WrapMsg(err, "post failed", "path", path)
+		}
+		return nil, &retryableError{err: errs.WrapMsg(err, "post failed", "path", path), sent: sent.Load()}
+	}
+	switch httpResp.StatusCode {
+	case http.StatusOK:
+		return httpResp, nil
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		_, _ = io.Copy(io.Discard, httpResp.Body)
+		httpResp.Body.Close()
+		return nil, &retryableError{err: errs.New("api unavailable", "path", path, "status", httpResp.Status).Wrap(), sent: true}
+	default:
+		httpResp.Body.Close()
+		return nil, errs.New("unexpected status", "path", path, "status", httpResp.Status).Wrap()
+	}
+}
+
+// decodeEnvelope decodes the envelope of httpResp and closes its body.
+func decodeEnvelope(httpResp *http.Response, path string) (*envelope, error) {
+	defer httpResp.Body.Close()
+	var env envelope
+	if err := json.NewDecoder(httpResp.Body).Decode(&env); err != nil {
+		return nil, errs.WrapMsg(err, "decode response failed", "path", path)
+	}
+	return &env, nil
+}
+
+// isTokenError reports whether the API rejected the token of the request.
+func isTokenError(code int) bool {
+	return code >= errs.TokenExpiredError && code <= errs.TokenNotExistError
+}
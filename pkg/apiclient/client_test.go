@@ -0,0 +1,224 @@
+// Copyright © 2024 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apiclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/openimsdk/openim-project-template/pkg/common/servererrs"
+	pbuser "github.com/openimsdk/openim-project-template/pkg/protocol/user"
+	"github.com/openimsdk/tools/errs"
+	"github.com/openimsdk/tools/mcontext"
+)
+
+func respond(w http.ResponseWriter, code int, data any) {
+	_ = json.NewEncoder(w).Encode(map[string]any{"errCode": code, "errMsg": "msg", "errDlt": "detail", "data": data})
+}
+
+func TestCallDecodesData(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/user/get_users_info" {
+			t.Errorf("path %s", r.URL.Path)
+		}
+		if r.Header.Get(headerOperationID) != "op1" {
+			t.Errorf("operationID %q, want op1", r.Header.Get(headerOperationID))
+		}
+		if r.Header.Get(headerToken) != "t1" {
+			t.Errorf("token %q, want t1", r.Header.Get(headerToken))
+		}
+		var req pbuser.GetDesignateUsersReq
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Error(err)
+		}
+		respond(w, 0, &pbuser.GetDesignateUsersResp{UsersInfo: []*pbuser.UserInfo{{UserID: req.UserIDs[0], Nickname: "one"}}})
+	}))
+	defer srv.Close()
+
+	c := New(srv.URL, WithToken("t1"))
+	resp, err := c.GetUsersInfo(mcontext.SetOperationID(context.Background(), "op1"), &pbuser.GetDesignateUsersReq{UserIDs: []string{"u1"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.UsersInfo) != 1 || resp.UsersInfo[0].UserID != "u1" || resp.UsersInfo[0].Nickname != "one" {
+		t.Fatalf("got %v", resp.UsersInfo)
+	}
+}
+
+func TestCallReturnsCodeError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		respond(w, servererrs.UserIDNotFoundError, nil)
+	}))
+	defer srv.Close()
+
+	_, err := New(srv.URL).GetUsersInfo(context.Background(), &pbuser.GetDesignateUsersReq{UserIDs: []string{"u1"}})
+	code, ok := err.(errs.CodeError)
+	if !ok {
+		t.Fatalf("got %T %v, want errs.CodeError", err, err)
+	}
+	if code.Code() != servererrs.UserIDNotFoundError || code.Detail() != "detail" {
+		t.Fatalf("got code %d detail %q", code.Code(), code.Detail())
+	}
+	if !servererrs.ErrUserIDNotFound.Is(err) {
+		t.Fatalf("%v is not ErrUserIDNotFound", err)
+	}
+}
+
+func TestCallRefreshesRejectedToken(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get(headerToken) != "fresh" {
+			respond(w, errs.TokenExpiredError, nil)
+			return
+		}
+		respond(w, 0, &pbuser.UserRegisterResp{})
+	}))
+	defer srv.Close()
+
+	var issued atomic.Int32
+	tokens := []string{"stale", "fresh"}
+	c := New(srv.URL, WithTokenSource(func(ctx context.Context) (string, error) {
+		return tokens[issued.Add(1)-1], nil
+	}))
+	for i := 0; i < 2; i++ {
+		if _, err := c.UserRegister(context.Background(), &pbuser.UserRegisterReq{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := issued.Load(); n != 2 {
+		t.Fatalf("token source called %d times, want 2", n)
+	}
+}
+
+func TestCallRetries(t *testing.T) {
+	var attempts atomic.Int32
+	operationIDs := make(chan string, 3)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		operationIDs <- r.Header.Get(headerOperationID)
+		if attempts.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		respond(w, 0, &pbuser.GetDesignateUsersResp{})
+	}))
+	defer srv.Close()
+
+	c := New(srv.URL, WithRetry(Retry{MaxAttempts: 3, MinBackoff: time.Millisecond, MaxBackoff: time.Millisecond}))
+	if _, err := c.GetUsersInfo(context.Background(), &pbuser.GetDesignateUsersReq{}); err != nil {
+		t.Fatal(err)
+	}
+	close(operationIDs)
+	first := <-operationIDs
+	for id := range operationIDs {
+		if id != first {
+			t.Fatalf("retry sent operationID %q, want %q", id, first)
+		}
+	}
+
+	attempts.Store(0)
+	c = New(srv.URL, WithRetry(Retry{MaxAttempts: 2, MinBackoff: time.Millisecond}))
+	operationIDs = make(chan string, 3)
+	if _, err := c.GetUsersInfo(context.Background(), &pbuser.GetDesignateUsersReq{}); err == nil {
+		t.Fatal("want an error after the attempts are exhausted")
+	}
+}
+
+func TestCallDoesNotRetrySentRequests(t *testing.T) {
+	var attempts atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	c := New(srv.URL, WithRetry(Retry{MaxAttempts: 3, MinBackoff: time.Millisecond}))
+	if _, err := c.UserRegister(context.Background(), &pbuser.UserRegisterReq{}); err == nil {
+		t.Fatal("want the error of the API")
+	}
+	if n := attempts.Load(); n != 1 {
+		t.Fatalf("register sent %d times, want 1", n)
+	}
+}
+
+func TestCallRetriesUnsentRequests(t *testing.T) {
+	var registered atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		registered.Add(1)
+		respond(w, 0, &pbuser.UserRegisterResp{})
+	}))
+	defer srv.Close()
+
+	// The first dial fails, the request never reaches the API.
+	var dials atomic.Int32
+	transport := &http.Transport{DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+		if dials.Add(1) == 1 {
+			return nil, errors.New("connection refused")
+		}
+		return (&net.Dialer{}).DialContext(ctx, network, addr)
+	}}
+	defer transport.CloseIdleConnections()
+	c := New(srv.URL, WithHTTPClient(&http.Client{Transport: transport}), WithRetry(Retry{MaxAttempts: 3, MinBackoff: time.Millisecond}))
+	if _, err := c.UserRegister(context.Background(), &pbuser.UserRegisterReq{}); err != nil {
+		t.Fatal(err)
+	}
+	if n := registered.Load(); n != 1 {
+		t.Fatalf("register handled %d times, want 1", n)
+	}
+}
+
+func TestStreamUsers(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		encoder := json.NewEncoder(w)
+		_ = encoder.Encode(&pbuser.UserInfo{UserID: "u1"})
+		_ = encoder.Encode(&pbuser.UserInfo{UserID: "u2"})
+		_ = encoder.Encode(map[string]any{"errCode": servererrs.UserIDNotFoundError, "errMsg": "msg", "errDlt": "detail"})
+	}))
+	defer srv.Close()
+
+	var userIDs []string
+	err := New(srv.URL).StreamUsers(context.Background(), &pbuser.StreamUsersReq{}, func(user *pbuser.UserInfo) error {
+		userIDs = append(userIDs, user.UserID)
+		return nil
+	})
+	if !servererrs.ErrUserIDNotFound.Is(err) {
+		t.Fatalf("got %v, want the error ending the stream", err)
+	}
+	if len(userIDs) != 2 || userIDs[0] != "u1" || userIDs[1] != "u2" {
+		t.Fatalf("got %v", userIDs)
+	}
+}
+
+func TestStreamUsersReturnsEnvelopeError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		respond(w, servererrs.UserIDNotFoundError, nil)
+	}))
+	defer srv.Close()
+
+	err := New(srv.URL).StreamUsers(context.Background(), &pbuser.StreamUsersReq{}, func(user *pbuser.UserInfo) error {
+		t.Errorf("unexpected user %v", user)
+		return nil
+	})
+	if !servererrs.ErrUserIDNotFound.Is(err) {
+		t.Fatalf("got %v, want ErrUserIDNotFound", err)
+	}
+}
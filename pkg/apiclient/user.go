@@ -0,0 +1,87 @@
+// Copyright © 2024 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apiclient
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+
+	"github.com/openimsdk/openim-project-template/pkg/common/servererrs"
+	pbuser "github.com/openimsdk/openim-project-template/pkg/protocol/user"
+	"github.com/openimsdk/tools/errs"
+)
+
+// UserRegister calls /user/user_register.
+func (c *Client) UserRegister(ctx context.Context, req *pbuser.UserRegisterReq) (*pbuser.UserRegisterResp, error) {
+	resp := &pbuser.UserRegisterResp{}
+	if err := c.call(ctx, "/user/user_register", req, resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// GetUsersInfo calls /user/get_users_info.
+func (c *Client) GetUsersInfo(ctx context.Context, req *pbuser.GetDesignateUsersReq) (*pbuser.GetDesignateUsersResp, error) {
+	resp := &pbuser.GetDesignateUsersResp{}
+	if err := c.callIdempotent(ctx, "/user/get_users_info", req, resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// StreamUsers calls /user/stream_users and fn with each user of the NDJSON response. An error of fn ends the
+// stream and is returned, as is the error the API ends the stream with.
+func (c *Client) StreamUsers(ctx context.Context, req *pbuser.StreamUsersReq, fn func(user *pbuser.UserInfo) error) error {
+	body, err := c.stream(ctx, "/user/stream_users", req)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		// The API ends a failed stream with the envelope of its error.
+		var end struct {
+			ErrCode *int   `json:"errCode"`
+			ErrMsg  string `json:"errMsg"`
+			ErrDlt  string `json:"errDlt"`
+		}
+		if err := json.Unmarshal(line, &end); err != nil {
+			return errs.WrapMsg(err, "decode stream line failed", "path", "/user/stream_users")
+		}
+		if end.ErrCode != nil {
+			if *end.ErrCode != 0 {
+				return servererrs.FromCode(*end.ErrCode, end.ErrMsg, end.ErrDlt)
+			}
+			continue
+		}
+		user := &pbuser.UserInfo{}
+		if err := json.Unmarshal(line, user); err != nil {
+			return errs.WrapMsg(err, "decode stream user failed", "path", "/user/stream_users")
+		}
+		if err := fn(user); err != nil {
+			return err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return errs.WrapMsg(err, "read stream failed", "path", "/user/stream_users")
+	}
+	return nil
+}
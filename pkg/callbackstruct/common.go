@@ -0,0 +1,58 @@
+// Copyright © 2024 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package callbackstruct
+
+import (
+	"github.com/openimsdk/openim-project-template/pkg/common/servererrs"
+	"github.com/openimsdk/tools/errs"
+)
+
+// CallbackReq is the body posted to a webhook.
+type CallbackReq interface {
+	GetCallbackCommand() string
+}
+
+// CallbackResp is the body answered by a webhook, Parse returns the rejection it carries.
+type CallbackResp interface {
+	Parse() (err error)
+}
+
+type CommonCallbackReq struct {
+	CallbackCommand string `json:"callbackCommand"`
+	OperationID     string `json:"operationID"`
+}
+
+func (c *CommonCallbackReq) GetCallbackCommand() string {
+	return c.CallbackCommand
+}
+
+// CommonCallbackResp rejects the operation when ActionCode or ErrCode is not 0.
+// ErrCode, ErrMsg and ErrDlt are then returned to the caller.
+type CommonCallbackResp struct {
+	ActionCode int    `json:"actionCode"`
+	ErrCode    int    `json:"errCode"`
+	ErrMsg     string `json:"errMsg"`
+	ErrDlt     string `json:"errDlt"`
+}
+
+func (c *CommonCallbackResp) Parse() error {
+	if c.ActionCode == 0 && c.ErrCode == 0 {
+		return nil
+	}
+	if c.ErrCode == 0 {
+		return servererrs.ErrCallback.WrapMsg("rejected by webhook", "errMsg", c.ErrMsg, "errDlt", c.ErrDlt)
+	}
+	return errs.NewCodeError(c.ErrCode, c.ErrMsg).WithDetail(c.ErrDlt).Wrap()
+}
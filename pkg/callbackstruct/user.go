@@ -0,0 +1,69 @@
+// Copyright © 2024 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package callbackstruct
+
+const (
+	CallbackBeforeUserRegisterCommand = "callbackBeforeUserRegisterCommand"
+	CallbackAfterUserRegisterCommand  = "callbackAfterUserRegisterCommand"
+
+	CallbackBeforeUpdateUserInfoCommand = "callbackBeforeUpdateUserInfoCommand"
+	CallbackAfterUpdateUserInfoCommand  = "callbackAfterUpdateUserInfoCommand"
+)
+
+// UserInfo is a user sent to or answered by a webhook. In answers, the fields left out are not rewritten.
+type UserInfo struct {
+	UserID   string  `json:"userID"`
+	Nickname *string `json:"nickname,omitempty"`
+}
+
+type CallbackBeforeUserRegisterReq struct {
+	CommonCallbackReq
+	Users []*UserInfo `json:"users"`
+}
+
+// CallbackBeforeUserRegisterResp may rewrite the set fields of the users to register, they are matched by userID.
+type CallbackBeforeUserRegisterResp struct {
+	CommonCallbackResp
+	Users []*UserInfo `json:"users"`
+}
+
+type CallbackAfterUserRegisterReq struct {
+	CommonCallbackReq
+	Users []*UserInfo `json:"users"`
+}
+
+type CallbackAfterUserRegisterResp struct {
+	CommonCallbackResp
+}
+
+type CallbackBeforeUpdateUserInfoReq struct {
+	CommonCallbackReq
+	User *UserInfo `json:"user"`
+}
+
+// CallbackBeforeUpdateUserInfoResp may rewrite the set fields of the update.
+type CallbackBeforeUpdateUserInfoResp struct {
+	CommonCallbackResp
+	Nickname *string `json:"nickname,omitempty"`
+}
+
+type CallbackAfterUpdateUserInfoReq struct {
+	CommonCallbackReq
+	User *UserInfo `json:"user"`
+}
+
+type CallbackAfterUpdateUserInfoResp struct {
+	CommonCallbackResp
+}
@@ -0,0 +1,206 @@
+// Copyright © 2024 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/openimsdk/openim-project-template/pkg/common/config"
+	"github.com/openimsdk/openim-project-template/pkg/common/prommetrics"
+	"github.com/openimsdk/openim-project-template/pkg/common/storage/database"
+	"github.com/openimsdk/openim-project-template/pkg/common/storage/model"
+	"github.com/openimsdk/tools/errs"
+	"github.com/openimsdk/tools/log"
+	"github.com/openimsdk/tools/mcontext"
+	"github.com/openimsdk/tools/mw/specialerror"
+)
+
+const (
+	defaultMaxRequestSize = 1024
+	queueSize             = 4096
+	batchSize             = 100
+	flushInterval         = time.Second
+	writeTimeout          = 10 * time.Second
+)
+
+// Recorder writes audit logs to the database, and to a file when configured, in the background.
+// A nil Recorder records nothing.
+type Recorder struct {
+	db             database.Audit
+	file           io.WriteCloser
+	maxRequestSize int
+
+	lock   sync.RWMutex
+	closed bool
+	logs   chan *model.AuditLog
+	done   chan struct{}
+}
+
+// NewRecorder starts a recorder writing to db, it must be closed to write the pending logs.
+func NewRecorder(conf *config.Audit, db database.Audit) (*Recorder, error) {
+	r := &Recorder{
+		db:             db,
+		maxRequestSize: conf.MaxRequestSize,
+		logs:           make(chan *model.AuditLog, queueSize),
+		done:           make(chan struct{}),
+	}
+	if r.maxRequestSize <= 0 {
+		r.maxRequestSize = defaultMaxRequestSize
+	}
+	if conf.File.Enable {
+		file, err := newRotateFile(conf.File.Path, conf.File.MaxSize, conf.File.MaxBackups)
+		if err != nil {
+			return nil, err
+		}
+		r.file = file
+	}
+	go r.run()
+	return r, nil
+}
+
+// Record logs action on targetIDs made by the operation of ctx, with a summary of req and the code of err.
+// Logs are dropped when the queue is full rather than slowing the operation down.
+func (r *Recorder) Record(ctx context.Context, action string, targetIDs []string, req any, err error) {
+	if r == nil {
+		return
+	}
+	l := &model.AuditLog{
+		ActorUserID: mcontext.GetOpUserID(ctx),
+		OperationID: mcontext.GetOperationID(ctx),
+		Action:      action,
+		TargetIDs:   targetIDs,
+		Request:     r.summary(req),
+		Code:        errCode(err),
+		IP:          ClientIP(ctx),
+		CreateTime:  time.Now(),
+	}
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	if r.closed {
+		log.ZWarn(ctx, "audit recorder is closed", nil, "action", action, "targetIDs", targetIDs)
+		prommetrics.AuditDroppedCounter.Inc()
+		return
+	}
+	select {
+	case r.logs <- l:
+	default:
+		log.ZWarn(ctx, "audit log queue is full", nil, "action", action, "targetIDs", targetIDs)
+		prommetrics.AuditDroppedCounter.Inc()
+	}
+}
+
+// Close stops recording and waits for the pending logs to be written.
+func (r *Recorder) Close(ctx context.Context) error {
+	if r == nil {
+		return nil
+	}
+	r.lock.Lock()
+	if !r.closed {
+		r.closed = true
+		close(r.logs)
+	}
+	r.lock.Unlock()
+	select {
+	case <-r.done:
+	case <-ctx.Done():
+		return errs.WrapMsg(ctx.Err(), "audit logs not written")
+	}
+	if r.file != nil {
+		return r.file.Close()
+	}
+	return nil
+}
+
+func (r *Recorder) run() {
+	defer close(r.done)
+	ticker := time.NewTicker(flushInterval)
+	defer ticker.Stop()
+	batch := make([]*model.AuditLog, 0, batchSize)
+	for {
+		select {
+		case l, ok := <-r.logs:
+			if !ok {
+				r.write(batch)
+				return
+			}
+			batch = append(batch, l)
+			if len(batch) < batchSize {
+				continue
+			}
+		case <-ticker.C:
+		}
+		r.write(batch)
+		batch = batch[:0]
+	}
+}
+
+func (r *Recorder) write(batch []*model.AuditLog) {
+	if len(batch) == 0 {
+		return
+	}
+	ctx, cancel := context.WithTimeout(mcontext.NewCtx("audit"), writeTimeout)
+	defer cancel()
+	if err := r.db.Create(ctx, batch); err != nil {
+		log.ZError(ctx, "write audit logs failed", err, "count", len(batch))
+		prommetrics.AuditDroppedCounter.Add(float64(len(batch)))
+	}
+	if r.file == nil {
+		return
+	}
+	for _, l := range batch {
+		line, err := json.Marshal(l)
+		if err != nil {
+			log.ZError(ctx, "marshal audit log failed", err, "action", l.Action)
+			continue
+		}
+		if _, err := r.file.Write(append(line, '\n')); err != nil {
+			log.ZError(ctx, "write audit log file failed", err, "action", l.Action)
+		}
+	}
+}
+
+// summary returns req as JSON, truncated to the configured size.
+func (r *Recorder) summary(req any) string {
+	if req == nil {
+		return ""
+	}
+	var s string
+	if data, err := json.Marshal(req); err == nil {
+		s = string(data)
+	} else {
+		s = fmt.Sprintf("%+v", req)
+	}
+	if len(s) > r.maxRequestSize {
+		s = strings.ToValidUTF8(s[:r.maxRequestSize], "") + "..."
+	}
+	return s
+}
+
+// errCode returns the code recorded for err, 0 for success and the internal error code for errors without one.
+func errCode(err error) int {
+	if err == nil {
+		return 0
+	}
+	if code := specialerror.ErrCode(errs.Unwrap(err)); code != nil {
+		return code.Code()
+	}
+	return errs.ServerInternalError
+}
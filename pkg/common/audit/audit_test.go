@@ -0,0 +1,108 @@
+// Copyright © 2024 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package audit
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/openimsdk/openim-project-template/pkg/common/config"
+	"github.com/openimsdk/openim-project-template/pkg/common/storage/model"
+	"github.com/openimsdk/tools/db/pagination"
+)
+
+type memoryAudit struct {
+	lock sync.Mutex
+	logs []*model.AuditLog
+}
+
+func (m *memoryAudit) Create(ctx context.Context, logs []*model.AuditLog) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.logs = append(m.logs, logs...)
+	return nil
+}
+
+func (m *memoryAudit) Search(ctx context.Context, actorUserID string, targetID string, start time.Time, end time.Time, pagination pagination.Pagination) (int64, []*model.AuditLog, error) {
+	return 0, nil, nil
+}
+
+func (m *memoryAudit) actions() []string {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	actions := make([]string, 0, len(m.logs))
+	for _, l := range m.logs {
+		actions = append(actions, l.Action)
+	}
+	return actions
+}
+
+func TestRecorderQueueFull(t *testing.T) {
+	db := &memoryAudit{}
+	// Not running, so that the queue is not drained.
+	r := &Recorder{db: db, maxRequestSize: defaultMaxRequestSize, logs: make(chan *model.AuditLog, 1), done: make(chan struct{})}
+	r.Record(context.Background(), "queued", nil, nil, nil)
+	r.Record(context.Background(), "dropped", nil, nil, nil)
+	if len(r.logs) != 1 {
+		t.Fatalf("queue has %d logs, want 1", len(r.logs))
+	}
+	go r.run()
+	if err := r.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if actions := db.actions(); len(actions) != 1 || actions[0] != "queued" {
+		t.Errorf("written %v, want [queued]", actions)
+	}
+}
+
+func TestRecorderClose(t *testing.T) {
+	db := &memoryAudit{}
+	r, err := NewRecorder(&config.Audit{}, db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.Record(context.Background(), "before", []string{"u1"}, map[string]string{"userID": "u1"}, nil)
+	if err := r.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	// Records after Close are dropped, not sent on the closed queue.
+	r.Record(context.Background(), "after", nil, nil, nil)
+	if err := r.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if actions := db.actions(); len(actions) != 1 || actions[0] != "before" {
+		t.Errorf("written %v, want [before]", actions)
+	}
+}
+
+func TestRecorderSummary(t *testing.T) {
+	r := &Recorder{maxRequestSize: 8}
+	if s := r.summary(map[string]string{"userID": "u1"}); s != `{"userID...` {
+		t.Errorf("summary = %q", s)
+	}
+	if s := r.summary(nil); s != "" {
+		t.Errorf("summary of nil = %q", s)
+	}
+}
+
+func TestNilRecorder(t *testing.T) {
+	var r *Recorder
+	r.Record(context.Background(), "user.register", nil, nil, nil)
+	if err := r.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+}
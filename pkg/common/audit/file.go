@@ -0,0 +1,107 @@
+// Copyright © 2024 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package audit
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/openimsdk/tools/errs"
+)
+
+const (
+	defaultFileMaxSize    = 100
+	defaultFileMaxBackups = 10
+)
+
+// rotateFile appends to path, renaming it to path.1 when it reaches maxSize bytes, and path.1 to path.2
+// and so on up to maxBackups files. It is not safe for concurrent use.
+type rotateFile struct {
+	path       string
+	maxSize    int64
+	maxBackups int
+	file       *os.File
+	size       int64
+}
+
+// newRotateFile opens path for appending, maxSizeMB and maxBackups default when not positive.
+func newRotateFile(path string, maxSizeMB int64, maxBackups int) (*rotateFile, error) {
+	if maxSizeMB <= 0 {
+		maxSizeMB = defaultFileMaxSize
+	}
+	if maxBackups <= 0 {
+		maxBackups = defaultFileMaxBackups
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, errs.WrapMsg(err, "create audit log dir failed", "path", path)
+	}
+	f := &rotateFile{path: path, maxSize: maxSizeMB << 20, maxBackups: maxBackups}
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func (f *rotateFile) open() error {
+	file, err := os.OpenFile(f.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return errs.WrapMsg(err, "open audit log file failed", "path", f.path)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return errs.WrapMsg(err, "stat audit log file failed", "path", f.path)
+	}
+	f.file, f.size = file, info.Size()
+	return nil
+}
+
+func (f *rotateFile) Write(p []byte) (int, error) {
+	if f.size > 0 && f.size+int64(len(p)) > f.maxSize {
+		if err := f.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+	if err != nil {
+		return n, errs.WrapMsg(err, "write audit log file failed", "path", f.path)
+	}
+	return n, nil
+}
+
+func (f *rotateFile) rotate() error {
+	if err := f.file.Close(); err != nil {
+		return errs.WrapMsg(err, "close audit log file failed", "path", f.path)
+	}
+	for i := f.maxBackups - 1; i > 0; i-- {
+		if err := os.Rename(f.backup(i), f.backup(i+1)); err != nil && !os.IsNotExist(err) {
+			return errs.WrapMsg(err, "rotate audit log file failed", "path", f.path)
+		}
+	}
+	if err := os.Rename(f.path, f.backup(1)); err != nil {
+		return errs.WrapMsg(err, "rotate audit log file failed", "path", f.path)
+	}
+	return f.open()
+}
+
+func (f *rotateFile) backup(i int) string {
+	return fmt.Sprintf("%s.%d", f.path, i)
+}
+
+func (f *rotateFile) Close() error {
+	return f.file.Close()
+}
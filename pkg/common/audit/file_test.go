@@ -0,0 +1,77 @@
+// Copyright © 2024 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package audit
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRotateFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "logs", "audit.log")
+	f, err := newRotateFile(path, 1, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.maxSize = 10
+	for _, line := range []string{"line1\n", "line2\n", "line3\n", "line4\n"} {
+		if _, err := f.Write([]byte(line)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+	for name, want := range map[string]string{path: "line4\n", path + ".1": "line3\n", path + ".2": "line2\n"} {
+		data, err := os.ReadFile(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != want {
+			t.Errorf("%s = %q, want %q", name, data, want)
+		}
+	}
+	if _, err := os.Stat(path + ".3"); !os.IsNotExist(err) {
+		t.Errorf("more than maxBackups backups: %v", err)
+	}
+}
+
+func TestRotateFileAppend(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	if err := os.WriteFile(path, []byte("old\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	f, err := newRotateFile(path, 1, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.maxSize = 8
+	if _, err := f.Write([]byte("new\n")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.Write([]byte("next\n")); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(path + ".1"); string(data) != "old\nnew\n" {
+		t.Errorf("backup = %q, want the existing content then the first write", data)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "next\n" {
+		t.Errorf("file = %q, want the write after the rotation", data)
+	}
+}
@@ -0,0 +1,96 @@
+// Copyright © 2024 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package audit
+
+import (
+	"context"
+	"net"
+
+	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+)
+
+// clientIPHeader carries the IP of the client that started an operation to the RPCs it calls.
+const clientIPHeader = "x-client-ip"
+
+type clientIPKey struct{}
+
+// Method describes how the calls of a gRPC method are recorded.
+type Method struct {
+	// Action names the operation in the logs, e.g. user.register.
+	Action string
+	// Targets returns the IDs of what the request changes.
+	Targets func(req any) []string
+}
+
+// WithClientIP returns ctx carrying ip as the IP of the client of the operation.
+func WithClientIP(ctx context.Context, ip string) context.Context {
+	return context.WithValue(ctx, clientIPKey{}, ip)
+}
+
+// ClientIP returns the IP of the client of the operation of ctx, read from the context, the metadata
+// of an incoming call or else its peer.
+func ClientIP(ctx context.Context) string {
+	if ip, ok := ctx.Value(clientIPKey{}).(string); ok && ip != "" {
+		return ip
+	}
+	if ips := metadata.ValueFromIncomingContext(ctx, clientIPHeader); len(ips) > 0 && ips[0] != "" {
+		return ips[0]
+	}
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		if host, _, err := net.SplitHostPort(p.Addr.String()); err == nil {
+			return host
+		}
+		return p.Addr.String()
+	}
+	return ""
+}
+
+// GinClientIP stores the IP of the client of a request in its context, for the RPCs it calls.
+func GinClientIP() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Request = c.Request.WithContext(WithClientIP(c.Request.Context(), c.ClientIP()))
+		c.Next()
+	}
+}
+
+// DialOption forwards the client IP of the context to the called RPCs.
+func DialOption() grpc.DialOption {
+	return grpc.WithChainUnaryInterceptor(func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		if ip, ok := ctx.Value(clientIPKey{}).(string); ok && ip != "" {
+			ctx = metadata.AppendToOutgoingContext(ctx, clientIPHeader, ip)
+		}
+		return invoker(ctx, method, req, reply, cc, opts...)
+	})
+}
+
+// UnaryServerInterceptor records the calls of methods, keyed by full method name, once they are handled.
+// It must run after the interceptor filling the operationID and opUserID of the context.
+func (r *Recorder) UnaryServerInterceptor(methods map[string]Method) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		ctx = WithClientIP(ctx, ClientIP(ctx))
+		resp, err := handler(ctx, req)
+		if m, ok := methods[info.FullMethod]; ok {
+			var targetIDs []string
+			if m.Targets != nil {
+				targetIDs = m.Targets(req)
+			}
+			r.Record(ctx, m.Action, targetIDs, req, err)
+		}
+		return resp, err
+	}
+}
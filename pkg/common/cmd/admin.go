@@ -0,0 +1,221 @@
+// Copyright © 2024 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+
+	"github.com/openimsdk/openim-project-template/pkg/common/config"
+	kdisc "github.com/openimsdk/openim-project-template/pkg/common/discoveryregister"
+	"github.com/openimsdk/openim-project-template/pkg/common/usercsv"
+	pbuser "github.com/openimsdk/openim-project-template/pkg/protocol/user"
+	"github.com/openimsdk/openim-project-template/pkg/rpcclient"
+	"github.com/openimsdk/tools/errs"
+	"github.com/openimsdk/tools/mcontext"
+	"github.com/openimsdk/tools/mw"
+	"github.com/openimsdk/tools/system/program"
+	"github.com/spf13/cobra"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+const (
+	flagAdminOutput   = "output"
+	flagAdminAs       = "as"
+	flagAdminFile     = "file"
+	flagAdminBatch    = "batch"
+	flagAdminNickname = "nickname"
+
+	outputText = "text"
+	outputJSON = "json"
+
+	defaultRegisterBatch = 1000
+)
+
+// AdminCmd calls the RPC services for operators, resolving them through discovery.yml like the services do.
+type AdminCmd struct {
+	*RootCmd
+	discovery config.Discovery
+	share     config.Share
+}
+
+func NewAdminCmd() *AdminCmd {
+	ret := &AdminCmd{}
+	ret.RootCmd = NewRootCmd(program.GetProcessName(), WithConfigMap(map[string]any{
+		DiscoveryConfigFilename: &ret.discovery,
+		ShareFileName:           &ret.share,
+	}))
+	ret.Command.Use = "openim-admin"
+	ret.Command.Short = "Call the RPC services"
+	// The subcommands load the config files with the flags of the root command.
+	ret.Command.PersistentFlags().AddFlagSet(ret.Command.Flags())
+	ret.Command.PersistentFlags().String(flagAdminOutput, outputText, "output format, text or json")
+	ret.Command.PersistentFlags().String(flagAdminAs, "", "userID of the operator, the first imAdminUserID of share.yml by default")
+	ret.Command.AddCommand(ret.userCmd())
+	return ret
+}
+
+func (a *AdminCmd) Exec() error {
+	return a.Execute()
+}
+
+func (a *AdminCmd) userCmd() *cobra.Command {
+	user := &cobra.Command{Use: "user", Short: "Get, register and update users"}
+
+	get := &cobra.Command{
+		Use:   "get USERID...",
+		Short: "Get the information of users",
+		Args:  cobra.MinimumNArgs(1),
+		RunE: a.withUserClient(func(ctx context.Context, cmd *cobra.Command, args []string, client pbuser.UserClient) error {
+			resp, err := client.GetDesignateUsers(ctx, &pbuser.GetDesignateUsersReq{UserIDs: args})
+			if err != nil {
+				return err
+			}
+			return a.print(cmd, resp.UsersInfo, func(w io.Writer) {
+				fmt.Fprintln(w, "USERID\tNICKNAME")
+				for _, user := range resp.UsersInfo {
+					fmt.Fprintf(w, "%s\t%s\n", user.UserID, user.Nickname)
+				}
+			})
+		}),
+	}
+
+	register := &cobra.Command{
+		Use:   "register --file users.csv",
+		Short: "Register the users of a CSV file",
+		Long:  "Registers the users of a CSV file of userID,nickname rows, a first row starting with userID is a header.",
+		Args:  cobra.NoArgs,
+		RunE: a.withUserClient(func(ctx context.Context, cmd *cobra.Command, args []string, client pbuser.UserClient) error {
+			file, _ := cmd.Flags().GetString(flagAdminFile)
+			batch, _ := cmd.Flags().GetInt(flagAdminBatch)
+			if batch <= 0 {
+				return errs.New("batch must be positive", "batch", batch).Wrap()
+			}
+			users, err := readUsersCSV(file)
+			if err != nil {
+				return err
+			}
+			registered := 0
+			for start := 0; start < len(users); start += batch {
+				end := min(start+batch, len(users))
+				if _, err := client.UserRegister(ctx, &pbuser.UserRegisterReq{Users: users[start:end]}); err != nil {
+					return errs.WrapMsg(err, "register failed", "registered", registered, "firstUserID", users[start].UserID)
+				}
+				registered = end
+			}
+			return a.print(cmd, map[string]int{"registered": registered}, func(w io.Writer) {
+				fmt.Fprintf(w, "registered %d users\n", registered)
+			})
+		}),
+	}
+	register.Flags().String(flagAdminFile, "", "CSV file of the users")
+	register.Flags().Int(flagAdminBatch, defaultRegisterBatch, "users registered per call")
+	_ = register.MarkFlagRequired(flagAdminFile)
+
+	update := &cobra.Command{
+		Use:   "update USERID --nickname NICKNAME",
+		Short: "Update the nickname of a user",
+		Args:  cobra.ExactArgs(1),
+		RunE: a.withUserClient(func(ctx context.Context, cmd *cobra.Command, args []string, client pbuser.UserClient) error {
+			nickname, _ := cmd.Flags().GetString(flagAdminNickname)
+			userInfo := &pbuser.UserInfo{UserID: args[0], Nickname: nickname}
+			if _, err := client.UpdateUserInfo(ctx, &pbuser.UpdateUserInfoReq{UserInfo: userInfo}); err != nil {
+				return err
+			}
+			return a.print(cmd, userInfo, func(w io.Writer) {
+				fmt.Fprintf(w, "updated %s\n", userInfo.UserID)
+			})
+		}),
+	}
+	update.Flags().String(flagAdminNickname, "", "new nickname")
+	_ = update.MarkFlagRequired(flagAdminNickname)
+
+	user.AddCommand(get, register, update)
+	return user
+}
+
+// withUserClient runs fn with a client of the user RPC and a context carrying an operationID and the operator
+// as opUserID, so that the calls are attributed to it in the audit log.
+func (a *AdminCmd) withUserClient(fn func(ctx context.Context, cmd *cobra.Command, args []string, client pbuser.UserClient) error) func(*cobra.Command, []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		if output, _ := cmd.Flags().GetString(flagAdminOutput); output != outputText && output != outputJSON {
+			return errs.New("unknown output format", "output", output).Wrap()
+		}
+		ctx := cmd.Context()
+		if ctx == nil {
+			ctx = context.Background()
+		}
+		ctx = operatorContext(ctx, &a.share)
+		if as, _ := cmd.Flags().GetString(flagAdminAs); as != "" {
+			ctx = mcontext.SetOpUserID(ctx, as)
+		}
+		if mcontext.GetOpUserID(ctx) == "" {
+			return errs.New("no operator, set --as or imAdminUserID in share.yml").Wrap()
+		}
+		client, err := kdisc.NewDiscoveryRegister(&a.discovery)
+		if err != nil {
+			return err
+		}
+		defer client.Close()
+		client.AddOption(mw.GrpcClient(), rpcclient.GrpcStreamClient(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+		conn, err := client.GetConn(ctx, a.share.RpcRegisterName.User)
+		if err != nil {
+			return err
+		}
+		return fn(ctx, cmd, args, pbuser.NewUserClient(conn))
+	}
+}
+
+// print writes v as JSON with --output json, or the table written by text otherwise.
+func (a *AdminCmd) print(cmd *cobra.Command, v any, text func(w io.Writer)) error {
+	out := cmd.OutOrStdout()
+	if output, _ := cmd.Flags().GetString(flagAdminOutput); output == outputJSON {
+		encoder := json.NewEncoder(out)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(v); err != nil {
+			return errs.WrapMsg(err, "write output failed")
+		}
+		return nil
+	}
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	text(w)
+	return w.Flush()
+}
+
+// readUsersCSV reads the users of a CSV file of userID,nickname rows.
+func readUsersCSV(name string) ([]*pbuser.UserInfo, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, errs.WrapMsg(err, "open users file failed", "file", name)
+	}
+	defer f.Close()
+	reader := usercsv.NewReader(f)
+	var users []*pbuser.UserInfo
+	for {
+		user, err := reader.Read()
+		if err == io.EOF {
+			return users, nil
+		}
+		if err != nil {
+			return nil, errs.WrapMsg(err, "read users file failed", "file", name)
+		}
+		users = append(users, user)
+	}
+}
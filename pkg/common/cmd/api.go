@@ -42,6 +42,7 @@ func NewApiCmd() *ApiCmd {
 	ret.Command.RunE = func(cmd *cobra.Command, args []string) error {
 		return ret.runE()
 	}
+	ret.Command.AddCommand(NewOpenAPICmd())
 	return ret
 }
 
@@ -19,13 +19,15 @@ import (
 )
 
 var (
-	OpenIMRPCUserCfgFileName string
-	RedisConfigFileName      string
-	MongodbConfigFileName    string
-	DiscoveryConfigFilename  string
-	OpenIMAPICfgFileName     string
-	LogConfigFileName        string
-	ShareFileName            string
+	OpenIMRPCUserCfgFileName  string
+	OpenIMRPCAuditCfgFileName string
+	RedisConfigFileName       string
+	MongodbConfigFileName     string
+	DiscoveryConfigFilename   string
+	OpenIMAPICfgFileName      string
+	LogConfigFileName         string
+	ShareFileName             string
+	WebhooksConfigFileName    string
 )
 
 const envPrefix = "IMENV_"
@@ -38,19 +40,23 @@ func init() {
 	MongodbConfigFileName = "mongodb.yml"
 	OpenIMAPICfgFileName = "openim-api.yml"
 	OpenIMRPCUserCfgFileName = "openim-rpc-user.yml"
+	OpenIMRPCAuditCfgFileName = "openim-rpc-audit.yml"
 	DiscoveryConfigFilename = "discovery.yml"
 	LogConfigFileName = "log.yml"
 	ShareFileName = "share.yml"
+	WebhooksConfigFileName = "webhooks.yml"
 
 	ConfigEnvPrefixMap = make(map[string]string)
 	fileNames := []string{
 		RedisConfigFileName,
 		MongodbConfigFileName,
 		OpenIMRPCUserCfgFileName,
+		OpenIMRPCAuditCfgFileName,
 		DiscoveryConfigFilename,
 		OpenIMAPICfgFileName,
 		LogConfigFileName,
 		ShareFileName,
+		WebhooksConfigFileName,
 	}
 
 	for _, fileName := range fileNames {
@@ -0,0 +1,167 @@
+// Copyright © 2024 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/openimsdk/openim-project-template/pkg/common/audit"
+	"github.com/openimsdk/openim-project-template/pkg/common/config"
+	"github.com/openimsdk/openim-project-template/pkg/common/startrpc"
+	"github.com/openimsdk/openim-project-template/pkg/common/storage/database/mgo"
+	"github.com/openimsdk/tools/errs"
+	"github.com/openimsdk/tools/log"
+	"github.com/openimsdk/tools/mcontext"
+	"github.com/openimsdk/tools/system/program"
+	"github.com/openimsdk/tools/utils/datautil"
+	"github.com/spf13/cobra"
+)
+
+const (
+	flagMigrateVersion = "version"
+	flagMigrateSteps   = "steps"
+)
+
+// MigrateCmd applies, reverts or lists the schema migrations of the database of mongodb.yml.
+// It is added as the migrate subcommand of the services using Mongo.
+type MigrateCmd struct {
+	*RootCmd
+	mongo config.Mongo
+	share config.Share
+}
+
+func NewMigrateCmd() *MigrateCmd {
+	ret := &MigrateCmd{}
+	ret.RootCmd = NewRootCmd(program.GetProcessName(), WithConfigMap(map[string]any{
+		MongodbConfigFileName: &ret.mongo,
+		ShareFileName:         &ret.share,
+	}))
+	ret.Command.Use = "migrate {up|down|status}"
+	ret.Command.Short = "Apply, revert or list the schema migrations"
+	ret.Command.Long = "up applies the pending migrations, down reverts the last applied ones and status lists them."
+	ret.Command.ValidArgs = []string{"up", "down", "status"}
+	ret.Command.Args = cobra.MatchAll(cobra.ExactArgs(1), cobra.OnlyValidArgs)
+	ret.Command.Flags().Int(flagMigrateVersion, 0, "version to migrate up to, the latest by default")
+	ret.Command.Flags().Int(flagMigrateSteps, 1, "number of migrations to revert")
+	ret.Command.RunE = func(cmd *cobra.Command, args []string) error {
+		return ret.runE(cmd, args[0])
+	}
+	return ret
+}
+
+func (m *MigrateCmd) runE(cmd *cobra.Command, action string) error {
+	ctx := cmd.Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if m.mongo.Mode == startrpc.MongoModeMemory {
+		return errs.New("mongo is in memory mode, there is nothing to migrate").Wrap()
+	}
+	mgoCli, err := mgo.NewClient(ctx, m.mongo.Build())
+	if err != nil {
+		return err
+	}
+	defer mgoCli.GetDB().Client().Disconnect(context.Background())
+	migrator := mgo.NewMigrator(mgoCli.GetDB())
+	out := cmd.OutOrStdout()
+	switch action {
+	case "up":
+		if m.share.Audit.Enable {
+			// Created before the migrations, which would create it with the default cap.
+			if err := mgo.CreateAuditCollection(ctx, mgoCli.GetDB(), m.share.Audit.CapSize); err != nil {
+				return err
+			}
+		}
+		version, _ := cmd.Flags().GetInt(flagMigrateVersion)
+		applied, err := migrator.Up(ctx, version)
+		m.record(ctx, mgoCli, "schema.migrate_up", applied, map[string]int{"version": version}, err)
+		for _, migration := range applied {
+			fmt.Fprintf(out, "applied %d %s\n", migration.Version, migration.Name)
+		}
+		if err != nil {
+			return err
+		}
+		if len(applied) == 0 {
+			fmt.Fprintln(out, "no pending migration")
+		}
+	case "down":
+		steps, _ := cmd.Flags().GetInt(flagMigrateSteps)
+		reverted, err := migrator.Down(ctx, steps)
+		m.record(ctx, mgoCli, "schema.migrate_down", reverted, map[string]int{"steps": steps}, err)
+		for _, migration := range reverted {
+			fmt.Fprintf(out, "reverted %d %s\n", migration.Version, migration.Name)
+		}
+		if err != nil {
+			return err
+		}
+		if len(reverted) == 0 {
+			fmt.Fprintln(out, "no applied migration")
+		}
+	case "status":
+		status, version, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "schema version %d, supported %d\n", version, mgo.LatestSchemaVersion())
+		w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED")
+		for _, s := range status {
+			applied := "pending"
+			if s.Applied {
+				applied = s.AppliedTime.Format(time.RFC3339)
+			}
+			fmt.Fprintf(w, "%d\t%s\t%s\n", s.Version, s.Name, applied)
+		}
+		return w.Flush()
+	}
+	return nil
+}
+
+// record writes the migrations changed by action to the audit log when it is enabled, attributed to the
+// first configured admin. Failing to record is logged and does not fail the command.
+func (m *MigrateCmd) record(ctx context.Context, mgoCli *mgo.Client, action string, migrations []*mgo.Migration, req any, err error) {
+	if !m.share.Audit.Enable || (len(migrations) == 0 && err == nil) {
+		return
+	}
+	auditDB, dbErr := mgo.NewAuditMongo(mgoCli.GetDB())
+	if dbErr != nil {
+		log.ZWarn(ctx, "open audit log failed", dbErr, "action", action)
+		return
+	}
+	recorder, dbErr := audit.NewRecorder(&m.share.Audit, auditDB)
+	if dbErr != nil {
+		log.ZWarn(ctx, "open audit log failed", dbErr, "action", action)
+		return
+	}
+	versions := datautil.Slice(migrations, func(e *mgo.Migration) string { return strconv.Itoa(e.Version) })
+	recorder.Record(operatorContext(ctx, &m.share), action, versions, req, err)
+	if dbErr := recorder.Close(ctx); dbErr != nil {
+		log.ZWarn(ctx, "write audit log failed", dbErr, "action", action)
+	}
+}
+
+// operatorContext returns ctx carrying an operationID for a command run by an operator, and the first
+// configured admin as its opUserID.
+func operatorContext(ctx context.Context, share *config.Share) context.Context {
+	ctx = mcontext.SetOperationID(ctx, program.GetProcessName()+"-"+strconv.FormatInt(time.Now().UnixMilli(), 10))
+	if len(share.IMAdminUserID) > 0 {
+		ctx = mcontext.SetOpUserID(ctx, share.IMAdminUserID[0])
+	}
+	return ctx
+}
@@ -0,0 +1,79 @@
+// Copyright © 2024 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/openimsdk/openim-project-template/internal/api"
+	"github.com/openimsdk/tools/errs"
+	"github.com/spf13/cobra"
+)
+
+const (
+	flagOpenAPIOutput = "output"
+	flagOpenAPIRoutes = "routes"
+)
+
+// NewOpenAPICmd returns the openapi subcommand of the API, writing the document served at /openapi.json.
+// It needs no config file.
+func NewOpenAPICmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "openapi",
+		Short: "Write the OpenAPI document of the routes",
+		Long:  "Writes the OpenAPI document served at /openapi.json, or with --routes a table of the routes and their messages.",
+		Args:  cobra.NoArgs,
+		// Replaces the config loading of the root command.
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			return nil
+		},
+		RunE: runOpenAPI,
+	}
+	cmd.Flags().StringP(flagOpenAPIOutput, "o", "", "file to write to, the standard output by default")
+	cmd.Flags().Bool(flagOpenAPIRoutes, false, "write the route table instead of the document")
+	return cmd
+}
+
+func runOpenAPI(cmd *cobra.Command, args []string) error {
+	out := cmd.OutOrStdout()
+	if output, _ := cmd.Flags().GetString(flagOpenAPIOutput); output != "" {
+		f, err := os.Create(output)
+		if err != nil {
+			return errs.WrapMsg(err, "create output failed", "output", output)
+		}
+		defer f.Close()
+		out = f
+	}
+	if routes, _ := cmd.Flags().GetBool(flagOpenAPIRoutes); routes {
+		w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "METHOD\tPATH\tREQUEST\tRESPONSE\tSUMMARY")
+		for _, route := range api.Routes() {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", route.Method, route.Path,
+				route.Req.ProtoReflect().Descriptor().FullName(), route.Resp.ProtoReflect().Descriptor().FullName(), route.Summary)
+		}
+		return w.Flush()
+	}
+	data, err := json.MarshalIndent(api.OpenAPI(), "", "  ")
+	if err != nil {
+		return errs.WrapMsg(err, "marshal openapi document failed")
+	}
+	if _, err := fmt.Fprintln(out, string(data)); err != nil {
+		return errs.WrapMsg(err, "write openapi document failed")
+	}
+	return nil
+}
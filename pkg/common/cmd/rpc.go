@@ -0,0 +1,70 @@
+// Copyright © 2024 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+
+	"github.com/openimsdk/openim-project-template/pkg/common/config"
+	"github.com/openimsdk/openim-project-template/pkg/common/startrpc"
+	"github.com/openimsdk/tools/system/program"
+	"github.com/spf13/cobra"
+)
+
+// RpcCmd starts the RPC service described by a startrpc.Service.
+type RpcCmd struct {
+	*RootCmd
+	ctx       context.Context
+	configMap map[string]any
+	service   *startrpc.Service
+	shared    *startrpc.SharedConfig
+}
+
+// NewRpcCmd loads the shared config files, the Mongo and Redis ones when the service needs them,
+// and the config files of the service. Services using Mongo get the migrate subcommand.
+func NewRpcCmd(service *startrpc.Service) *RpcCmd {
+	var shared startrpc.SharedConfig
+	ret := &RpcCmd{service: service, shared: &shared}
+	ret.configMap = map[string]any{
+		ShareFileName:           &shared.Share,
+		DiscoveryConfigFilename: &shared.Discovery,
+	}
+	if service.Needs.Mongo {
+		ret.configMap[MongodbConfigFileName] = &shared.Mongo
+	}
+	if service.Needs.Redis || service.Needs.RedisCache {
+		ret.configMap[RedisConfigFileName] = &shared.Redis
+	}
+	for fileName, configStruct := range service.ConfigFiles {
+		ret.configMap[fileName] = configStruct
+	}
+	ret.RootCmd = NewRootCmd(program.GetProcessName(), WithConfigMap(ret.configMap))
+	ret.ctx = context.WithValue(context.Background(), "version", config.Version)
+	ret.Command.RunE = func(cmd *cobra.Command, args []string) error {
+		return ret.runE()
+	}
+	if service.Needs.Mongo {
+		ret.Command.AddCommand(&NewMigrateCmd().Command)
+	}
+	return ret
+}
+
+func (a *RpcCmd) Exec() error {
+	return a.Execute()
+}
+
+func (a *RpcCmd) runE() error {
+	return startrpc.Run(a.ctx, a.Index(), a.service, a.shared)
+}
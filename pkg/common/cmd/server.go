@@ -0,0 +1,117 @@
+// Copyright © 2024 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+
+	"github.com/openimsdk/openim-project-template/internal/api"
+	"github.com/openimsdk/openim-project-template/pkg/common/config"
+	"github.com/openimsdk/openim-project-template/pkg/common/discoveryregister/local"
+	"github.com/openimsdk/openim-project-template/pkg/common/prommetrics"
+	"github.com/openimsdk/openim-project-template/pkg/common/startrpc"
+	"github.com/openimsdk/openim-project-template/pkg/common/tracing"
+	"github.com/openimsdk/tools/log"
+	"github.com/openimsdk/tools/system/program"
+	"github.com/spf13/cobra"
+)
+
+// ServerCmd runs the API and the given RPC services in a single process. The services
+// reach each other through in-memory connections, discovery.yml is not used.
+type ServerCmd struct {
+	*RootCmd
+	ctx       context.Context
+	configMap map[string]any
+	services  []*startrpc.Service
+	apiConfig *api.Config
+	shared    *startrpc.SharedConfig
+	needs     startrpc.Needs
+}
+
+func NewServerCmd(services ...*startrpc.Service) *ServerCmd {
+	var (
+		apiConfig api.Config
+		shared    startrpc.SharedConfig
+	)
+	ret := &ServerCmd{services: services, apiConfig: &apiConfig, shared: &shared}
+	ret.configMap = map[string]any{
+		OpenIMAPICfgFileName: &apiConfig.API,
+		ShareFileName:        &shared.Share,
+	}
+	for _, service := range services {
+		ret.needs.Mongo = ret.needs.Mongo || service.Needs.Mongo
+		ret.needs.Redis = ret.needs.Redis || service.Needs.Redis
+		ret.needs.RedisCache = ret.needs.RedisCache || service.Needs.RedisCache
+		ret.needs.Audit = ret.needs.Audit || service.Needs.Audit
+		for fileName, configStruct := range service.ConfigFiles {
+			ret.configMap[fileName] = configStruct
+		}
+	}
+	if ret.needs.Mongo {
+		ret.configMap[MongodbConfigFileName] = &shared.Mongo
+	}
+	if ret.needs.Redis || ret.needs.RedisCache {
+		ret.configMap[RedisConfigFileName] = &shared.Redis
+	}
+	ret.RootCmd = NewRootCmd(program.GetProcessName(), WithConfigMap(ret.configMap))
+	ret.ctx = context.WithValue(context.Background(), "version", config.Version)
+	ret.Command.RunE = func(cmd *cobra.Command, args []string) error {
+		return ret.runE()
+	}
+	ret.Command.AddCommand(NewOpenAPICmd())
+	if ret.needs.Mongo {
+		ret.Command.AddCommand(&NewMigrateCmd().Command)
+	}
+	return ret
+}
+
+func (a *ServerCmd) Exec() error {
+	return a.Execute()
+}
+
+func (a *ServerCmd) runE() error {
+	lifecycle := startrpc.NewLifecycle(&a.shared.Share.Shutdown)
+	defer lifecycle.Stop(a.ctx)
+
+	if err := prommetrics.Init(program.GetProcessName(), a.Index()); err != nil {
+		return err
+	}
+	shutdownTracing, err := tracing.Init(a.ctx, &a.shared.Share.Tracing, program.GetProcessName())
+	if err != nil {
+		return err
+	}
+	lifecycle.OnStop(startrpc.PhaseFlush, "tracing", shutdownTracing)
+
+	deps, err := startrpc.NewDependenciesWithDiscovery(a.ctx, lifecycle, local.NewConnManager(), a.needs, a.shared)
+	if err != nil {
+		return err
+	}
+	netErr := make(chan error, 2*(len(a.services)+1))
+	// The RPC services are registered first, the API connects to them while starting.
+	for _, service := range a.services {
+		if !service.Available(a.shared) {
+			log.ZWarn(a.ctx, "service skipped, it is unavailable in memory mode", nil, "service", service.Name)
+			continue
+		}
+		if err := startrpc.Serve(a.ctx, lifecycle, a.Index(), service, deps, netErr); err != nil {
+			return err
+		}
+	}
+	a.apiConfig.Share = a.shared.Share
+	if err := api.Serve(a.ctx, lifecycle, a.Index(), a.apiConfig, deps.Discovery, netErr); err != nil {
+		return err
+	}
+	return lifecycle.Run(a.ctx, netErr)
+}
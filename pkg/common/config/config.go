@@ -18,6 +18,7 @@ import (
 	_ "embed"
 	"github.com/openimsdk/tools/db/mongoutil"
 	"github.com/openimsdk/tools/db/redisutil"
+	"path/filepath"
 )
 
 //go:embed version
@@ -35,17 +36,57 @@ type Log struct {
 }
 
 type Mongo struct {
-	URI         string   `mapstructure:"uri"`
-	Address     []string `mapstructure:"address"`
-	Database    string   `mapstructure:"database"`
-	Username    string   `mapstructure:"username"`
-	Password    string   `mapstructure:"password"`
-	MaxPoolSize int      `mapstructure:"maxPoolSize"`
-	MaxRetry    int      `mapstructure:"maxRetry"`
+	Mode        string         `mapstructure:"mode"`
+	URI         string         `mapstructure:"uri"`
+	Address     []string       `mapstructure:"address"`
+	Database    string         `mapstructure:"database"`
+	Username    string         `mapstructure:"username"`
+	Password    string         `mapstructure:"password"`
+	MaxPoolSize int            `mapstructure:"maxPoolSize"`
+	MaxRetry    int            `mapstructure:"maxRetry"`
+	Migration   MongoMigration `mapstructure:"migration"`
+}
+
+type MongoMigration struct {
+	Mode    string `mapstructure:"mode"`
+	Timeout int    `mapstructure:"timeout"`
 }
 
 type Share struct {
 	RpcRegisterName RpcRegisterName `mapstructure:"rpcRegisterName"`
+	IMAdminUserID   []string        `mapstructure:"imAdminUserID"`
+	Shutdown        Shutdown        `mapstructure:"shutdown"`
+	Tracing         Tracing         `mapstructure:"tracing"`
+	Audit           Audit           `mapstructure:"audit"`
+}
+
+type Shutdown struct {
+	PropagationDelay int `mapstructure:"propagationDelay"`
+	Timeout          int `mapstructure:"timeout"`
+}
+
+type Tracing struct {
+	Enable      bool               `mapstructure:"enable"`
+	Exporter    string             `mapstructure:"exporter"`
+	Endpoint    string             `mapstructure:"endpoint"`
+	Insecure    bool               `mapstructure:"insecure"`
+	FilePath    string             `mapstructure:"filePath"`
+	SampleRatio float64            `mapstructure:"sampleRatio"`
+	Services    map[string]float64 `mapstructure:"services"`
+}
+
+type Audit struct {
+	Enable         bool      `mapstructure:"enable"`
+	CapSize        int64     `mapstructure:"capSize"`
+	MaxRequestSize int       `mapstructure:"maxRequestSize"`
+	File           AuditFile `mapstructure:"file"`
+}
+
+type AuditFile struct {
+	Enable     bool   `mapstructure:"enable"`
+	Path       string `mapstructure:"path"`
+	MaxSize    int64  `mapstructure:"maxSize"`
+	MaxBackups int    `mapstructure:"maxBackups"`
 }
 
 type API struct {
@@ -55,23 +96,140 @@ type API struct {
 		Ports    []int  `mapstructure:"ports"`
 	} `mapstructure:"api"`
 	Prometheus struct {
-		Enable     bool   `mapstructure:"enable"`
-		Ports      []int  `mapstructure:"ports"`
-		GrafanaURL string `mapstructure:"grafanaURL"`
+		Enable        bool           `mapstructure:"enable"`
+		Ports         []int          `mapstructure:"ports"`
+		ListenIP      string         `mapstructure:"listenIP"`
+		Path          string         `mapstructure:"path"`
+		Auth          MetricsAuth    `mapstructure:"auth"`
+		GrafanaURL    string         `mapstructure:"grafanaURL"`
+		PushGateway   PushGateway    `mapstructure:"pushGateway"`
+		CustomMetrics []CustomMetric `mapstructure:"customMetrics"`
 	} `mapstructure:"prometheus"`
+	Debug struct {
+		SwaggerUI bool `mapstructure:"swaggerUI"`
+	} `mapstructure:"debug"`
 }
 
 type Prometheus struct {
-	Enable bool  `mapstructure:"enable"`
-	Ports  []int `mapstructure:"ports"`
+	Enable      bool        `mapstructure:"enable"`
+	Ports       []int       `mapstructure:"ports"`
+	ListenIP    string      `mapstructure:"listenIP"`
+	Path        string      `mapstructure:"path"`
+	Auth        MetricsAuth `mapstructure:"auth"`
+	PushGateway PushGateway `mapstructure:"pushGateway"`
+}
+
+// CustomMetric declares a metric of the API recorded for the requests of its routes.
+type CustomMetric struct {
+	Name    string        `mapstructure:"name"`
+	Type    string        `mapstructure:"type"`
+	Help    string        `mapstructure:"help"`
+	Labels  []MetricLabel `mapstructure:"labels"`
+	Buckets []float64     `mapstructure:"buckets"`
+	Routes  []string      `mapstructure:"routes"`
+}
+
+// MetricLabel is a label of a CustomMetric and the request value it is set to.
+type MetricLabel struct {
+	Name   string   `mapstructure:"name"`
+	Header string   `mapstructure:"header"`
+	Query  string   `mapstructure:"query"`
+	Values []string `mapstructure:"values"`
+}
+
+type MetricsAuth struct {
+	Accounts []MetricsAccount `mapstructure:"accounts"`
+	Token    string           `mapstructure:"token"`
+}
+
+type MetricsAccount struct {
+	Username string `mapstructure:"username"`
+	Password string `mapstructure:"password"`
+}
+
+type PushGateway struct {
+	URL      string            `mapstructure:"url"`
+	Job      string            `mapstructure:"job"`
+	Interval int               `mapstructure:"interval"`
+	Grouping map[string]string `mapstructure:"grouping"`
+}
+
+type RPC struct {
+	RegisterIP string `mapstructure:"registerIP"`
+	ListenIP   string `mapstructure:"listenIP"`
+	Ports      []int  `mapstructure:"ports"`
+	Reflection bool   `mapstructure:"reflection"`
 }
 
 type User struct {
-	RPC struct {
-		RegisterIP string `mapstructure:"registerIP"`
-		ListenIP   string `mapstructure:"listenIP"`
-		Ports      []int  `mapstructure:"ports"`
-	} `mapstructure:"rpc"`
+	RPC          RPC          `mapstructure:"rpc"`
+	Prometheus   Prometheus   `mapstructure:"prometheus"`
+	Outbox       Outbox       `mapstructure:"outbox"`
+	CacheWatcher CacheWatcher `mapstructure:"cacheWatcher"`
+}
+
+type CacheWatcher struct {
+	Enable   bool `mapstructure:"enable"`
+	LeaseTTL int  `mapstructure:"leaseTTL"`
+}
+
+type Outbox struct {
+	Enable       bool              `mapstructure:"enable"`
+	PollInterval int               `mapstructure:"pollInterval"`
+	BatchSize    int               `mapstructure:"batchSize"`
+	LeaseTTL     int               `mapstructure:"leaseTTL"`
+	Retry        OutboxRetry       `mapstructure:"retry"`
+	Webhook      OutboxWebhook     `mapstructure:"webhook"`
+	RedisStream  OutboxRedisStream `mapstructure:"redisStream"`
+	Kafka        OutboxKafka       `mapstructure:"kafka"`
+}
+
+type OutboxRetry struct {
+	MinBackoff  int `mapstructure:"minBackoff"`
+	MaxBackoff  int `mapstructure:"maxBackoff"`
+	MaxAttempts int `mapstructure:"maxAttempts"`
+}
+
+type OutboxWebhook struct {
+	Enable  bool   `mapstructure:"enable"`
+	URL     string `mapstructure:"url"`
+	Timeout int    `mapstructure:"timeout"`
+}
+
+type OutboxRedisStream struct {
+	Enable bool   `mapstructure:"enable"`
+	Stream string `mapstructure:"stream"`
+	MaxLen int64  `mapstructure:"maxLen"`
+}
+
+type OutboxKafka struct {
+	Enable bool     `mapstructure:"enable"`
+	Addr   []string `mapstructure:"addr"`
+	Topic  string   `mapstructure:"topic"`
+}
+
+type Webhooks struct {
+	URL                  string       `mapstructure:"url"`
+	Secret               string       `mapstructure:"secret"`
+	BeforeUserRegister   BeforeConfig `mapstructure:"beforeUserRegister"`
+	AfterUserRegister    AfterConfig  `mapstructure:"afterUserRegister"`
+	BeforeUpdateUserInfo BeforeConfig `mapstructure:"beforeUpdateUserInfo"`
+	AfterUpdateUserInfo  AfterConfig  `mapstructure:"afterUpdateUserInfo"`
+}
+
+type BeforeConfig struct {
+	Enable         bool `mapstructure:"enable"`
+	Timeout        int  `mapstructure:"timeout"`
+	FailedContinue bool `mapstructure:"failedContinue"`
+}
+
+type AfterConfig struct {
+	Enable  bool `mapstructure:"enable"`
+	Timeout int  `mapstructure:"timeout"`
+}
+
+type AuditRPC struct {
+	RPC        RPC        `mapstructure:"rpc"`
 	Prometheus Prometheus `mapstructure:"prometheus"`
 }
 
@@ -86,12 +244,16 @@ type Redis struct {
 }
 
 type RpcRegisterName struct {
-	User string `mapstructure:"user"`
+	User  string `mapstructure:"user"`
+	Audit string `mapstructure:"audit"`
 }
 
 type Discovery struct {
-	Enable string `mapstructure:"enable"`
-	Etcd   Etcd   `mapstructure:"etcd"`
+	Enable     string        `mapstructure:"enable"`
+	Etcd       Etcd          `mapstructure:"etcd"`
+	Kubernetes Kubernetes    `mapstructure:"kubernetes"`
+	Direct     Direct        `mapstructure:"direct"`
+	File       DiscoveryFile `mapstructure:"file"`
 }
 
 type Etcd struct {
@@ -101,6 +263,29 @@ type Etcd struct {
 	Password      string   `mapstructure:"password"`
 }
 
+type Kubernetes struct {
+	Namespace     string `mapstructure:"namespace"`
+	ServiceName   string `mapstructure:"serviceName"`
+	PortName      string `mapstructure:"portName"`
+	Port          int    `mapstructure:"port"`
+	ClusterDomain string `mapstructure:"clusterDomain"`
+}
+
+type Direct struct {
+	Services map[string][]string `mapstructure:"services"`
+}
+
+type DiscoveryFile struct {
+	Path string `mapstructure:"path"`
+}
+
+// resolvePaths resolves a relative path of the discovery file against the configuration directory.
+func (d *Discovery) resolvePaths(configDirectory string) {
+	if d.File.Path != "" && !filepath.IsAbs(d.File.Path) {
+		d.File.Path = filepath.Join(configDirectory, d.File.Path)
+	}
+}
+
 func (m *Mongo) Build() *mongoutil.Config {
 	return &mongoutil.Config{
 		Uri:         m.URI,
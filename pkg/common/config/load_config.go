@@ -9,15 +9,28 @@ import (
 	"strings"
 )
 
+// pathResolver is implemented by the configurations holding paths relative to the configuration directory.
+type pathResolver interface {
+	resolvePaths(configDirectory string)
+}
+
 func Load(configDirectory string, configFileName string, envPrefix string, config any) error {
 	if os.Getenv(DeploymentType) == KUBERNETES {
 		mountPath := os.Getenv(MountConfigFilePath)
 		if mountPath == "" {
 			return errs.ErrArgs.WrapMsg(MountConfigFilePath + " env is empty")
 		}
-		return loadConfigK8s(mountPath, configFileName, config)
+		configDirectory = mountPath
+		if err := loadConfigK8s(mountPath, configFileName, config); err != nil {
+			return err
+		}
+	} else if err := loadConfig(filepath.Join(configDirectory, configFileName), envPrefix, config); err != nil {
+		return err
 	}
-	return loadConfig(filepath.Join(configDirectory, configFileName), envPrefix, config)
+	if resolver, ok := config.(pathResolver); ok {
+		resolver.resolvePaths(configDirectory)
+	}
+	return nil
 }
 
 func loadConfig(path string, envPrefix string, config any) error {
@@ -0,0 +1,47 @@
+// Copyright © 2024 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadResolvesDiscoveryFile(t *testing.T) {
+	dir := t.TempDir()
+	content := "enable: file\nfile:\n  path: ./discovery-services.yml\n"
+	if err := os.WriteFile(filepath.Join(dir, "discovery.yml"), []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	var discovery Discovery
+	if err := Load(dir, "discovery.yml", "TEST_DISCOVERY", &discovery); err != nil {
+		t.Fatal(err)
+	}
+	if want := filepath.Join(dir, "discovery-services.yml"); discovery.File.Path != want {
+		t.Fatalf("path %q, want %q", discovery.File.Path, want)
+	}
+
+	content = "enable: file\nfile:\n  path: /etc/openim/services.yml\n"
+	if err := os.WriteFile(filepath.Join(dir, "discovery.yml"), []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := Load(dir, "discovery.yml", "TEST_DISCOVERY", &discovery); err != nil {
+		t.Fatal(err)
+	}
+	if discovery.File.Path != "/etc/openim/services.yml" {
+		t.Fatalf("absolute path changed to %q", discovery.File.Path)
+	}
+}
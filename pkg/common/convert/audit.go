@@ -0,0 +1,37 @@
+// Copyright © 2024 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package convert
+
+import (
+	"github.com/openimsdk/openim-project-template/pkg/common/storage/model"
+	pbaudit "github.com/openimsdk/openim-project-template/pkg/protocol/audit"
+)
+
+func AuditLogsDB2Pb(logs []*model.AuditLog) []*pbaudit.AuditLog {
+	result := make([]*pbaudit.AuditLog, 0, len(logs))
+	for _, l := range logs {
+		result = append(result, &pbaudit.AuditLog{
+			ActorUserID: l.ActorUserID,
+			OperationID: l.OperationID,
+			Action:      l.Action,
+			TargetIDs:   l.TargetIDs,
+			Request:     l.Request,
+			Code:        int32(l.Code),
+			Ip:          l.IP,
+			CreateTime:  l.CreateTime.UnixMilli(),
+		})
+	}
+	return result
+}
@@ -0,0 +1,266 @@
+// Copyright © 2024 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package direct
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"sync"
+
+	"github.com/openimsdk/tools/errs"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/resolver"
+)
+
+const scheme = "direct"
+
+// ConnManager is a service discovery and registry client backed by a static service name to addresses table.
+// Instances registered through Register are added to the table, so services started in the same process
+// can reach each other without any external registry.
+type ConnManager struct {
+	lock        sync.RWMutex
+	services    map[string][]string
+	resolvers   map[*serviceResolver]struct{}
+	conns       map[string]*grpc.ClientConn
+	dialOptions []grpc.DialOption
+	registered  []registration
+	selfTarget  string
+}
+
+type registration struct {
+	serviceName string
+	address     string
+}
+
+// NewConnManager creates a ConnManager that resolves each service name to the given addresses.
+func NewConnManager(services map[string][]string, options ...grpc.DialOption) *ConnManager {
+	c := &ConnManager{
+		services:    make(map[string][]string),
+		resolvers:   make(map[*serviceResolver]struct{}),
+		conns:       make(map[string]*grpc.ClientConn),
+		dialOptions: options,
+	}
+	for serviceName, addrs := range services {
+		c.services[serviceName] = append([]string(nil), addrs...)
+	}
+	return c
+}
+
+// UpdateServices replaces the whole address table and pushes the new addresses to every open connection.
+// Instances registered through Register are kept.
+func (c *ConnManager) UpdateServices(services map[string][]string) {
+	c.lock.Lock()
+	next := make(map[string][]string, len(services))
+	for serviceName, addrs := range services {
+		next[serviceName] = append([]string(nil), addrs...)
+	}
+	for _, r := range c.registered {
+		next[r.serviceName] = appendDistinct(next[r.serviceName], r.address)
+	}
+	c.services = next
+	c.lock.Unlock()
+	c.notify()
+}
+
+// GetConns returns one connection per address of the service.
+func (c *ConnManager) GetConns(ctx context.Context, serviceName string, opts ...grpc.DialOption) ([]*grpc.ClientConn, error) {
+	addrs := c.addresses(serviceName)
+	if len(addrs) == 0 {
+		return nil, errs.New("no address found for service", "serviceName", serviceName).Wrap()
+	}
+	conns := make([]*grpc.ClientConn, 0, len(addrs))
+	for _, addr := range addrs {
+		conn, err := c.dial(ctx, addr, addr, opts)
+		if err != nil {
+			return nil, err
+		}
+		conns = append(conns, conn)
+	}
+	return conns, nil
+}
+
+// GetConn returns a connection balancing across all addresses of the service.
+func (c *ConnManager) GetConn(ctx context.Context, serviceName string, opts ...grpc.DialOption) (*grpc.ClientConn, error) {
+	target := fmt.Sprintf("%s:///%s", scheme, serviceName)
+	opts = append(opts, grpc.WithResolvers(&resolverBuilder{manager: c}))
+	return c.dial(ctx, target, target, opts)
+}
+
+// GetSelfConnTarget returns the address this instance registered with.
+func (c *ConnManager) GetSelfConnTarget() string {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return c.selfTarget
+}
+
+// AddOption appends dial options used by every new connection.
+func (c *ConnManager) AddOption(opts ...grpc.DialOption) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.dialOptions = append(c.dialOptions, opts...)
+}
+
+// CloseConn closes the connection and drops it from the cache.
+func (c *ConnManager) CloseConn(conn *grpc.ClientConn) {
+	c.lock.Lock()
+	for key, cached := range c.conns {
+		if cached == conn {
+			delete(c.conns, key)
+		}
+	}
+	c.lock.Unlock()
+	_ = conn.Close()
+}
+
+// Register adds the instance to the address table of the service.
+func (c *ConnManager) Register(serviceName, host string, port int, opts ...grpc.DialOption) error {
+	address := net.JoinHostPort(host, strconv.Itoa(port))
+	c.lock.Lock()
+	c.registered = append(c.registered, registration{serviceName: serviceName, address: address})
+	c.services[serviceName] = appendDistinct(c.services[serviceName], address)
+	c.selfTarget = address
+	c.lock.Unlock()
+	c.notify()
+	return nil
+}
+
+// UnRegister removes the instances added by Register from the address table.
+func (c *ConnManager) UnRegister() error {
+	c.lock.Lock()
+	for _, r := range c.registered {
+		addrs := c.services[r.serviceName]
+		for i, addr := range addrs {
+			if addr == r.address {
+				c.services[r.serviceName] = append(addrs[:i:i], addrs[i+1:]...)
+				break
+			}
+		}
+	}
+	c.registered = nil
+	c.selfTarget = ""
+	c.lock.Unlock()
+	c.notify()
+	return nil
+}
+
+// Close closes all cached connections.
+func (c *ConnManager) Close() {
+	c.lock.Lock()
+	conns := c.conns
+	c.conns = make(map[string]*grpc.ClientConn)
+	c.lock.Unlock()
+	for _, conn := range conns {
+		_ = conn.Close()
+	}
+}
+
+// GetUserIdHashGatewayHost is not supported by static discovery.
+func (c *ConnManager) GetUserIdHashGatewayHost(ctx context.Context, userId string) (string, error) {
+	return "", nil
+}
+
+func (c *ConnManager) dial(ctx context.Context, key string, target string, opts []grpc.DialOption) (*grpc.ClientConn, error) {
+	c.lock.RLock()
+	conn, ok := c.conns[key]
+	options := append(append([]grpc.DialOption(nil), c.dialOptions...), opts...)
+	c.lock.RUnlock()
+	if ok {
+		return conn, nil
+	}
+	// The lock must not be held while dialing, building the resolver registers it with the manager.
+	conn, err := grpc.DialContext(ctx, target, options...)
+	if err != nil {
+		return nil, errs.WrapMsg(err, "dial failed", "target", target)
+	}
+	c.lock.Lock()
+	cached, ok := c.conns[key]
+	if !ok {
+		c.conns[key] = conn
+	}
+	c.lock.Unlock()
+	if ok {
+		_ = conn.Close()
+		return cached, nil
+	}
+	return conn, nil
+}
+
+func (c *ConnManager) addresses(serviceName string) []string {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return append([]string(nil), c.services[serviceName]...)
+}
+
+func (c *ConnManager) notify() {
+	c.lock.RLock()
+	resolvers := make([]*serviceResolver, 0, len(c.resolvers))
+	for r := range c.resolvers {
+		resolvers = append(resolvers, r)
+	}
+	c.lock.RUnlock()
+	for _, r := range resolvers {
+		r.ResolveNow(resolver.ResolveNowOptions{})
+	}
+}
+
+func appendDistinct(addrs []string, address string) []string {
+	for _, addr := range addrs {
+		if addr == address {
+			return addrs
+		}
+	}
+	return append(addrs, address)
+}
+
+// resolverBuilder builds resolvers that read addresses from a ConnManager.
+type resolverBuilder struct {
+	manager *ConnManager
+}
+
+func (b *resolverBuilder) Build(target resolver.Target, cc resolver.ClientConn, _ resolver.BuildOptions) (resolver.Resolver, error) {
+	r := &serviceResolver{manager: b.manager, serviceName: target.Endpoint(), cc: cc}
+	b.manager.lock.Lock()
+	b.manager.resolvers[r] = struct{}{}
+	b.manager.lock.Unlock()
+	r.ResolveNow(resolver.ResolveNowOptions{})
+	return r, nil
+}
+
+func (b *resolverBuilder) Scheme() string {
+	return scheme
+}
+
+type serviceResolver struct {
+	manager     *ConnManager
+	serviceName string
+	cc          resolver.ClientConn
+}
+
+func (r *serviceResolver) ResolveNow(resolver.ResolveNowOptions) {
+	addrs := r.manager.addresses(r.serviceName)
+	state := resolver.State{Addresses: make([]resolver.Address, 0, len(addrs))}
+	for _, addr := range addrs {
+		state.Addresses = append(state.Addresses, resolver.Address{Addr: addr})
+	}
+	_ = r.cc.UpdateState(state)
+}
+
+func (r *serviceResolver) Close() {
+	r.manager.lock.Lock()
+	defer r.manager.lock.Unlock()
+	delete(r.manager.resolvers, r)
+}
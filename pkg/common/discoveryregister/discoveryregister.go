@@ -17,9 +17,11 @@ package discoveryregister
 import (
 	"fmt"
 	"github.com/openimsdk/openim-project-template/pkg/common/config"
+	"github.com/openimsdk/openim-project-template/pkg/common/discoveryregister/direct"
+	"github.com/openimsdk/openim-project-template/pkg/common/discoveryregister/file"
+	"github.com/openimsdk/openim-project-template/pkg/common/discoveryregister/kubernetes"
 	"github.com/openimsdk/tools/discovery"
 	"github.com/openimsdk/tools/discovery/etcd"
-	"github.com/openimsdk/tools/discovery/kubernetes"
 	"github.com/openimsdk/tools/errs"
 	"time"
 )
@@ -38,7 +40,11 @@ func NewDiscoveryRegister(discovery *config.Discovery) (discovery.SvcDiscoveryRe
 			etcd.WithMaxCallSendMsgSize(20*1024*1024),
 			etcd.WithUsernameAndPassword(discovery.Etcd.Username, discovery.Etcd.Password))
 	case "kubernetes":
-		return kubernetes.NewKubernetesConnManager("default")
+		return kubernetes.NewConnManager(&discovery.Kubernetes)
+	case "direct", "static":
+		return direct.NewConnManager(discovery.Direct.Services), nil
+	case "file":
+		return file.NewConnManager(discovery.File.Path)
 	default:
 		return nil, errs.New("unsupported discovery type", "type", discovery.Enable).Wrap()
 	}
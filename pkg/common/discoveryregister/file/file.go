@@ -0,0 +1,136 @@
+// Copyright © 2024 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package file
+
+import (
+	"context"
+	"path/filepath"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/mitchellh/mapstructure"
+	"github.com/openimsdk/openim-project-template/pkg/common/discoveryregister/direct"
+	"github.com/openimsdk/tools/errs"
+	"github.com/openimsdk/tools/log"
+	"github.com/spf13/viper"
+	"google.golang.org/grpc"
+)
+
+// ServiceFile is the content of the watched file, a JSON or YAML document such as
+//
+//	services:
+//	  user: [ 127.0.0.1:10310, 127.0.0.1:10311 ]
+type ServiceFile struct {
+	Services map[string][]string `mapstructure:"services"`
+}
+
+// ConnManager is a static discovery client whose address table is reloaded whenever the file changes.
+type ConnManager struct {
+	*direct.ConnManager
+	path    string
+	watcher *fsnotify.Watcher
+	done    chan struct{}
+}
+
+// NewConnManager loads the service addresses from path and starts watching it for changes.
+func NewConnManager(path string, options ...grpc.DialOption) (*ConnManager, error) {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return nil, errs.WrapMsg(err, "invalid discovery file path", "path", path)
+	}
+	services, err := readServices(path)
+	if err != nil {
+		return nil, err
+	}
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, errs.WrapMsg(err, "create file watcher failed")
+	}
+	// Watch the directory rather than the file, editors and ConfigMap updates replace the file instead of writing it.
+	if err := watcher.Add(filepath.Dir(path)); err != nil {
+		_ = watcher.Close()
+		return nil, errs.WrapMsg(err, "watch discovery file failed", "path", path)
+	}
+	c := &ConnManager{
+		ConnManager: direct.NewConnManager(services, options...),
+		path:        path,
+		watcher:     watcher,
+		done:        make(chan struct{}),
+	}
+	go c.watch()
+	return c, nil
+}
+
+// Close stops watching the file and closes all connections.
+func (c *ConnManager) Close() {
+	select {
+	case <-c.done:
+	default:
+		close(c.done)
+		_ = c.watcher.Close()
+	}
+	c.ConnManager.Close()
+}
+
+func (c *ConnManager) watch() {
+	ctx := context.Background()
+	for {
+		select {
+		case <-c.done:
+			return
+		case event, ok := <-c.watcher.Events:
+			if !ok {
+				return
+			}
+			if filepath.Clean(event.Name) != c.path && !isConfigMapSwap(event.Name) {
+				continue
+			}
+			if !event.Has(fsnotify.Write) && !event.Has(fsnotify.Create) {
+				continue
+			}
+			services, err := readServices(c.path)
+			if err != nil {
+				log.ZWarn(ctx, "reload discovery file failed, keep previous addresses", err, "path", c.path)
+				continue
+			}
+			log.ZInfo(ctx, "discovery file reloaded", "path", c.path, "services", services)
+			c.UpdateServices(services)
+		case err, ok := <-c.watcher.Errors:
+			if !ok {
+				return
+			}
+			log.ZWarn(ctx, "discovery file watcher error", err, "path", c.path)
+		}
+	}
+}
+
+// isConfigMapSwap reports whether the event is Kubernetes atomically swapping a mounted ConfigMap.
+func isConfigMapSwap(name string) bool {
+	return filepath.Base(name) == "..data"
+}
+
+func readServices(path string) (map[string][]string, error) {
+	v := viper.New()
+	v.SetConfigFile(path)
+	if err := v.ReadInConfig(); err != nil {
+		return nil, errs.WrapMsg(err, "failed to read discovery file", "path", path)
+	}
+	var file ServiceFile
+	if err := v.Unmarshal(&file, func(config *mapstructure.DecoderConfig) {
+		config.TagName = "mapstructure"
+	}); err != nil {
+		return nil, errs.WrapMsg(err, "failed to unmarshal discovery file", "path", path)
+	}
+	return file.Services, nil
+}
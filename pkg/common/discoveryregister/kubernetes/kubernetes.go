@@ -0,0 +1,249 @@
+// Copyright © 2024 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kubernetes
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"text/template"
+
+	"github.com/openimsdk/openim-project-template/pkg/common/config"
+	"github.com/openimsdk/tools/errs"
+	"google.golang.org/grpc"
+)
+
+const (
+	serviceAccountNamespaceFile = "/var/run/secrets/kubernetes.io/serviceaccount/namespace"
+	podNamespaceEnv             = "POD_NAMESPACE"
+	defaultNamespace            = "default"
+	defaultClusterDomain        = "cluster.local"
+	defaultServiceName          = "{{.Name}}"
+)
+
+// ConnManager resolves services through the cluster DNS. An rpcRegisterName is mapped to
+// <serviceName>.<namespace>.svc.<clusterDomain>, where serviceName is rendered from a template.
+// Use headless Services so that the DNS name resolves to every pod and requests are balanced across them.
+type ConnManager struct {
+	lock          sync.RWMutex
+	namespace     string
+	clusterDomain string
+	serviceName   *template.Template
+	portName      string
+	port          int
+	dialOptions   []grpc.DialOption
+	conns         map[string]*grpc.ClientConn
+	selfTarget    string
+}
+
+// NewConnManager creates a ConnManager from the kubernetes section of discovery.yml.
+// The namespace is detected from the pod's service account when it is not configured.
+func NewConnManager(conf *config.Kubernetes, options ...grpc.DialOption) (*ConnManager, error) {
+	serviceName := conf.ServiceName
+	if serviceName == "" {
+		serviceName = defaultServiceName
+	}
+	tmpl, err := template.New("serviceName").Option("missingkey=error").Parse(serviceName)
+	if err != nil {
+		return nil, errs.WrapMsg(err, "invalid kubernetes serviceName template", "serviceName", serviceName)
+	}
+	clusterDomain := conf.ClusterDomain
+	if clusterDomain == "" {
+		clusterDomain = defaultClusterDomain
+	}
+	return &ConnManager{
+		namespace:     detectNamespace(conf.Namespace),
+		clusterDomain: clusterDomain,
+		serviceName:   tmpl,
+		portName:      conf.PortName,
+		port:          conf.Port,
+		dialOptions:   options,
+		conns:         make(map[string]*grpc.ClientConn),
+	}, nil
+}
+
+// Namespace returns the namespace services are looked up in.
+func (c *ConnManager) Namespace() string {
+	return c.namespace
+}
+
+// GetConns returns one connection per pod address behind the service.
+func (c *ConnManager) GetConns(ctx context.Context, serviceName string, opts ...grpc.DialOption) ([]*grpc.ClientConn, error) {
+	host, port, err := c.resolve(ctx, serviceName)
+	if err != nil {
+		return nil, err
+	}
+	ips, err := net.DefaultResolver.LookupHost(ctx, host)
+	if err != nil {
+		return nil, errs.WrapMsg(err, "lookup service failed", "host", host)
+	}
+	conns := make([]*grpc.ClientConn, 0, len(ips))
+	for _, ip := range ips {
+		conn, err := c.dial(ctx, net.JoinHostPort(ip, strconv.Itoa(port)), opts)
+		if err != nil {
+			return nil, err
+		}
+		conns = append(conns, conn)
+	}
+	return conns, nil
+}
+
+// GetConn returns a connection to the service, load balanced across the addresses the DNS name resolves to.
+func (c *ConnManager) GetConn(ctx context.Context, serviceName string, opts ...grpc.DialOption) (*grpc.ClientConn, error) {
+	host, port, err := c.resolve(ctx, serviceName)
+	if err != nil {
+		return nil, err
+	}
+	return c.dial(ctx, fmt.Sprintf("dns:///%s", net.JoinHostPort(host, strconv.Itoa(port))), opts)
+}
+
+// GetSelfConnTarget returns the address this instance registered with.
+func (c *ConnManager) GetSelfConnTarget() string {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return c.selfTarget
+}
+
+// AddOption appends dial options used by every new connection.
+func (c *ConnManager) AddOption(opts ...grpc.DialOption) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.dialOptions = append(c.dialOptions, opts...)
+}
+
+// CloseConn closes the connection and drops it from the cache.
+func (c *ConnManager) CloseConn(conn *grpc.ClientConn) {
+	c.lock.Lock()
+	for target, cached := range c.conns {
+		if cached == conn {
+			delete(c.conns, target)
+		}
+	}
+	c.lock.Unlock()
+	_ = conn.Close()
+}
+
+// Register only records the instance address, pods are registered by their Service and readiness state.
+func (c *ConnManager) Register(serviceName, host string, port int, opts ...grpc.DialOption) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.selfTarget = net.JoinHostPort(host, strconv.Itoa(port))
+	return nil
+}
+
+// UnRegister is a no-op, a pod leaves its Service when it stops being ready.
+func (c *ConnManager) UnRegister() error {
+	return nil
+}
+
+// Close closes all cached connections.
+func (c *ConnManager) Close() {
+	c.lock.Lock()
+	conns := c.conns
+	c.conns = make(map[string]*grpc.ClientConn)
+	c.lock.Unlock()
+	for _, conn := range conns {
+		_ = conn.Close()
+	}
+}
+
+// GetUserIdHashGatewayHost is not supported by kubernetes discovery.
+func (c *ConnManager) GetUserIdHashGatewayHost(ctx context.Context, userId string) (string, error) {
+	return "", nil
+}
+
+// resolve maps an rpcRegisterName to the service DNS name and port. A name that already
+// carries a port, such as "user-rpc-service:10310", is used as is.
+func (c *ConnManager) resolve(ctx context.Context, serviceName string) (string, int, error) {
+	if host, portStr, err := net.SplitHostPort(serviceName); err == nil {
+		port, err := strconv.Atoi(portStr)
+		if err != nil {
+			return "", 0, errs.WrapMsg(err, "invalid service port", "serviceName", serviceName)
+		}
+		return c.fqdn(host), port, nil
+	}
+	var buf bytes.Buffer
+	if err := c.serviceName.Execute(&buf, struct{ Name string }{Name: serviceName}); err != nil {
+		return "", 0, errs.WrapMsg(err, "render kubernetes service name failed", "serviceName", serviceName)
+	}
+	host := c.fqdn(buf.String())
+	if c.portName != "" {
+		// Kubernetes publishes named Service ports as _<portName>._tcp.<service> SRV records.
+		_, srvs, err := net.DefaultResolver.LookupSRV(ctx, c.portName, "tcp", host)
+		if err != nil {
+			return "", 0, errs.WrapMsg(err, "lookup service port failed", "host", host, "portName", c.portName)
+		}
+		if len(srvs) == 0 {
+			return "", 0, errs.New("service port not found", "host", host, "portName", c.portName).Wrap()
+		}
+		return host, int(srvs[0].Port), nil
+	}
+	if c.port == 0 {
+		return "", 0, errs.New("kubernetes discovery needs port or portName", "serviceName", serviceName).Wrap()
+	}
+	return host, c.port, nil
+}
+
+func (c *ConnManager) fqdn(service string) string {
+	if strings.Contains(service, ".") {
+		return service
+	}
+	return fmt.Sprintf("%s.%s.svc.%s", service, c.namespace, c.clusterDomain)
+}
+
+func (c *ConnManager) dial(ctx context.Context, target string, opts []grpc.DialOption) (*grpc.ClientConn, error) {
+	c.lock.RLock()
+	conn, ok := c.conns[target]
+	options := append(append([]grpc.DialOption(nil), c.dialOptions...), opts...)
+	c.lock.RUnlock()
+	if ok {
+		return conn, nil
+	}
+	conn, err := grpc.DialContext(ctx, target, options...)
+	if err != nil {
+		return nil, errs.WrapMsg(err, "dial failed", "target", target)
+	}
+	c.lock.Lock()
+	cached, ok := c.conns[target]
+	if !ok {
+		c.conns[target] = conn
+	}
+	c.lock.Unlock()
+	if ok {
+		_ = conn.Close()
+		return cached, nil
+	}
+	return conn, nil
+}
+
+func detectNamespace(namespace string) string {
+	if namespace != "" {
+		return namespace
+	}
+	if namespace = os.Getenv(podNamespaceEnv); namespace != "" {
+		return namespace
+	}
+	if data, err := os.ReadFile(serviceAccountNamespaceFile); err == nil {
+		if namespace = strings.TrimSpace(string(data)); namespace != "" {
+			return namespace
+		}
+	}
+	return defaultNamespace
+}
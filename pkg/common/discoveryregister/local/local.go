@@ -0,0 +1,167 @@
+// Copyright © 2024 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package local
+
+import (
+	"context"
+	"net"
+	"sync"
+
+	"github.com/openimsdk/tools/errs"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+const bufSize = 1024 * 1024
+
+// ConnManager is a service discovery and registry client for services running in the same process.
+// Every service is served on an in-memory listener obtained from Listen, and connections to it
+// never leave the process.
+type ConnManager struct {
+	lock        sync.RWMutex
+	listeners   map[string]*bufconn.Listener
+	registered  map[string]struct{}
+	conns       map[string]*grpc.ClientConn
+	dialOptions []grpc.DialOption
+	selfTarget  string
+}
+
+// NewConnManager creates an empty ConnManager.
+func NewConnManager(options ...grpc.DialOption) *ConnManager {
+	return &ConnManager{
+		listeners:   make(map[string]*bufconn.Listener),
+		registered:  make(map[string]struct{}),
+		conns:       make(map[string]*grpc.ClientConn),
+		dialOptions: options,
+	}
+}
+
+// Listen returns the in-memory listener the service must be served on.
+func (c *ConnManager) Listen(serviceName string) net.Listener {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	lis, ok := c.listeners[serviceName]
+	if !ok {
+		lis = bufconn.Listen(bufSize)
+		c.listeners[serviceName] = lis
+	}
+	return lis
+}
+
+// GetConns returns the single connection of the service.
+func (c *ConnManager) GetConns(ctx context.Context, serviceName string, opts ...grpc.DialOption) ([]*grpc.ClientConn, error) {
+	conn, err := c.GetConn(ctx, serviceName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return []*grpc.ClientConn{conn}, nil
+}
+
+// GetConn returns a connection to the in-memory listener of a registered service.
+func (c *ConnManager) GetConn(ctx context.Context, serviceName string, opts ...grpc.DialOption) (*grpc.ClientConn, error) {
+	c.lock.RLock()
+	conn, ok := c.conns[serviceName]
+	lis := c.listeners[serviceName]
+	_, registered := c.registered[serviceName]
+	options := append(append([]grpc.DialOption(nil), c.dialOptions...), opts...)
+	c.lock.RUnlock()
+	if ok {
+		return conn, nil
+	}
+	if lis == nil || !registered {
+		return nil, errs.New("service is not registered in process", "serviceName", serviceName).Wrap()
+	}
+	options = append(options, grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+		return lis.DialContext(ctx)
+	}))
+	conn, err := grpc.DialContext(ctx, "passthrough:///"+serviceName, options...)
+	if err != nil {
+		return nil, errs.WrapMsg(err, "dial failed", "serviceName", serviceName)
+	}
+	c.lock.Lock()
+	cached, ok := c.conns[serviceName]
+	if !ok {
+		c.conns[serviceName] = conn
+	}
+	c.lock.Unlock()
+	if ok {
+		_ = conn.Close()
+		return cached, nil
+	}
+	return conn, nil
+}
+
+// GetSelfConnTarget returns the name of the last registered service.
+func (c *ConnManager) GetSelfConnTarget() string {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return c.selfTarget
+}
+
+// AddOption appends dial options used by every new connection.
+func (c *ConnManager) AddOption(opts ...grpc.DialOption) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.dialOptions = append(c.dialOptions, opts...)
+}
+
+// CloseConn closes the connection and drops it from the cache.
+func (c *ConnManager) CloseConn(conn *grpc.ClientConn) {
+	c.lock.Lock()
+	for key, cached := range c.conns {
+		if cached == conn {
+			delete(c.conns, key)
+		}
+	}
+	c.lock.Unlock()
+	_ = conn.Close()
+}
+
+// Register makes the service reachable, host and port are ignored as connections stay in process.
+func (c *ConnManager) Register(serviceName, host string, port int, opts ...grpc.DialOption) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if _, ok := c.listeners[serviceName]; !ok {
+		return errs.New("service has no in-process listener", "serviceName", serviceName).Wrap()
+	}
+	c.registered[serviceName] = struct{}{}
+	c.selfTarget = serviceName
+	return nil
+}
+
+// UnRegister makes every registered service unreachable for new connections.
+func (c *ConnManager) UnRegister() error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.registered = make(map[string]struct{})
+	c.selfTarget = ""
+	return nil
+}
+
+// Close closes all cached connections.
+func (c *ConnManager) Close() {
+	c.lock.Lock()
+	conns := c.conns
+	c.conns = make(map[string]*grpc.ClientConn)
+	c.lock.Unlock()
+	for _, conn := range conns {
+		_ = conn.Close()
+	}
+}
+
+// GetUserIdHashGatewayHost is not supported by in-process discovery.
+func (c *ConnManager) GetUserIdHashGatewayHost(ctx context.Context, userId string) (string, error) {
+	return "", nil
+}
@@ -15,21 +15,26 @@
 package ginprometheus
 
 import (
-	"bytes"
+	"context"
 	"fmt"
-	"io"
 	"net/http"
 	"os"
 	"strconv"
+	"sync"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/openimsdk/tools/errs"
+	"github.com/openimsdk/tools/log"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/prometheus/client_golang/prometheus/push"
 )
 
 var defaultMetricPath = "/metrics"
 
+const defaultPushInterval = 15 * time.Second
+
 // counter, counter_vec, gauge, gauge_vec,
 // histogram, histogram_vec, summary, summary_vec.
 var (
@@ -107,6 +112,8 @@ type Prometheus struct {
 	reqSz, resSz  prometheus.Summary
 	router        *gin.Engine
 	listenAddress string
+	registerer    prometheus.Registerer
+	gatherer      prometheus.Gatherer
 	Ppg           PrometheusPushGateway
 
 	MetricsList []*Metric
@@ -121,23 +128,34 @@ type Prometheus struct {
 // PrometheusPushGateway contains the configuration for pushing to a Prometheus pushgateway (optional).
 type PrometheusPushGateway struct {
 
-	// Push interval in seconds
-	PushIntervalSeconds time.Duration
+	// Push interval, defaults to 15 seconds
+	PushInterval time.Duration
 
 	// Push Gateway URL in format http://domain:port
-	// where JOBNAME can be any string of your choice
 	PushGatewayURL string
 
-	// Local metrics URL where metrics are fetched from, this could be omitted in the future
-	// if implemented using prometheus common/expfmt instead
-	MetricsURL string
-
 	// pushgateway job name, defaults to "gin"
 	Job string
+
+	// Grouping labels of the pushed metrics, the instance label defaults to the hostname
+	Grouping map[string]string
+
+	// Gatherer the metrics are read from, defaults to prometheus.DefaultGatherer
+	Gatherer prometheus.Gatherer
+
+	stopOnce sync.Once
+	stop     chan struct{}
+	done     chan struct{}
 }
 
-// NewPrometheus generates a new set of metrics with a certain subsystem name.
+// NewPrometheus generates a new set of metrics with a certain subsystem name, registered on the default registry.
 func NewPrometheus(subsystem string, customMetricsList ...[]*Metric) *Prometheus {
+	return NewPrometheusWithRegistry(subsystem, prometheus.DefaultRegisterer, prometheus.DefaultGatherer, customMetricsList...)
+}
+
+// NewPrometheusWithRegistry generates a new set of metrics registered on registerer, and exposes and pushes
+// the metrics of gatherer.
+func NewPrometheusWithRegistry(subsystem string, registerer prometheus.Registerer, gatherer prometheus.Gatherer, customMetricsList ...[]*Metric) *Prometheus {
 	if subsystem == "" {
 		subsystem = "app"
 	}
@@ -152,6 +170,9 @@ func NewPrometheus(subsystem string, customMetricsList ...[]*Metric) *Prometheus
 	metricsList = append(metricsList, standardMetrics...)
 
 	p := &Prometheus{
+		registerer:  registerer,
+		gatherer:    gatherer,
+		Ppg:         PrometheusPushGateway{Gatherer: gatherer},
 		MetricsList: metricsList,
 		MetricsPath: defaultMetricPath,
 		ReqCntURLLabelMappingFn: func(c *gin.Context) string {
@@ -164,13 +185,12 @@ func NewPrometheus(subsystem string, customMetricsList ...[]*Metric) *Prometheus
 	return p
 }
 
-// SetPushGateway sends metrics to a remote pushgateway exposed on pushGatewayURL
-// every pushIntervalSeconds. Metrics are fetched from metricsURL.
-func (p *Prometheus) SetPushGateway(pushGatewayURL, metricsURL string, pushIntervalSeconds time.Duration) {
+// SetPushGateway sends the gathered metrics to a remote pushgateway exposed on pushGatewayURL
+// every pushInterval, until StopPushGateway is called. Job and grouping labels must be set before.
+func (p *Prometheus) SetPushGateway(pushGatewayURL string, pushInterval time.Duration) {
 	p.Ppg.PushGatewayURL = pushGatewayURL
-	p.Ppg.MetricsURL = metricsURL
-	p.Ppg.PushIntervalSeconds = pushIntervalSeconds
-	p.startPushTicker()
+	p.Ppg.PushInterval = pushInterval
+	p.Ppg.Start()
 }
 
 // SetPushGatewayJob job name, defaults to "gin".
@@ -178,6 +198,16 @@ func (p *Prometheus) SetPushGatewayJob(j string) {
 	p.Ppg.Job = j
 }
 
+// SetPushGatewayGrouping sets the grouping labels of the pushed metrics.
+func (p *Prometheus) SetPushGatewayGrouping(grouping map[string]string) {
+	p.Ppg.Grouping = grouping
+}
+
+// StopPushGateway stops the periodic push and pushes the final state of the metrics.
+func (p *Prometheus) StopPushGateway(ctx context.Context) error {
+	return p.Ppg.Stop(ctx)
+}
+
 // SetListenAddress for exposing metrics on address. If not set, it will be exposed at the
 // same address of the gin engine that is being used.
 func (p *Prometheus) SetListenAddress(address string) {
@@ -200,76 +230,106 @@ func (p *Prometheus) SetListenAddressWithRouter(listenAddress string, r *gin.Eng
 func (p *Prometheus) SetMetricsPath(e *gin.Engine) error {
 
 	if p.listenAddress != "" {
-		p.router.GET(p.MetricsPath, prometheusHandler())
+		p.router.GET(p.MetricsPath, p.prometheusHandler())
 		return p.runServer()
 	} else {
-		e.GET(p.MetricsPath, prometheusHandler())
+		e.GET(p.MetricsPath, p.prometheusHandler())
 		return nil
 	}
 }
 
 // SetMetricsPathWithAuth set metrics paths with authentication.
 func (p *Prometheus) SetMetricsPathWithAuth(e *gin.Engine, accounts gin.Accounts) error {
+	return p.SetMetricsPathWithMiddleware(e, gin.BasicAuth(accounts))
+}
 
+// SetMetricsPathWithMiddleware set metrics paths behind middleware, e.g. a bearer token check.
+func (p *Prometheus) SetMetricsPathWithMiddleware(e *gin.Engine, middleware ...gin.HandlerFunc) error {
+	handlers := append(middleware, p.prometheusHandler())
 	if p.listenAddress != "" {
-		p.router.GET(p.MetricsPath, gin.BasicAuth(accounts), prometheusHandler())
+		p.router.GET(p.MetricsPath, handlers...)
 		return p.runServer()
 	} else {
-		e.GET(p.MetricsPath, gin.BasicAuth(accounts), prometheusHandler())
+		e.GET(p.MetricsPath, handlers...)
 		return nil
 	}
-
 }
 
 func (p *Prometheus) runServer() error {
 	return p.router.Run(p.listenAddress)
 }
 
-func (p *Prometheus) getMetrics() []byte {
-	response, err := http.Get(p.Ppg.MetricsURL)
-	if err != nil {
-		return nil
-	}
-
-	defer response.Body.Close()
-
-	body, _ := io.ReadAll(response.Body)
-	return body
-}
-
 var hostname, _ = os.Hostname()
 
-func (p *Prometheus) getPushGatewayURL() string {
-	if p.Ppg.Job == "" {
-		p.Ppg.Job = "gin"
+func (g *PrometheusPushGateway) pusher() *push.Pusher {
+	job := g.Job
+	if job == "" {
+		job = "gin"
 	}
-	return p.Ppg.PushGatewayURL + "/metrics/job/" + p.Ppg.Job + "/instance/" + hostname
-}
-
-func (p *Prometheus) sendMetricsToPushGateway(metrics []byte) {
-	req, err := http.NewRequest("POST", p.getPushGatewayURL(), bytes.NewBuffer(metrics))
-	if err != nil {
-		return
+	gatherer := g.Gatherer
+	if gatherer == nil {
+		gatherer = prometheus.DefaultGatherer
 	}
-
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		fmt.Println("Error sending to push gateway error:", err.Error())
+	pusher := push.New(g.PushGatewayURL, job).Gatherer(gatherer)
+	if _, ok := g.Grouping["instance"]; !ok {
+		pusher = pusher.Grouping("instance", hostname)
+	}
+	for name, value := range g.Grouping {
+		pusher = pusher.Grouping(name, value)
 	}
+	return pusher
+}
 
-	resp.Body.Close()
+// Push replaces the metrics of the job and grouping on the pushgateway with the current ones.
+func (g *PrometheusPushGateway) Push(ctx context.Context) error {
+	return g.pusher().PushContext(ctx)
 }
 
-func (p *Prometheus) startPushTicker() {
-	ticker := time.NewTicker(time.Second * p.Ppg.PushIntervalSeconds)
+// Start pushes the metrics every PushInterval until Stop is called.
+func (g *PrometheusPushGateway) Start() {
+	interval := g.PushInterval
+	if interval <= 0 {
+		interval = defaultPushInterval
+	}
+	g.stop = make(chan struct{})
+	g.done = make(chan struct{})
+	ticker := time.NewTicker(interval)
 	go func() {
-		for range ticker.C {
-			p.sendMetricsToPushGateway(p.getMetrics())
+		defer close(g.done)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-g.stop:
+				return
+			case <-ticker.C:
+				ctx, cancel := context.WithTimeout(context.Background(), interval)
+				if err := g.Push(ctx); err != nil {
+					log.ZWarn(ctx, "push metrics to push gateway failed", err, "url", g.PushGatewayURL, "job", g.Job)
+				}
+				cancel()
+			}
 		}
 	}()
 }
 
+// Stop ends the periodic push started by Start and pushes a final batch. It can be called again after
+// its context expired.
+func (g *PrometheusPushGateway) Stop(ctx context.Context) error {
+	if g.stop == nil {
+		return nil
+	}
+	g.stopOnce.Do(func() { close(g.stop) })
+	select {
+	case <-g.done:
+	case <-ctx.Done():
+		return errs.WrapMsg(ctx.Err(), "push gateway not stopped")
+	}
+	if err := g.Push(ctx); err != nil {
+		return errs.WrapMsg(err, "push final metrics failed", "url", g.PushGatewayURL)
+	}
+	return nil
+}
+
 // NewMetric associates prometheus.Collector based on Metric.Type.
 func NewMetric(m *Metric, subsystem string) prometheus.Collector {
 	var metric prometheus.Collector
@@ -349,7 +409,7 @@ func NewMetric(m *Metric, subsystem string) prometheus.Collector {
 func (p *Prometheus) registerMetrics(subsystem string) {
 	for _, metricDef := range p.MetricsList {
 		metric := NewMetric(metricDef, subsystem)
-		if err := prometheus.Register(metric); err != nil {
+		if err := p.registerer.Register(metric); err != nil {
 			fmt.Println("could not be registered in Prometheus,metricDef.Name:", metricDef.Name, "   error:", err.Error())
 		}
 
@@ -379,6 +439,12 @@ func (p *Prometheus) UseWithAuth(e *gin.Engine, accounts gin.Accounts) error {
 	return p.SetMetricsPathWithAuth(e, accounts)
 }
 
+// UseWithMiddleware adds the middleware to a gin engine, serving the metrics behind middleware.
+func (p *Prometheus) UseWithMiddleware(e *gin.Engine, middleware ...gin.HandlerFunc) error {
+	e.Use(p.HandlerFunc())
+	return p.SetMetricsPathWithMiddleware(e, middleware...)
+}
+
 // HandlerFunc defines handler function for middleware.
 func (p *Prometheus) HandlerFunc() gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -411,8 +477,8 @@ func (p *Prometheus) HandlerFunc() gin.HandlerFunc {
 	}
 }
 
-func prometheusHandler() gin.HandlerFunc {
-	h := promhttp.Handler()
+func (p *Prometheus) prometheusHandler() gin.HandlerFunc {
+	h := promhttp.InstrumentMetricHandler(p.registerer, promhttp.HandlerFor(p.gatherer, promhttp.HandlerOpts{}))
 	return func(c *gin.Context) {
 		h.ServeHTTP(c.Writer, c.Request)
 	}
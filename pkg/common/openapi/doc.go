@@ -0,0 +1,17 @@
+// Copyright © 2024 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package openapi builds the OpenAPI 3 document of the HTTP API from its routes and the protobuf messages
+// they exchange, wrapped in the errCode/errMsg/data envelope of a2r.
+package openapi // import "github.com/openimsdk/openim-project-template/pkg/common/openapi"
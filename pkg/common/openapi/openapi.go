@@ -0,0 +1,281 @@
+// Copyright © 2024 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package openapi
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/openimsdk/tools/errs"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+const (
+	version = "3.0.3"
+
+	envelopeSchema = "ApiResponse"
+	errCodeSchema  = "ErrCode"
+	tokenScheme    = "token"
+)
+
+// Route is an endpoint of the API, receiving Req as its JSON body and answering Resp as the data of the envelope.
+// A Stream route answers NDJSON of Resp instead, the envelope being written on errors only.
+type Route struct {
+	Method  string
+	Path    string
+	Summary string
+	Req     proto.Message
+	Resp    proto.Message
+	Stream  bool
+}
+
+// Document is an OpenAPI document, marshaled with encoding/json.
+type Document struct {
+	OpenAPI    string                          `json:"openapi"`
+	Info       Info                            `json:"info"`
+	Paths      map[string]map[string]Operation `json:"paths"`
+	Components Components                      `json:"components"`
+}
+
+type Info struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+type Operation struct {
+	Tags        []string              `json:"tags,omitempty"`
+	Summary     string                `json:"summary,omitempty"`
+	OperationID string                `json:"operationId"`
+	Parameters  []Parameter           `json:"parameters,omitempty"`
+	RequestBody *RequestBody          `json:"requestBody,omitempty"`
+	Responses   map[string]Response   `json:"responses"`
+	Security    []map[string][]string `json:"security"`
+}
+
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required"`
+	Schema      *Schema `json:"schema"`
+}
+
+type RequestBody struct {
+	Required bool                 `json:"required"`
+	Content  map[string]MediaType `json:"content"`
+}
+
+type Response struct {
+	Description string               `json:"description"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+type Components struct {
+	Schemas         map[string]*Schema        `json:"schemas"`
+	SecuritySchemes map[string]SecurityScheme `json:"securitySchemes"`
+}
+
+type SecurityScheme struct {
+	Type        string `json:"type"`
+	In          string `json:"in"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+}
+
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	Enum                 []any              `json:"enum,omitempty"`
+	AllOf                []*Schema          `json:"allOf,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	// ErrorCodes names the values of the errCode schema.
+	ErrorCodes []ErrorCode `json:"x-error-codes,omitempty"`
+}
+
+type ErrorCode struct {
+	Code int    `json:"code"`
+	Name string `json:"name"`
+}
+
+// New returns the document of routes. codes are the error codes the API may answer with.
+func New(info Info, routes []Route, codes []errs.CodeError) *Document {
+	doc := &Document{
+		OpenAPI: version,
+		Info:    info,
+		Paths:   make(map[string]map[string]Operation),
+		Components: Components{
+			Schemas: make(map[string]*Schema),
+			SecuritySchemes: map[string]SecurityScheme{
+				tokenScheme: {Type: "apiKey", In: "header", Name: "token", Description: "Token of the user making the request."},
+			},
+		},
+	}
+	doc.Components.Schemas[errCodeSchema] = errCodes(codes)
+	doc.Components.Schemas[envelopeSchema] = &Schema{
+		Type:        "object",
+		Description: "Envelope of every response, data is set when errCode is 0.",
+		Properties: map[string]*Schema{
+			"errCode": {Ref: ref(errCodeSchema)},
+			"errMsg":  {Type: "string"},
+			"errDlt":  {Type: "string", Description: "Detail of the error."},
+			"data":    {Type: "object"},
+		},
+		Required: []string{"errCode", "errMsg", "errDlt"},
+	}
+	for _, route := range routes {
+		method := strings.ToLower(route.Method)
+		if doc.Paths[route.Path] == nil {
+			doc.Paths[route.Path] = make(map[string]Operation)
+		}
+		doc.Paths[route.Path][method] = doc.operation(route)
+	}
+	return doc
+}
+
+func (d *Document) operation(route Route) Operation {
+	op := Operation{
+		Summary:     route.Summary,
+		OperationID: operationID(route.Path),
+		Parameters: []Parameter{{
+			Name:        "operationID",
+			In:          "header",
+			Description: "Identifies the request in logs and traces.",
+			Required:    true,
+			Schema:      &Schema{Type: "string"},
+		}},
+		RequestBody: &RequestBody{
+			Required: true,
+			Content:  map[string]MediaType{"application/json": {Schema: d.message(route.Req.ProtoReflect().Descriptor())}},
+		},
+		Responses: map[string]Response{
+			"200": {
+				Description: "The envelope, its data is set when errCode is 0.",
+				Content: map[string]MediaType{"application/json": {Schema: &Schema{AllOf: []*Schema{
+					{Ref: ref(envelopeSchema)},
+					{Type: "object", Properties: map[string]*Schema{"data": d.message(route.Resp.ProtoReflect().Descriptor())}},
+				}}}},
+			},
+		},
+		Security: []map[string][]string{{tokenScheme: {}}},
+	}
+	if route.Stream {
+		op.Responses["200"] = Response{
+			Description: "A line per message, the envelope alone on an error before the first one, or as the last line on a later error.",
+			Content: map[string]MediaType{
+				"application/x-ndjson": {Schema: d.message(route.Resp.ProtoReflect().Descriptor())},
+				"application/json":     {Schema: &Schema{Ref: ref(envelopeSchema)}},
+			},
+		}
+	}
+	if segments := strings.Split(strings.Trim(route.Path, "/"), "/"); len(segments) > 1 {
+		op.Tags = []string{segments[0]}
+	}
+	return op
+}
+
+// message returns a reference to the schema of the message, adding it and the messages it uses to the components.
+func (d *Document) message(desc protoreflect.MessageDescriptor) *Schema {
+	name := string(desc.FullName())
+	if _, ok := d.Components.Schemas[name]; ok {
+		return &Schema{Ref: ref(name)}
+	}
+	schema := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+	// Set before the fields so that recursive messages refer to it.
+	d.Components.Schemas[name] = schema
+	fields := desc.Fields()
+	for i := 0; i < fields.Len(); i++ {
+		field := fields.Get(i)
+		schema.Properties[string(field.Name())] = d.field(field)
+	}
+	return &Schema{Ref: ref(name)}
+}
+
+// field returns the schema of the field as encoding/json writes it, which is how gin writes the messages.
+func (d *Document) field(field protoreflect.FieldDescriptor) *Schema {
+	if field.IsMap() {
+		return &Schema{Type: "object", AdditionalProperties: d.value(field.MapValue())}
+	}
+	if field.IsList() {
+		return &Schema{Type: "array", Items: d.value(field)}
+	}
+	return d.value(field)
+}
+
+func (d *Document) value(field protoreflect.FieldDescriptor) *Schema {
+	switch field.Kind() {
+	case protoreflect.BoolKind:
+		return &Schema{Type: "boolean"}
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		return &Schema{Type: "integer", Format: "int32"}
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		return &Schema{Type: "integer", Format: "int64"}
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind,
+		protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		return &Schema{Type: "integer", Format: "int64"}
+	case protoreflect.FloatKind:
+		return &Schema{Type: "number", Format: "float"}
+	case protoreflect.DoubleKind:
+		return &Schema{Type: "number", Format: "double"}
+	case protoreflect.StringKind:
+		return &Schema{Type: "string"}
+	case protoreflect.BytesKind:
+		return &Schema{Type: "string", Format: "byte"}
+	case protoreflect.EnumKind:
+		values := field.Enum().Values()
+		schema := &Schema{Type: "integer", Format: "int32"}
+		var names []string
+		for i := 0; i < values.Len(); i++ {
+			schema.Enum = append(schema.Enum, int(values.Get(i).Number()))
+			names = append(names, fmt.Sprintf("%d: %s", values.Get(i).Number(), values.Get(i).Name()))
+		}
+		schema.Description = strings.Join(names, ", ")
+		return schema
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		return d.message(field.Message())
+	default:
+		return &Schema{}
+	}
+}
+
+// errCodes returns the schema of errCode listing codes, 0 being success.
+func errCodes(codes []errs.CodeError) *Schema {
+	schema := &Schema{Type: "integer", Description: "0 on success, the code of the error otherwise.",
+		ErrorCodes: []ErrorCode{{Code: 0, Name: "Success"}}}
+	for _, code := range codes {
+		schema.ErrorCodes = append(schema.ErrorCodes, ErrorCode{Code: code.Code(), Name: code.Msg()})
+	}
+	sort.Slice(schema.ErrorCodes, func(i, j int) bool { return schema.ErrorCodes[i].Code < schema.ErrorCodes[j].Code })
+	return schema
+}
+
+func ref(name string) string {
+	return "#/components/schemas/" + name
+}
+
+// operationID derives the operationId from the path, /user/user_register is user_user_register.
+func operationID(path string) string {
+	return strings.ReplaceAll(strings.Trim(path, "/"), "/", "_")
+}
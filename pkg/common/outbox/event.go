@@ -0,0 +1,74 @@
+// Copyright © 2024 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package outbox
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/openimsdk/openim-project-template/pkg/common/storage/model"
+	"github.com/openimsdk/tools/errs"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Types of the user events.
+const (
+	UserRegistered = "user.registered"
+	UserUpdated    = "user.updated"
+	UserDeleted    = "user.deleted"
+)
+
+// Event is the message delivered to the sinks.
+type Event struct {
+	// ID is unique per event, consumers deduplicate on it since delivery is at least once.
+	ID   string `json:"id"`
+	Type string `json:"type"`
+	// Key is the userID of a user event.
+	Key     string          `json:"key"`
+	Payload json.RawMessage `json:"payload"`
+	// CreateTime is in milliseconds.
+	CreateTime int64 `json:"createTime"`
+}
+
+// UserPayload is the payload of the user events.
+type UserPayload struct {
+	UserID   string `json:"userID"`
+	Nickname string `json:"nickname"`
+}
+
+// NewEvent returns an outbox event of typ about key with payload encoded as JSON.
+func NewEvent(typ string, key string, payload any) (*model.OutboxEvent, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, errs.WrapMsg(err, "marshal outbox event payload failed", "type", typ)
+	}
+	return &model.OutboxEvent{
+		ID:         primitive.NewObjectID(),
+		Type:       typ,
+		Key:        key,
+		Payload:    string(data),
+		CreateTime: time.Now(),
+	}, nil
+}
+
+func eventOf(e *model.OutboxEvent) *Event {
+	return &Event{
+		ID:         e.ID.Hex(),
+		Type:       e.Type,
+		Key:        e.Key,
+		Payload:    json.RawMessage(e.Payload),
+		CreateTime: e.CreateTime.UnixMilli(),
+	}
+}
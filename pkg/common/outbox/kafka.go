@@ -0,0 +1,68 @@
+// Copyright © 2024 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/openimsdk/tools/errs"
+	"github.com/segmentio/kafka-go"
+)
+
+type kafkaSink struct {
+	writer *kafka.Writer
+}
+
+// NewKafkaSink returns a sink producing the events to topic on a Kafka compatible cluster.
+// Messages are keyed by the event key, so the events of a user land on one partition in order.
+func NewKafkaSink(addr []string, topic string) Sink {
+	return &kafkaSink{writer: &kafka.Writer{
+		Addr:         kafka.TCP(addr...),
+		Topic:        topic,
+		Balancer:     &kafka.Hash{},
+		RequiredAcks: kafka.RequireAll,
+		// The relay sends one event at a time and retries failures itself.
+		BatchSize:   1,
+		MaxAttempts: 1,
+	}}
+}
+
+func (k *kafkaSink) Name() string {
+	return "kafka"
+}
+
+func (k *kafkaSink) Send(ctx context.Context, event *Event) error {
+	value, err := json.Marshal(event)
+	if err != nil {
+		return errs.WrapMsg(err, "marshal event failed")
+	}
+	err = k.writer.WriteMessages(ctx, kafka.Message{
+		Key:   []byte(event.Key),
+		Value: value,
+		Headers: []kafka.Header{
+			{Key: "id", Value: []byte(event.ID)},
+			{Key: "type", Value: []byte(event.Type)},
+		},
+	})
+	if err != nil {
+		return errs.WrapMsg(err, "produce event to kafka failed", "topic", k.writer.Topic)
+	}
+	return nil
+}
+
+func (k *kafkaSink) Close() error {
+	return errs.Wrap(k.writer.Close())
+}
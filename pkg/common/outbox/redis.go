@@ -0,0 +1,64 @@
+// Copyright © 2024 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package outbox
+
+import (
+	"context"
+
+	"github.com/openimsdk/tools/errs"
+	"github.com/redis/go-redis/v9"
+)
+
+type redisStreamSink struct {
+	rdb    redis.UniversalClient
+	stream string
+	maxLen int64
+}
+
+// NewRedisStreamSink returns a sink adding the events to stream, trimmed to about maxLen entries when it is positive.
+// The entries have the fields of Event.
+func NewRedisStreamSink(rdb redis.UniversalClient, stream string, maxLen int64) Sink {
+	return &redisStreamSink{rdb: rdb, stream: stream, maxLen: maxLen}
+}
+
+func (r *redisStreamSink) Name() string {
+	return "redisStream"
+}
+
+func (r *redisStreamSink) Send(ctx context.Context, event *Event) error {
+	args := &redis.XAddArgs{
+		Stream: r.stream,
+		Values: []any{
+			"id", event.ID,
+			"type", event.Type,
+			"key", event.Key,
+			"payload", string(event.Payload),
+			"createTime", event.CreateTime,
+		},
+	}
+	if r.maxLen > 0 {
+		args.MaxLen = r.maxLen
+		args.Approx = true
+	}
+	if err := r.rdb.XAdd(ctx, args).Err(); err != nil {
+		return errs.WrapMsg(err, "add event to redis stream failed", "stream", r.stream)
+	}
+	return nil
+}
+
+// Close leaves the client open, it is shared with the service.
+func (r *redisStreamSink) Close() error {
+	return nil
+}
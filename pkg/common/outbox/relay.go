@@ -0,0 +1,309 @@
+// Copyright © 2024 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package outbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/openimsdk/openim-project-template/pkg/common/config"
+	"github.com/openimsdk/openim-project-template/pkg/common/prommetrics"
+	"github.com/openimsdk/openim-project-template/pkg/common/storage/database"
+	"github.com/openimsdk/openim-project-template/pkg/common/storage/model"
+	"github.com/openimsdk/tools/errs"
+	"github.com/openimsdk/tools/log"
+	"github.com/openimsdk/tools/mcontext"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	defaultPollInterval = time.Second
+	defaultBatchSize    = 100
+	defaultLeaseTTL     = 30 * time.Second
+	defaultMinBackoff   = time.Second
+	defaultMaxBackoff   = 5 * time.Minute
+	sendTimeout         = 10 * time.Second
+)
+
+// Relay delivers the outbox events to the sinks, at least once and in the order of their sequence per key, which
+// is the order the changes committed when the events are written in their transaction. An event failing on a sink
+// is retried with exponential backoff and the later events of its key wait for it. There is no order across keys.
+// Only the instance holding the lease delivers, the others stand by.
+type Relay struct {
+	db           database.Outbox
+	sinks        []Sink
+	owner        string
+	pollInterval time.Duration
+	batchSize    int
+	leaseTTL     time.Duration
+	minBackoff   time.Duration
+	maxBackoff   time.Duration
+	maxAttempts  int
+	now          func() time.Time
+
+	// leaseExpire is when the lease taken by the relay expires.
+	leaseExpire time.Time
+	// after and blocked are the progress of the current pass over the outbox: the last event read, and the keys
+	// with an undelivered event, whose later events must wait for the next pass.
+	after   primitive.ObjectID
+	blocked map[string]struct{}
+
+	stopOnce sync.Once
+	stop     chan struct{}
+	done     chan struct{}
+}
+
+// NewRelay returns a relay delivering the events of db to sinks, it does nothing until started.
+func NewRelay(conf *config.Outbox, db database.Outbox, sinks ...Sink) *Relay {
+	host, _ := os.Hostname()
+	r := &Relay{
+		db:           db,
+		sinks:        sinks,
+		owner:        fmt.Sprintf("%s-%d-%s", host, os.Getpid(), primitive.NewObjectID().Hex()),
+		pollInterval: seconds(conf.PollInterval, defaultPollInterval),
+		batchSize:    conf.BatchSize,
+		leaseTTL:     seconds(conf.LeaseTTL, defaultLeaseTTL),
+		minBackoff:   seconds(conf.Retry.MinBackoff, defaultMinBackoff),
+		maxBackoff:   seconds(conf.Retry.MaxBackoff, defaultMaxBackoff),
+		maxAttempts:  conf.Retry.MaxAttempts,
+		now:          time.Now,
+		blocked:      make(map[string]struct{}),
+		stop:         make(chan struct{}),
+		done:         make(chan struct{}),
+	}
+	if r.batchSize <= 0 {
+		r.batchSize = defaultBatchSize
+	}
+	if r.maxBackoff < r.minBackoff {
+		r.maxBackoff = r.minBackoff
+	}
+	return r
+}
+
+// Start polls the outbox in the background until Stop.
+func (r *Relay) Start() {
+	go r.run()
+}
+
+// Stop waits for the delivery in progress, gives up the lease and closes the sinks.
+// Undelivered events stay in the outbox for the next relay.
+func (r *Relay) Stop(ctx context.Context) error {
+	r.stopOnce.Do(func() { close(r.stop) })
+	select {
+	case <-r.done:
+	case <-ctx.Done():
+		return errs.WrapMsg(ctx.Err(), "outbox relay not stopped")
+	}
+	var errList []error
+	if err := r.db.ReleaseLease(ctx, r.owner); err != nil {
+		errList = append(errList, err)
+	}
+	for _, sink := range r.sinks {
+		if err := sink.Close(); err != nil {
+			errList = append(errList, errs.WrapMsg(err, "close outbox sink failed", "sink", sink.Name()))
+		}
+	}
+	return errors.Join(errList...)
+}
+
+func (r *Relay) run() {
+	defer close(r.done)
+	ctx := mcontext.NewCtx("outbox")
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+	for {
+		select {
+		case <-r.stop:
+			return
+		case <-timer.C:
+		}
+		wait := r.pollInterval
+		ok, err := r.acquireLease(ctx)
+		if err != nil || !ok {
+			if err != nil {
+				log.ZError(ctx, "acquire outbox lease failed", err)
+			}
+			// Another relay may deliver, the progress of the pass is stale once the lease comes back.
+			r.endPass()
+		} else {
+			more, err := r.deliver(ctx)
+			if err != nil {
+				log.ZError(ctx, "deliver outbox events failed", err)
+			} else if more {
+				// The pass over the outbox is not over, go on without sleeping.
+				wait = 0
+			}
+		}
+		timer.Reset(wait)
+	}
+}
+
+// deliver sends the next batch of pending events of the current pass over the outbox, it reports whether
+// the pass goes on. Each pass reads the events once, so that the events of blocked keys are not read again
+// and again, and cannot hold up the events of the other keys.
+func (r *Relay) deliver(ctx context.Context) (more bool, err error) {
+	blocked := make([]string, 0, len(r.blocked))
+	for key := range r.blocked {
+		blocked = append(blocked, key)
+	}
+	events, err := r.db.FindPending(ctx, r.after, blocked, r.batchSize)
+	if err != nil {
+		return false, err
+	}
+	keys := make([]string, 0, len(events))
+	for _, event := range events {
+		if !slices.Contains(keys, event.Key) {
+			keys = append(keys, event.Key)
+		}
+	}
+	// The IDs of the events written by different instances are not ordered, an event is only sent once the
+	// earlier events of its key are gone.
+	first, err := r.db.FirstPending(ctx, keys)
+	if err != nil {
+		return false, err
+	}
+	now := r.now()
+	delivered := make([]primitive.ObjectID, 0, len(events))
+	for _, event := range events {
+		if _, ok := r.blocked[event.Key]; ok {
+			r.after = event.ID
+			continue
+		}
+		if event.NextAttemptTime.After(now) {
+			r.blocked[event.Key] = struct{}{}
+			r.after = event.ID
+			continue
+		}
+		if event.Seq != 0 && event.Seq != first[event.Key] {
+			// Sent on the next pass, the earlier events of its key may come later in this one.
+			r.after = event.ID
+			continue
+		}
+		if err := r.keepLease(ctx); err != nil {
+			// Another relay may take over, it starts from the first event not deleted.
+			r.endPass()
+			return false, errors.Join(err, r.db.Delete(ctx, delivered))
+		}
+		if err := r.send(ctx, event); err != nil {
+			r.blocked[event.Key] = struct{}{}
+			r.fail(ctx, event, now, err)
+		} else {
+			delivered = append(delivered, event.ID)
+			first[event.Key] = event.Seq + 1
+		}
+		r.after = event.ID
+	}
+	if len(events) < r.batchSize {
+		r.endPass()
+	}
+	if err := r.db.Delete(ctx, delivered); err != nil {
+		// The events are sent again on the next pass, which at least once delivery allows.
+		r.endPass()
+		return false, err
+	}
+	return len(events) == r.batchSize, nil
+}
+
+// endPass makes the next delivery start a pass from the oldest pending event.
+func (r *Relay) endPass() {
+	r.after = primitive.NilObjectID
+	clear(r.blocked)
+}
+
+// acquireLease takes or renews the lease, it reports whether the relay holds it.
+func (r *Relay) acquireLease(ctx context.Context) (bool, error) {
+	start := time.Now()
+	ok, err := r.db.AcquireLease(ctx, r.owner, r.leaseTTL)
+	if err != nil || !ok {
+		return false, err
+	}
+	r.leaseExpire = start.Add(r.leaseTTL)
+	return true, nil
+}
+
+// keepLease renews the lease once half of its TTL elapsed, so that it does not expire while sending an event
+// and let another relay send the later events of its key first.
+func (r *Relay) keepLease(ctx context.Context) error {
+	if time.Until(r.leaseExpire) > r.leaseTTL/2 {
+		return nil
+	}
+	ok, err := r.acquireLease(ctx)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errs.New("outbox lease lost", "owner", r.owner).Wrap()
+	}
+	return nil
+}
+
+// send sends event to the sinks it was not delivered to, adding those succeeding to event.Delivered.
+func (r *Relay) send(ctx context.Context, event *model.OutboxEvent) error {
+	var errList []error
+	for _, sink := range r.sinks {
+		if slices.Contains(event.Delivered, sink.Name()) {
+			continue
+		}
+		sendCtx, cancel := context.WithTimeout(ctx, sendTimeout)
+		err := sink.Send(sendCtx, eventOf(event))
+		cancel()
+		if err != nil {
+			prommetrics.OutboxFailedCounter.WithLabelValues(sink.Name()).Inc()
+			errList = append(errList, errs.WrapMsg(err, "send outbox event failed", "sink", sink.Name()))
+			continue
+		}
+		prommetrics.OutboxDeliveredCounter.WithLabelValues(sink.Name()).Inc()
+		event.Delivered = append(event.Delivered, sink.Name())
+	}
+	return errors.Join(errList...)
+}
+
+// fail schedules the retry of event, or marks it dead once its attempts are exhausted.
+func (r *Relay) fail(ctx context.Context, event *model.OutboxEvent, now time.Time, err error) {
+	event.Attempts++
+	event.LastError = err.Error()
+	event.NextAttemptTime = now.Add(r.backoff(event.Attempts))
+	if r.maxAttempts > 0 && event.Attempts >= r.maxAttempts {
+		event.Dead = true
+		prommetrics.OutboxDeadCounter.Inc()
+		log.ZError(ctx, "outbox event is dead", err, "id", event.ID.Hex(), "type", event.Type, "key", event.Key, "attempts", event.Attempts)
+	} else {
+		log.ZWarn(ctx, "outbox event will be retried", err, "id", event.ID.Hex(), "type", event.Type, "key", event.Key, "attempts", event.Attempts, "next", event.NextAttemptTime)
+	}
+	if err := r.db.UpdateAttempt(ctx, event); err != nil {
+		log.ZError(ctx, "update outbox event attempt failed", err, "id", event.ID.Hex())
+	}
+}
+
+// backoff returns the delay before the retry following the given number of attempts.
+func (r *Relay) backoff(attempts int) time.Duration {
+	d := r.minBackoff
+	for i := 1; i < attempts && d < r.maxBackoff; i++ {
+		d *= 2
+	}
+	return min(d, r.maxBackoff)
+}
+
+func seconds(n int, def time.Duration) time.Duration {
+	if n <= 0 {
+		return def
+	}
+	return time.Duration(n) * time.Second
+}
@@ -0,0 +1,450 @@
+// Copyright © 2024 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package outbox
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/openimsdk/openim-project-template/pkg/common/config"
+	"github.com/openimsdk/openim-project-template/pkg/common/storage/model"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// memoryOutbox is an in-memory database.Outbox.
+type memoryOutbox struct {
+	lock   sync.Mutex
+	events []*model.OutboxEvent
+	// seqs is the last sequence taken for each key.
+	seqs  map[string]int64
+	owner string
+	// leases counts the leases taken or renewed.
+	leases int
+}
+
+func (m *memoryOutbox) Create(ctx context.Context, events []*model.OutboxEvent) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if m.seqs == nil {
+		m.seqs = make(map[string]int64)
+	}
+	for _, e := range events {
+		m.seqs[e.Key]++
+		e.Seq = m.seqs[e.Key]
+		c := *e
+		m.events = append(m.events, &c)
+	}
+	return nil
+}
+
+func (m *memoryOutbox) FirstPending(ctx context.Context, keys []string) (map[string]int64, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	seqs := make(map[string]int64)
+	for _, e := range m.events {
+		if e.Dead || e.Seq == 0 || !slices.Contains(keys, e.Key) {
+			continue
+		}
+		if seq, ok := seqs[e.Key]; !ok || e.Seq < seq {
+			seqs[e.Key] = e.Seq
+		}
+	}
+	return seqs, nil
+}
+
+func (m *memoryOutbox) FindPending(ctx context.Context, after primitive.ObjectID, excludeKeys []string, limit int) ([]*model.OutboxEvent, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	var res []*model.OutboxEvent
+	events := slices.Clone(m.events)
+	slices.SortFunc(events, func(a, b *model.OutboxEvent) int { return bytes.Compare(a.ID[:], b.ID[:]) })
+	for _, e := range events {
+		if e.Dead || (!after.IsZero() && e.ID.Hex() <= after.Hex()) || slices.Contains(excludeKeys, e.Key) {
+			continue
+		}
+		if len(res) == limit {
+			break
+		}
+		c := *e
+		c.Delivered = slices.Clone(e.Delivered)
+		res = append(res, &c)
+	}
+	return res, nil
+}
+
+func (m *memoryOutbox) Delete(ctx context.Context, ids []primitive.ObjectID) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.events = slices.DeleteFunc(m.events, func(e *model.OutboxEvent) bool { return slices.Contains(ids, e.ID) })
+	return nil
+}
+
+func (m *memoryOutbox) UpdateAttempt(ctx context.Context, event *model.OutboxEvent) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	for i, e := range m.events {
+		if e.ID == event.ID {
+			c := *event
+			c.Delivered = slices.Clone(event.Delivered)
+			m.events[i] = &c
+		}
+	}
+	return nil
+}
+
+func (m *memoryOutbox) AcquireLease(ctx context.Context, owner string, ttl time.Duration) (bool, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if m.owner != "" && m.owner != owner {
+		return false, nil
+	}
+	m.owner = owner
+	m.leases++
+	return true, nil
+}
+
+func (m *memoryOutbox) ReleaseLease(ctx context.Context, owner string) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if m.owner == owner {
+		m.owner = ""
+	}
+	return nil
+}
+
+func (m *memoryOutbox) pending() []*model.OutboxEvent {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	return slices.Clone(m.events)
+}
+
+func addEvents(t *testing.T, db *memoryOutbox, keys ...string) []string {
+	t.Helper()
+	ids := make([]string, 0, len(keys))
+	for _, key := range keys {
+		event, err := NewEvent(UserRegistered, key, &UserPayload{UserID: key})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := db.Create(context.Background(), []*model.OutboxEvent{event}); err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, event.ID.Hex())
+	}
+	return ids
+}
+
+func eventIDs(events []*Event) []string {
+	ids := make([]string, 0, len(events))
+	for _, e := range events {
+		ids = append(ids, e.ID)
+	}
+	return ids
+}
+
+func newTestRelay(conf *config.Outbox, db *memoryOutbox, sinks ...Sink) (*Relay, *time.Time) {
+	r := NewRelay(conf, db, sinks...)
+	now := time.Now()
+	r.now = func() time.Time { return now }
+	return r, &now
+}
+
+func TestRelayDeliversToEverySink(t *testing.T) {
+	db := &memoryOutbox{}
+	ids := addEvents(t, db, "u1", "u2", "u1")
+	webhook, stream := NewMemorySink("webhook"), NewMemorySink("stream")
+	r, _ := newTestRelay(&config.Outbox{}, db, webhook, stream)
+
+	if _, err := r.deliver(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	for _, sink := range []*MemorySink{webhook, stream} {
+		if got := eventIDs(sink.Events()); !slices.Equal(got, ids) {
+			t.Errorf("%s got %v, want %v", sink.Name(), got, ids)
+		}
+	}
+	if n := len(db.pending()); n != 0 {
+		t.Errorf("%d events left in the outbox", n)
+	}
+}
+
+func TestRelayRetriesInOrderPerKey(t *testing.T) {
+	db := &memoryOutbox{}
+	ids := addEvents(t, db, "u1", "u2", "u1")
+	good, flaky := NewMemorySink("good"), NewMemorySink("flaky")
+	flaky.FailWith(func(e *Event) error {
+		if e.ID == ids[0] {
+			return errors.New("unavailable")
+		}
+		return nil
+	})
+	r, now := newTestRelay(&config.Outbox{Retry: config.OutboxRetry{MinBackoff: 2, MaxBackoff: 10}}, db, good, flaky)
+	ctx := context.Background()
+
+	if _, err := r.deliver(ctx); err != nil {
+		t.Fatal(err)
+	}
+	// The second event of u1 waits for the first, u2 is not held up.
+	if got, want := eventIDs(flaky.Events()), []string{ids[1]}; !slices.Equal(got, want) {
+		t.Fatalf("flaky got %v, want %v", got, want)
+	}
+	if got, want := eventIDs(good.Events()), []string{ids[0], ids[1]}; !slices.Equal(got, want) {
+		t.Fatalf("good got %v, want %v", got, want)
+	}
+	pending := db.pending()
+	if len(pending) != 2 || pending[0].Attempts != 1 || !pending[0].NextAttemptTime.Equal(now.Add(2*time.Second)) {
+		t.Fatalf("unexpected pending events %+v", pending)
+	}
+
+	flaky.FailWith(nil)
+	// Still backing off, nothing is sent.
+	if _, err := r.deliver(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(flaky.Events()); n != 1 {
+		t.Fatalf("flaky got %d events during the backoff", n)
+	}
+
+	*now = now.Add(2 * time.Second)
+	if _, err := r.deliver(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := eventIDs(flaky.Events()), []string{ids[1], ids[0], ids[2]}; !slices.Equal(got, want) {
+		t.Errorf("flaky got %v, want %v", got, want)
+	}
+	// The sink that accepted the event the first time does not get it again.
+	if got, want := eventIDs(good.Events()), ids; !slices.Equal(got, want) {
+		t.Errorf("good got %v, want %v", got, want)
+	}
+	if n := len(db.pending()); n != 0 {
+		t.Errorf("%d events left in the outbox", n)
+	}
+}
+
+func TestRelayMarksEventDead(t *testing.T) {
+	db := &memoryOutbox{}
+	ids := addEvents(t, db, "u1", "u1")
+	sink := NewMemorySink("sink")
+	sink.FailWith(func(e *Event) error {
+		if e.ID == ids[0] {
+			return errors.New("rejected")
+		}
+		return nil
+	})
+	r, now := newTestRelay(&config.Outbox{Retry: config.OutboxRetry{MaxAttempts: 2}}, db, sink)
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		if _, err := r.deliver(ctx); err != nil {
+			t.Fatal(err)
+		}
+		*now = now.Add(time.Hour)
+	}
+	pending := db.pending()
+	if len(pending) != 1 || !pending[0].Dead || pending[0].Attempts != 2 || pending[0].LastError == "" {
+		t.Fatalf("unexpected pending events %+v", pending)
+	}
+	// The events after a dead one are delivered.
+	if got, want := eventIDs(sink.Events()), ids[1:]; !slices.Equal(got, want) {
+		t.Errorf("sink got %v, want %v", got, want)
+	}
+}
+
+func TestRelayBatches(t *testing.T) {
+	db := &memoryOutbox{}
+	ids := addEvents(t, db, "u1", "u2", "u3")
+	sink := NewMemorySink("sink")
+	r, _ := newTestRelay(&config.Outbox{BatchSize: 2}, db, sink)
+
+	full, err := r.deliver(context.Background())
+	if err != nil || !full {
+		t.Fatalf("first batch full %v, err %v", full, err)
+	}
+	full, err = r.deliver(context.Background())
+	if err != nil || full {
+		t.Fatalf("second batch full %v, err %v", full, err)
+	}
+	if got := eventIDs(sink.Events()); !slices.Equal(got, ids) {
+		t.Errorf("sink got %v, want %v", got, ids)
+	}
+}
+
+func TestRelayBlockedKeyDoesNotHoldUpOthers(t *testing.T) {
+	db := &memoryOutbox{}
+	ids := addEvents(t, db, "u1", "u1", "u1", "u2")
+	sink := NewMemorySink("sink")
+	sink.FailWith(func(e *Event) error {
+		if e.ID == ids[0] {
+			return errors.New("unavailable")
+		}
+		return nil
+	})
+	r, _ := newTestRelay(&config.Outbox{BatchSize: 2}, db, sink)
+	ctx := context.Background()
+
+	for i, want := range []bool{true, false, true, false} {
+		more, err := r.deliver(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if more != want {
+			t.Fatalf("delivery %d reported more %v, want %v", i, more, want)
+		}
+	}
+	// The pass goes past the events of u1 waiting for the first one.
+	if got, want := eventIDs(sink.Events()), ids[3:]; !slices.Equal(got, want) {
+		t.Errorf("sink got %v, want %v", got, want)
+	}
+	if pending := db.pending(); len(pending) != 3 || pending[0].Attempts != 1 {
+		t.Errorf("unexpected pending events %+v", pending)
+	}
+}
+
+func TestRelayDeliversInSequenceOrder(t *testing.T) {
+	db := &memoryOutbox{}
+	// Another instance wrote the second event of u1 with an earlier ID.
+	var events []*model.OutboxEvent
+	for _, id := range []primitive.ObjectID{primitive.NewObjectID(), primitive.NewObjectIDFromTimestamp(time.Now().Add(-time.Second))} {
+		event, err := NewEvent(UserUpdated, "u1", &UserPayload{UserID: "u1"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		event.ID = id
+		if err := db.Create(context.Background(), []*model.OutboxEvent{event}); err != nil {
+			t.Fatal(err)
+		}
+		events = append(events, event)
+	}
+	sink := NewMemorySink("sink")
+	r, _ := newTestRelay(&config.Outbox{}, db, sink)
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		if _, err := r.deliver(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got, want := eventIDs(sink.Events()), []string{events[0].ID.Hex(), events[1].ID.Hex()}; !slices.Equal(got, want) {
+		t.Errorf("sink got %v, want %v", got, want)
+	}
+	if n := len(db.pending()); n != 0 {
+		t.Errorf("%d events left in the outbox", n)
+	}
+}
+
+func TestRelayRenewsLease(t *testing.T) {
+	db := &memoryOutbox{}
+	addEvents(t, db, "u1", "u2")
+	sink := NewMemorySink("sink")
+	r, _ := newTestRelay(&config.Outbox{}, db, sink)
+	ctx := context.Background()
+
+	if ok, err := r.acquireLease(ctx); err != nil || !ok {
+		t.Fatalf("acquire lease %v, err %v", ok, err)
+	}
+	if _, err := r.deliver(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if db.leases != 1 {
+		t.Errorf("lease taken %d times, want 1 while it is fresh", db.leases)
+	}
+
+	addEvents(t, db, "u1", "u2")
+	r.leaseExpire = time.Now().Add(r.leaseTTL / 4)
+	if _, err := r.deliver(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if db.leases != 2 {
+		t.Errorf("lease taken %d times, want it renewed once", db.leases)
+	}
+	if n := len(sink.Events()); n != 4 {
+		t.Errorf("sink got %d events, want 4", n)
+	}
+}
+
+func TestRelayStopsWhenLeaseLost(t *testing.T) {
+	db := &memoryOutbox{}
+	addEvents(t, db, "u1")
+	sink := NewMemorySink("sink")
+	r, _ := newTestRelay(&config.Outbox{}, db, sink)
+	ctx := context.Background()
+
+	if ok, err := r.acquireLease(ctx); err != nil || !ok {
+		t.Fatalf("acquire lease %v, err %v", ok, err)
+	}
+	// The lease expired and another relay took it.
+	r.leaseExpire = time.Now()
+	db.owner = "other"
+	if _, err := r.deliver(ctx); err == nil {
+		t.Fatal("delivered without the lease")
+	}
+	if n := len(sink.Events()); n != 0 {
+		t.Errorf("sink got %d events without the lease", n)
+	}
+}
+
+func TestRelayBackoff(t *testing.T) {
+	r := NewRelay(&config.Outbox{Retry: config.OutboxRetry{MinBackoff: 1, MaxBackoff: 5}}, &memoryOutbox{})
+	want := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second}
+	for i, w := range want {
+		if got := r.backoff(i + 1); got != w {
+			t.Errorf("backoff(%d) = %v, want %v", i+1, got, w)
+		}
+	}
+}
+
+func TestRelayStartStop(t *testing.T) {
+	db := &memoryOutbox{}
+	ids := addEvents(t, db, "u1", "u2")
+	sink := NewMemorySink("sink")
+	r := NewRelay(&config.Outbox{}, db, sink)
+	r.Start()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for len(sink.Events()) < len(ids) && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := r.Stop(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if got := eventIDs(sink.Events()); !slices.Equal(got, ids) {
+		t.Errorf("sink got %v, want %v", got, ids)
+	}
+	if db.owner != "" {
+		t.Errorf("lease still held by %s", db.owner)
+	}
+}
+
+func TestRelayWaitsForLease(t *testing.T) {
+	db := &memoryOutbox{owner: "other"}
+	addEvents(t, db, "u1")
+	sink := NewMemorySink("sink")
+	r := NewRelay(&config.Outbox{}, db, sink)
+	r.Start()
+	time.Sleep(50 * time.Millisecond)
+	if err := r.Stop(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(sink.Events()); n != 0 {
+		t.Errorf("relay without the lease sent %d events", n)
+	}
+}
@@ -0,0 +1,109 @@
+// Copyright © 2024 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package outbox
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/openimsdk/openim-project-template/pkg/common/config"
+	"github.com/openimsdk/tools/errs"
+	"github.com/redis/go-redis/v9"
+)
+
+// Sink delivers events to a consumer. Send must return an error unless the event was accepted,
+// the relay then retries it later.
+type Sink interface {
+	// Name identifies the sink in the delivered events and the logs, it must not change.
+	Name() string
+	Send(ctx context.Context, event *Event) error
+	Close() error
+}
+
+// MemorySink keeps the events sent to it, it is meant for tests.
+type MemorySink struct {
+	name string
+
+	lock   sync.Mutex
+	events []*Event
+	fail   func(event *Event) error
+}
+
+// NewMemorySink returns an empty sink named name.
+func NewMemorySink(name string) *MemorySink {
+	return &MemorySink{name: name}
+}
+
+func (m *MemorySink) Name() string {
+	return m.name
+}
+
+// FailWith makes Send return the result of fail, events it fails are not kept. A nil fail accepts every event.
+func (m *MemorySink) FailWith(fail func(event *Event) error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.fail = fail
+}
+
+func (m *MemorySink) Send(ctx context.Context, event *Event) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if m.fail != nil {
+		if err := m.fail(event); err != nil {
+			return err
+		}
+	}
+	m.events = append(m.events, event)
+	return nil
+}
+
+// Events returns the events sent so far, in the order they were sent.
+func (m *MemorySink) Events() []*Event {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	return append([]*Event(nil), m.events...)
+}
+
+func (m *MemorySink) Close() error {
+	return nil
+}
+
+// NewSinks returns the sinks enabled in conf. rdb is only used by the Redis Streams sink.
+func NewSinks(conf *config.Outbox, rdb redis.UniversalClient) ([]Sink, error) {
+	var sinks []Sink
+	if conf.Webhook.Enable {
+		if conf.Webhook.URL == "" {
+			return nil, errs.New("outbox webhook url is empty").Wrap()
+		}
+		sinks = append(sinks, NewWebhookSink(conf.Webhook.URL, time.Duration(conf.Webhook.Timeout)*time.Second))
+	}
+	if conf.RedisStream.Enable {
+		if rdb == nil {
+			return nil, errs.New("outbox redis stream needs redis").Wrap()
+		}
+		sinks = append(sinks, NewRedisStreamSink(rdb, conf.RedisStream.Stream, conf.RedisStream.MaxLen))
+	}
+	if conf.Kafka.Enable {
+		if len(conf.Kafka.Addr) == 0 || conf.Kafka.Topic == "" {
+			return nil, errs.New("outbox kafka addr and topic are required").Wrap()
+		}
+		sinks = append(sinks, NewKafkaSink(conf.Kafka.Addr, conf.Kafka.Topic))
+	}
+	if len(sinks) == 0 {
+		return nil, errs.New("outbox is enabled without any sink").Wrap()
+	}
+	return sinks, nil
+}
@@ -0,0 +1,80 @@
+// Copyright © 2024 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package outbox
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/openimsdk/tools/errs"
+)
+
+const defaultWebhookTimeout = 5 * time.Second
+
+// Headers set on the webhook requests, the body is the JSON Event.
+const (
+	HeaderEventID   = "X-OpenIM-Event-ID"
+	HeaderEventType = "X-OpenIM-Event-Type"
+)
+
+type webhookSink struct {
+	url    string
+	client *http.Client
+}
+
+// NewWebhookSink returns a sink posting the events to url, responses other than 2xx are failures.
+func NewWebhookSink(url string, timeout time.Duration) Sink {
+	if timeout <= 0 {
+		timeout = defaultWebhookTimeout
+	}
+	return &webhookSink{url: url, client: &http.Client{Timeout: timeout}}
+}
+
+func (w *webhookSink) Name() string {
+	return "webhook"
+}
+
+func (w *webhookSink) Send(ctx context.Context, event *Event) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return errs.WrapMsg(err, "marshal event failed")
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url, bytes.NewReader(body))
+	if err != nil {
+		return errs.WrapMsg(err, "new webhook request failed", "url", w.url)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderEventID, event.ID)
+	req.Header.Set(HeaderEventType, event.Type)
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return errs.WrapMsg(err, "post webhook failed", "url", w.url)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<16))
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return errs.New("webhook responded with an error", "url", w.url, "status", resp.StatusCode).Wrap()
+	}
+	return nil
+}
+
+func (w *webhookSink) Close() error {
+	w.client.CloseIdleConnections()
+	return nil
+}
@@ -0,0 +1,98 @@
+// Copyright © 2024 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prommetrics
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/openimsdk/openim-project-template/pkg/common/config"
+)
+
+const defaultMetricsPath = "/metrics"
+
+// MetricsPath returns the configured path of the metrics endpoint.
+func MetricsPath(path string) string {
+	if path == "" {
+		return defaultMetricsPath
+	}
+	return path
+}
+
+// AuthEnabled reports whether scraping requires credentials.
+func AuthEnabled(conf *config.MetricsAuth) bool {
+	return len(conf.Accounts) > 0 || conf.Token != ""
+}
+
+// Authorized reports whether the request carries one of the configured basic auth accounts or the bearer token.
+func Authorized(conf *config.MetricsAuth, r *http.Request) bool {
+	if !AuthEnabled(conf) {
+		return true
+	}
+	if username, password, ok := r.BasicAuth(); ok {
+		for _, account := range conf.Accounts {
+			if secureEqual(username, account.Username) && secureEqual(password, account.Password) {
+				return true
+			}
+		}
+		return false
+	}
+	if token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok && conf.Token != "" {
+		return secureEqual(token, conf.Token)
+	}
+	return false
+}
+
+// AuthHandler rejects the requests that are not Authorized before they reach next.
+func AuthHandler(conf *config.MetricsAuth, next http.Handler) http.Handler {
+	if !AuthEnabled(conf) {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !Authorized(conf, r) {
+			unauthorized(w, conf)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// GinAuth is AuthHandler as gin middlewares, none when auth is not configured.
+func GinAuth(conf *config.MetricsAuth) []gin.HandlerFunc {
+	if !AuthEnabled(conf) {
+		return nil
+	}
+	return []gin.HandlerFunc{func(c *gin.Context) {
+		if !Authorized(conf, c.Request) {
+			unauthorized(c.Writer, conf)
+			c.Abort()
+			return
+		}
+		c.Next()
+	}}
+}
+
+func unauthorized(w http.ResponseWriter, conf *config.MetricsAuth) {
+	if len(conf.Accounts) > 0 {
+		w.Header().Set("WWW-Authenticate", `Basic realm="metrics"`)
+	}
+	http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+}
+
+func secureEqual(a, b string) bool {
+	return subtle.ConstantTimeCompare([]byte(a), []byte(b)) == 1
+}
@@ -14,17 +14,242 @@
 
 package prommetrics
 
-import ginprom "github.com/openimsdk/openim-project-template/pkg/common/ginprometheus"
+import (
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/openimsdk/openim-project-template/pkg/common/config"
+	"github.com/openimsdk/tools/errs"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// ginSubsystem prefixes the names of the API metrics, as for the request metrics of ginprometheus.
+const ginSubsystem = "app"
+
+// Types of a config.CustomMetric.
+const (
+	GinCounterType   = "counter"
+	GinGaugeType     = "gauge"
+	GinHistogramType = "histogram"
+)
+
+// Label values taken from the request when a config.MetricLabel has neither header nor query.
+const (
+	RouteLabel  = "route"
+	MethodLabel = "method"
+	CodeLabel   = "code"
+)
+
+const (
+	unknownLabelValue = "unknown"
+	otherLabelValue   = "other"
+)
+
+// ginLabels reads the label values of a metric from a request.
+type ginLabels []config.MetricLabel
+
+func (l ginLabels) names() []string {
+	names := make([]string, len(l))
+	for i, label := range l {
+		names[i] = label.Name
+	}
+	return names
+}
+
+func (l ginLabels) values(c *gin.Context) []string {
+	values := make([]string, len(l))
+	for i, label := range l {
+		var v string
+		switch {
+		case label.Header != "":
+			v = c.GetHeader(label.Header)
+		case label.Query != "":
+			v = c.Query(label.Query)
+		case label.Name == RouteLabel:
+			v = c.FullPath()
+		case label.Name == MethodLabel:
+			v = c.Request.Method
+		case label.Name == CodeLabel:
+			v = strconv.Itoa(c.Writer.Status())
+		}
+		switch {
+		case v == "":
+			v = unknownLabelValue
+		case len(label.Values) > 0 && !slices.Contains(label.Values, v):
+			// Bounds the series of labels read from clients.
+			v = otherLabelValue
+		}
+		values[i] = v
+	}
+	return values
+}
 
 /*
-labels := prometheus.Labels{"label_one": "any", "label_two": "value"}
-ApiCustomCnt.MetricCollector.(*prometheus.CounterVec).With(labels).Inc().
+Handlers declare their metrics once and record them with the request:
+
+	var usersInfoCounter = prommetrics.NewGinCounter("get_users_info_total", "Users info requests per platform.",
+		config.MetricLabel{Name: "platform", Header: "platform", Values: []string{"ios", "android", "web"}})
+
+	usersInfoCounter.Inc(c)
 */
-var (
-	ApiCustomCnt = &ginprom.Metric{
-		Name:        "custom_total",
-		Description: "Custom counter events.",
-		Type:        "counter_vec",
-		Args:        []string{"label_one", "label_two"},
+
+// GinCounter is a counter of the API labelled from the request it is recorded for.
+type GinCounter struct {
+	vec    *prometheus.CounterVec
+	labels ginLabels
+}
+
+// NewGinCounter declares a counter of the API, named app_<name>.
+func NewGinCounter(name, help string, labels ...config.MetricLabel) *GinCounter {
+	m := newGinCounter(name, help, labels)
+	Register(m.vec)
+	return m
+}
+
+func newGinCounter(name, help string, labels ginLabels) *GinCounter {
+	return &GinCounter{
+		vec:    prometheus.NewCounterVec(prometheus.CounterOpts{Subsystem: ginSubsystem, Name: name, Help: help}, labels.names()),
+		labels: labels,
 	}
-)
+}
+
+// Inc increments the counter for the request of c.
+func (m *GinCounter) Inc(c *gin.Context) {
+	m.vec.WithLabelValues(m.labels.values(c)...).Inc()
+}
+
+// Add adds v to the counter for the request of c.
+func (m *GinCounter) Add(c *gin.Context, v float64) {
+	m.vec.WithLabelValues(m.labels.values(c)...).Add(v)
+}
+
+// GinGauge is a gauge of the API labelled from the request it is recorded for.
+type GinGauge struct {
+	vec    *prometheus.GaugeVec
+	labels ginLabels
+}
+
+// NewGinGauge declares a gauge of the API, named app_<name>.
+func NewGinGauge(name, help string, labels ...config.MetricLabel) *GinGauge {
+	m := newGinGauge(name, help, labels)
+	Register(m.vec)
+	return m
+}
+
+func newGinGauge(name, help string, labels ginLabels) *GinGauge {
+	return &GinGauge{
+		vec:    prometheus.NewGaugeVec(prometheus.GaugeOpts{Subsystem: ginSubsystem, Name: name, Help: help}, labels.names()),
+		labels: labels,
+	}
+}
+
+// Set sets the gauge to v for the request of c.
+func (m *GinGauge) Set(c *gin.Context, v float64) {
+	m.vec.WithLabelValues(m.labels.values(c)...).Set(v)
+}
+
+// Add adds v, which may be negative, to the gauge for the request of c.
+func (m *GinGauge) Add(c *gin.Context, v float64) {
+	m.vec.WithLabelValues(m.labels.values(c)...).Add(v)
+}
+
+// GinHistogram is a histogram of the API labelled from the request it is recorded for.
+type GinHistogram struct {
+	vec    *prometheus.HistogramVec
+	labels ginLabels
+}
+
+// NewGinHistogram declares a histogram of the API, named app_<name>. The default buckets are used when buckets is empty.
+func NewGinHistogram(name, help string, buckets []float64, labels ...config.MetricLabel) *GinHistogram {
+	m := newGinHistogram(name, help, buckets, labels)
+	Register(m.vec)
+	return m
+}
+
+func newGinHistogram(name, help string, buckets []float64, labels ginLabels) *GinHistogram {
+	return &GinHistogram{
+		vec:    prometheus.NewHistogramVec(prometheus.HistogramOpts{Subsystem: ginSubsystem, Name: name, Help: help, Buckets: buckets}, labels.names()),
+		labels: labels,
+	}
+}
+
+// Observe adds v to the histogram for the request of c.
+func (m *GinHistogram) Observe(c *gin.Context, v float64) {
+	m.vec.WithLabelValues(m.labels.values(c)...).Observe(v)
+}
+
+// GinMetrics returns the middleware recording the custom metrics of conf for the requests of their routes,
+// every route when a metric lists none. Counters count the requests, gauges the requests in flight and
+// histograms observe the request latency in seconds.
+func GinMetrics(conf []config.CustomMetric) (gin.HandlerFunc, error) {
+	type recorder struct {
+		routes []string
+		before func(c *gin.Context)
+		after  func(c *gin.Context, elapsed time.Duration)
+	}
+	reg, err := Registerer()
+	if err != nil {
+		return nil, err
+	}
+	recorders := make([]recorder, 0, len(conf))
+	for _, m := range conf {
+		if m.Name == "" {
+			return nil, errs.New("custom metric name is empty").Wrap()
+		}
+		help := m.Help
+		if help == "" {
+			help = "Custom metric " + m.Name + "."
+		}
+		var (
+			collector prometheus.Collector
+			r         = recorder{routes: m.Routes}
+		)
+		switch m.Type {
+		case GinCounterType:
+			counter := newGinCounter(m.Name, help, m.Labels)
+			collector = counter.vec
+			r.after = func(c *gin.Context, _ time.Duration) { counter.Inc(c) }
+		case GinGaugeType:
+			// Code is not known while the request is in flight.
+			if slices.ContainsFunc(m.Labels, func(l config.MetricLabel) bool { return l.Name == CodeLabel && l.Header == "" && l.Query == "" }) {
+				return nil, errs.New("gauge custom metric cannot be labelled with code", "name", m.Name).Wrap()
+			}
+			gauge := newGinGauge(m.Name, help, m.Labels)
+			collector = gauge.vec
+			r.before = func(c *gin.Context) { gauge.Add(c, 1) }
+			r.after = func(c *gin.Context, _ time.Duration) { gauge.Add(c, -1) }
+		case GinHistogramType:
+			histogram := newGinHistogram(m.Name, help, m.Buckets, m.Labels)
+			collector = histogram.vec
+			r.after = func(c *gin.Context, elapsed time.Duration) { histogram.Observe(c, elapsed.Seconds()) }
+		default:
+			return nil, errs.New("unknown custom metric type", "name", m.Name, "type", m.Type).Wrap()
+		}
+		if err := reg.Register(collector); err != nil {
+			return nil, errs.WrapMsg(err, "register custom metric failed", "name", m.Name)
+		}
+		recorders = append(recorders, r)
+	}
+	return func(c *gin.Context) {
+		route := c.FullPath()
+		matched := make([]recorder, 0, len(recorders))
+		for _, r := range recorders {
+			if len(r.routes) == 0 || slices.Contains(r.routes, route) {
+				matched = append(matched, r)
+			}
+		}
+		for _, r := range matched {
+			if r.before != nil {
+				r.before(c)
+			}
+		}
+		start := time.Now()
+		c.Next()
+		elapsed := time.Since(start)
+		for _, r := range matched {
+			r.after(c, elapsed)
+		}
+	}, nil
+}
@@ -0,0 +1,82 @@
+// Copyright © 2024 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prommetrics
+
+import (
+	"context"
+	"net"
+	"strings"
+	"time"
+
+	gp "github.com/grpc-ecosystem/go-grpc-prometheus"
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+var grpcClientMetrics = newGrpcClientMetrics()
+
+// RPCClientDuration measures calls per downstream service and method, its buckets
+// include the latency objectives of the API to RPC hop.
+var RPCClientDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+	Name:    "rpc_client_request_duration_seconds",
+	Help:    "Latency of gRPC calls per downstream service, method and code.",
+	Buckets: []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.2, 0.3, 0.5, 1, 2, 5},
+}, []string{"downstream", "method", "code"})
+
+func newGrpcClientMetrics() *gp.ClientMetrics {
+	grpcMetrics := gp.NewClientMetrics()
+	grpcMetrics.EnableClientHandlingTimeHistogram()
+	Register(grpcMetrics)
+	return grpcMetrics
+}
+
+func init() {
+	Register(RPCClientDuration)
+}
+
+// GrpcClientDialOptions returns the dial options recording the client metrics of every call.
+func GrpcClientDialOptions() []grpc.DialOption {
+	return []grpc.DialOption{
+		grpc.WithChainUnaryInterceptor(grpcClientMetrics.UnaryClientInterceptor(), downstreamUnaryInterceptor),
+		grpc.WithChainStreamInterceptor(grpcClientMetrics.StreamClientInterceptor()),
+	}
+}
+
+func downstreamUnaryInterceptor(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	start := time.Now()
+	err := invoker(ctx, method, req, reply, cc, opts...)
+	RPCClientDuration.WithLabelValues(Downstream(cc.Target()), method, status.Code(err).String()).Observe(time.Since(start).Seconds())
+	return err
+}
+
+// Downstream returns the service name a connection was dialed for, from targets such as
+// "etcd:///openim/user", "passthrough:///user" or "dns:///user.default.svc.cluster.local:10110".
+func Downstream(target string) string {
+	if _, endpoint, ok := strings.Cut(target, ":///"); ok {
+		target = endpoint
+	}
+	if i := strings.LastIndex(target, "/"); i >= 0 {
+		target = target[i+1:]
+	}
+	if host, _, err := net.SplitHostPort(target); err == nil {
+		target = host
+	}
+	if net.ParseIP(target) != nil {
+		return target
+	}
+	name, _, _ := strings.Cut(target, ".")
+	return name
+}
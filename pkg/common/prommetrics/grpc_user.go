@@ -23,4 +23,12 @@ var (
 		Name: "user_register_total",
 		Help: "user register total",
 	})
+	UserRegisterFailedCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "user_register_failed_total",
+		Help: "user register failures by error code",
+	}, []string{"code"})
 )
+
+func init() {
+	Register(UserRegisterCounter, UserRegisterFailedCounter)
+}
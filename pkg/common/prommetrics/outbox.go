@@ -0,0 +1,38 @@
+// Copyright © 2024 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prommetrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	OutboxDeliveredCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "outbox_events_delivered_total",
+		Help: "Outbox events delivered per sink.",
+	}, []string{"sink"})
+	OutboxFailedCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "outbox_delivery_failed_total",
+		Help: "Failed deliveries of outbox events per sink, the events are retried.",
+	}, []string{"sink"})
+	OutboxDeadCounter = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "outbox_events_dead_total",
+		Help: "Outbox events given up after exhausting their attempts.",
+	})
+)
+
+func init() {
+	Register(OutboxDeliveredCounter, OutboxFailedCounter, OutboxDeadCounter)
+}
@@ -16,25 +16,18 @@ package prommetrics
 
 import (
 	gp "github.com/grpc-ecosystem/go-grpc-prometheus"
-	"github.com/openimsdk/openim-project-template/pkg/common/ginprometheus"
-	"github.com/prometheus/client_golang/prometheus"
-	"github.com/prometheus/client_golang/prometheus/collectors"
 )
 
-func NewGrpcPromObj(cusMetrics []prometheus.Collector) (*prometheus.Registry, *gp.ServerMetrics, error) {
-	reg := prometheus.NewRegistry()
+var grpcServerMetrics = newGrpcServerMetrics()
+
+func newGrpcServerMetrics() *gp.ServerMetrics {
 	grpcMetrics := gp.NewServerMetrics()
 	grpcMetrics.EnableHandlingTimeHistogram()
-	cusMetrics = append(cusMetrics, grpcMetrics, collectors.NewGoCollector())
-	reg.MustRegister(cusMetrics...)
-	return reg, grpcMetrics, nil
+	Register(grpcMetrics)
+	return grpcMetrics
 }
 
-func GetGinCusMetrics(name string) []*ginprometheus.Metric {
-	switch name {
-	case "Api":
-		return []*ginprometheus.Metric{ApiCustomCnt}
-	default:
-		return []*ginprometheus.Metric{ApiCustomCnt}
-	}
+// GrpcServerMetrics returns the gRPC server metrics of the process, shared by every server it runs.
+func GrpcServerMetrics() *gp.ServerMetrics {
+	return grpcServerMetrics
 }
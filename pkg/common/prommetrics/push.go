@@ -0,0 +1,55 @@
+// Copyright © 2024 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prommetrics
+
+import (
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/openimsdk/openim-project-template/pkg/common/config"
+	"github.com/openimsdk/openim-project-template/pkg/common/ginprometheus"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// NewPushGateway returns a pusher of the metrics of gatherer configured by conf for the instance index.
+func NewPushGateway(conf *config.PushGateway, gatherer prometheus.Gatherer, index int) *ginprometheus.PrometheusPushGateway {
+	return &ginprometheus.PrometheusPushGateway{
+		PushGatewayURL: conf.URL,
+		PushInterval:   PushInterval(conf),
+		Job:            conf.Job,
+		Grouping:       PushGrouping(conf, index),
+		Gatherer:       gatherer,
+	}
+}
+
+// PushInterval returns the configured push interval.
+func PushInterval(conf *config.PushGateway) time.Duration {
+	return time.Duration(conf.Interval) * time.Second
+}
+
+// PushGrouping returns the configured grouping labels. The instance label defaults to the hostname and
+// the instance index, the metrics already carry an index label which must not be part of the grouping.
+func PushGrouping(conf *config.PushGateway, index int) map[string]string {
+	grouping := make(map[string]string, len(conf.Grouping)+1)
+	for name, value := range conf.Grouping {
+		grouping[name] = value
+	}
+	if _, ok := grouping["instance"]; !ok {
+		hostname, _ := os.Hostname()
+		grouping["instance"] = hostname + "-" + strconv.Itoa(index)
+	}
+	return grouping
+}
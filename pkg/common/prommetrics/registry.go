@@ -0,0 +1,88 @@
+// Copyright © 2024 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prommetrics
+
+import (
+	"strconv"
+	"sync"
+
+	"github.com/openimsdk/openim-project-template/pkg/common/config"
+	"github.com/openimsdk/tools/errs"
+	"github.com/openimsdk/tools/system/program"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+)
+
+var (
+	registryLock sync.Mutex
+	declared     []prometheus.Collector
+	registry     *prometheus.Registry
+	registerer   prometheus.Registerer
+)
+
+// Register declares collectors exposed by the registry of the process. Packages call it from init
+// for the metrics they declare; collectors declared after Init are registered right away.
+func Register(cs ...prometheus.Collector) {
+	registryLock.Lock()
+	defer registryLock.Unlock()
+	declared = append(declared, cs...)
+	if registerer != nil {
+		registerer.MustRegister(cs...)
+	}
+}
+
+// Init creates the registry of the process with the Go and process collectors and every declared
+// collector. All metrics get the service, index and service_version constant labels. Only the first call
+// has an effect.
+func Init(service string, index int) error {
+	registryLock.Lock()
+	defer registryLock.Unlock()
+	if registry != nil {
+		return nil
+	}
+	reg := prometheus.NewRegistry()
+	wrapped := prometheus.WrapRegistererWith(prometheus.Labels{
+		"service":         service,
+		"index":           strconv.Itoa(index),
+		"service_version": config.Version,
+	}, reg)
+	cs := append([]prometheus.Collector{
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	}, declared...)
+	for _, c := range cs {
+		if err := wrapped.Register(c); err != nil {
+			return errs.WrapMsg(err, "register metrics failed")
+		}
+	}
+	registry, registerer = reg, wrapped
+	return nil
+}
+
+// Registry returns the registry of the process, initialized for the process name when Init was not called.
+func Registry() (*prometheus.Registry, error) {
+	if err := Init(program.GetProcessName(), 0); err != nil {
+		return nil, err
+	}
+	return registry, nil
+}
+
+// Registerer returns the registerer adding the constant labels to the registry of the process.
+func Registerer() (prometheus.Registerer, error) {
+	if _, err := Registry(); err != nil {
+		return nil, err
+	}
+	return registerer, nil
+}
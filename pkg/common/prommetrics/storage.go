@@ -0,0 +1,77 @@
+// Copyright © 2024 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prommetrics
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Results of a cache lookup, used as the result label of CacheRequestCounter.
+const (
+	CacheHit   = "hit"
+	CacheMiss  = "miss"
+	CacheError = "error"
+)
+
+var (
+	MongoOperationDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "mongo_operation_duration_seconds",
+		Help:    "Latency of mongo operations per collection and operation.",
+		Buckets: []float64{0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5},
+	}, []string{"collection", "operation"})
+	MongoOperationErrorCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "mongo_operation_errors_total",
+		Help: "Failed mongo operations per collection and operation.",
+	}, []string{"collection", "operation"})
+
+	CacheRequestCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "redis_cache_requests_total",
+		Help: "Cache lookups per cache and result (hit, miss or error).",
+	}, []string{"cache", "result"})
+	CacheRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "redis_cache_request_duration_seconds",
+		Help:    "Latency of cache lookups per cache, including the database load on a miss.",
+		Buckets: []float64{0.0005, 0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1},
+	}, []string{"cache"})
+	CacheDeleteFailedCounter = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "redis_cache_delete_failed_total",
+		Help: "Cache keys that could not be tagged as deleted.",
+	})
+
+	AuditDroppedCounter = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "audit_log_dropped_total",
+		Help: "Audit logs that could not be queued or written to the database.",
+	})
+)
+
+func init() {
+	Register(MongoOperationDuration, MongoOperationErrorCounter, CacheRequestCounter, CacheRequestDuration, CacheDeleteFailedCounter, AuditDroppedCounter)
+}
+
+// ObserveMongoOperation records the latency of an operation on collection and counts it as failed when failed is true.
+func ObserveMongoOperation(collection, operation string, duration time.Duration, failed bool) {
+	MongoOperationDuration.WithLabelValues(collection, operation).Observe(duration.Seconds())
+	if failed {
+		MongoOperationErrorCounter.WithLabelValues(collection, operation).Inc()
+	}
+}
+
+// ObserveCacheRequest records the result and latency of a lookup in cache.
+func ObserveCacheRequest(cache, result string, duration time.Duration) {
+	CacheRequestCounter.WithLabelValues(cache, result).Inc()
+	CacheRequestDuration.WithLabelValues(cache).Observe(duration.Seconds())
+}
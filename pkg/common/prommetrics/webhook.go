@@ -0,0 +1,43 @@
+// Copyright © 2024 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prommetrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Results of a webhook call, used as the result label of WebhookRequestCounter.
+const (
+	WebhookSuccess  = "success"
+	WebhookFailed   = "failed"
+	WebhookRejected = "rejected"
+	WebhookDropped  = "dropped"
+)
+
+var (
+	WebhookRequestCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "webhook_requests_total",
+		Help: "Webhook calls per command and result (success, failed, rejected or dropped).",
+	}, []string{"command", "result"})
+	WebhookRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "webhook_request_duration_seconds",
+		Help:    "Latency of webhook calls per command.",
+		Buckets: []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10},
+	}, []string{"command"})
+)
+
+func init() {
+	Register(WebhookRequestCounter, WebhookRequestDuration)
+}
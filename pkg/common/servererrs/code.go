@@ -41,6 +41,7 @@ const (
 	DatabaseError = 90002 // Database error (redis/mysql, etc.)
 	NetworkError  = 90004 // Network error
 	DataError     = 90007 // Data error
+	CallbackError = 80000 // Rejected by a webhook
 
 	// General error codes.
 	ServerInternalError = 500  // Server internal error
@@ -14,14 +14,49 @@
 
 package servererrs
 
-import "github.com/openimsdk/tools/errs"
+import (
+	"sort"
+
+	"github.com/openimsdk/tools/errs"
+)
 
 var (
 	ErrDatabase = errs.NewCodeError(DatabaseError, "DatabaseError")
 	ErrNetwork  = errs.NewCodeError(NetworkError, "NetworkError")
+	ErrCallback = errs.NewCodeError(CallbackError, "CallbackError")
 
 	ErrInternalServer    = errs.NewCodeError(ServerInternalError, "ServerInternalError")
 	ErrArgs              = errs.NewCodeError(ArgsError, "ArgsError")
 	ErrUserIDNotFound    = errs.NewCodeError(UserIDNotFoundError, "UserIDNotFoundError")
 	ErrRegisteredAlready = errs.NewCodeError(RegisteredAlreadyError, "RegisteredAlreadyError")
 )
+
+// predefined are the errors FromCode returns by code.
+var predefined = map[int]errs.CodeError{}
+
+func init() {
+	for _, err := range []errs.CodeError{
+		ErrDatabase, ErrNetwork, ErrCallback, ErrInternalServer, ErrArgs, ErrUserIDNotFound, ErrRegisteredAlready,
+		errs.ErrNoPermission, errs.ErrDuplicateKey, errs.ErrRecordNotFound, errs.ErrTokenExpired,
+	} {
+		predefined[err.Code()] = err
+	}
+}
+
+// Predefined returns the errors with a predefined code, ordered by code.
+func Predefined() []errs.CodeError {
+	codes := make([]errs.CodeError, 0, len(predefined))
+	for _, err := range predefined {
+		codes = append(codes, err)
+	}
+	sort.Slice(codes, func(i, j int) bool { return codes[i].Code() < codes[j].Code() })
+	return codes
+}
+
+// FromCode returns the error of an errCode received from the API, the predefined one when the code has one.
+func FromCode(code int, msg string, detail string) errs.CodeError {
+	if err, ok := predefined[code]; ok {
+		return err.WithDetail(detail)
+	}
+	return errs.NewCodeError(code, msg).WithDetail(detail)
+}
@@ -0,0 +1,187 @@
+// Copyright © 2024 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package startrpc
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/openimsdk/tools/log"
+	"github.com/openimsdk/tools/mw"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	"google.golang.org/grpc/health/grpc_health_v1"
+)
+
+const (
+	healthCheckInterval = 5 * time.Second
+	healthCheckTimeout  = 3 * time.Second
+)
+
+// HealthCheck returns an error when a dependency of the service is unreachable.
+type HealthCheck func(ctx context.Context) error
+
+type healthCtxKey struct{}
+
+// RegisterHealthCheck adds a dependency check to the health service of the server being started.
+// ctx must be the context passed to Service.Start. While any check fails, every service
+// of the server reports NOT_SERVING.
+func RegisterHealthCheck(ctx context.Context, name string, check HealthCheck) {
+	if h, ok := ctx.Value(healthCtxKey{}).(*healthService); ok {
+		h.add(name, check)
+	}
+}
+
+// healthService maintains the grpc_health_v1 status of every service registered on a server.
+type healthService struct {
+	server   *health.Server
+	lock     sync.Mutex
+	checks   map[string]HealthCheck
+	services []string
+	serving  bool
+	cancel   context.CancelFunc
+}
+
+func newHealthService() *healthService {
+	return &healthService{
+		server: health.NewServer(),
+		checks: make(map[string]HealthCheck),
+	}
+}
+
+func (h *healthService) withContext(ctx context.Context) context.Context {
+	return context.WithValue(ctx, healthCtxKey{}, h)
+}
+
+func (h *healthService) add(name string, check HealthCheck) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	h.checks[name] = check
+}
+
+// register installs the health service on srv and starts checking dependencies periodically.
+func (h *healthService) register(ctx context.Context, srv *grpc.Server) {
+	grpc_health_v1.RegisterHealthServer(srv, h.server)
+	h.services = []string{""}
+	for name := range srv.GetServiceInfo() {
+		if name != grpc_health_v1.Health_ServiceDesc.ServiceName {
+			h.services = append(h.services, name)
+		}
+	}
+	ctx, h.cancel = context.WithCancel(ctx)
+	h.check(ctx)
+	go func() {
+		ticker := time.NewTicker(healthCheckInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				h.check(ctx)
+			}
+		}
+	}()
+}
+
+func (h *healthService) check(ctx context.Context) {
+	h.lock.Lock()
+	checks := make(map[string]HealthCheck, len(h.checks))
+	for name, check := range h.checks {
+		checks[name] = check
+	}
+	h.lock.Unlock()
+
+	serving := true
+	for name, check := range checks {
+		checkCtx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+		err := check(checkCtx)
+		cancel()
+		if err != nil {
+			serving = false
+			log.ZWarn(ctx, "health check failed", err, "dependency", name)
+		}
+	}
+	h.setServing(ctx, serving)
+}
+
+func (h *healthService) setServing(ctx context.Context, serving bool) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	status := grpc_health_v1.HealthCheckResponse_NOT_SERVING
+	if serving {
+		status = grpc_health_v1.HealthCheckResponse_SERVING
+	}
+	if serving != h.serving {
+		log.ZInfo(ctx, "health status changed", "status", status.String(), "services", h.services)
+	}
+	h.serving = serving
+	for _, service := range h.services {
+		h.server.SetServingStatus(service, status)
+	}
+}
+
+// shutdown reports NOT_SERVING for every service and ignores later checks.
+func (h *healthService) shutdown() {
+	if h.cancel != nil {
+		h.cancel()
+	}
+	h.server.Shutdown()
+}
+
+// grpcServerOptions install the mw server interceptor for every method except the health service,
+// which is called by load balancers and probes that carry no operationID.
+func grpcServerOptions() []grpc.ServerOption {
+	return []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+			if isHealthMethod(info.FullMethod) {
+				return handler(ctx, req)
+			}
+			return mw.RpcServerInterceptor(ctx, req, info, handler)
+		}),
+		grpc.ChainStreamInterceptor(func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+			if isHealthMethod(info.FullMethod) {
+				return handler(srv, ss)
+			}
+			return streamServerInterceptor(srv, ss, info, handler)
+		}),
+	}
+}
+
+func isHealthMethod(fullMethod string) bool {
+	return strings.HasPrefix(fullMethod, "/"+grpc_health_v1.Health_ServiceDesc.ServiceName+"/")
+}
+
+// streamServerInterceptor runs a stream handler as the handler of mw.RpcServerInterceptor, which only
+// intercepts unary calls, so that streams get the context and the error codes of the other calls.
+func streamServerInterceptor(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	unary := &grpc.UnaryServerInfo{Server: srv, FullMethod: info.FullMethod}
+	_, err := mw.RpcServerInterceptor(ss.Context(), nil, unary, func(ctx context.Context, _ any) (any, error) {
+		return nil, handler(srv, &serverStream{ServerStream: ss, ctx: ctx})
+	})
+	return err
+}
+
+// serverStream replaces the context of a stream.
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
@@ -0,0 +1,197 @@
+// Copyright © 2024 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package startrpc
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/openimsdk/openim-project-template/pkg/common/config"
+	"github.com/openimsdk/tools/errs"
+	"github.com/openimsdk/tools/log"
+	"github.com/openimsdk/tools/system/program"
+)
+
+const (
+	defaultShutdownTimeout = 15 * time.Second
+)
+
+// Phase orders the hooks run when a process stops.
+type Phase int
+
+const (
+	// PhaseUnhealthy reports the instance as not serving so that probes and load balancers stop picking it.
+	PhaseUnhealthy Phase = iota
+	// PhaseDeregister removes the instance from service discovery.
+	// The propagation delay is waited after this phase so that clients and load balancers drop the instance.
+	PhaseDeregister
+	// PhaseDrain stops accepting requests and waits for in-flight ones.
+	PhaseDrain
+	// PhaseFlush pushes buffered metrics, traces and audit entries. The logs are synced after its hooks.
+	PhaseFlush
+	// PhaseClose releases storage clients and other dependencies, in reverse registration order.
+	PhaseClose
+
+	phaseCount
+)
+
+var phaseNames = [phaseCount]string{"unhealthy", "deregister", "drain", "flush", "close"}
+
+func (p Phase) String() string {
+	if p < 0 || p >= phaseCount {
+		return "unknown"
+	}
+	return phaseNames[p]
+}
+
+// StopHook is run when the process stops. ctx expires when the shutdown timeout is reached.
+type StopHook func(ctx context.Context) error
+
+type namedHook struct {
+	name string
+	fn   StopHook
+}
+
+// Lifecycle runs the shutdown of a service in a fixed order of phases.
+type Lifecycle struct {
+	lock             sync.Mutex
+	hooks            [phaseCount][]namedHook
+	propagationDelay time.Duration
+	timeout          time.Duration
+	once             sync.Once
+	stopErr          error
+	// running is set once Run is reached, the startup having succeeded.
+	running bool
+}
+
+type lifecycleCtxKey struct{}
+
+// NewLifecycle creates a Lifecycle from the shutdown section of share.yml.
+func NewLifecycle(conf *config.Shutdown) *Lifecycle {
+	timeout := time.Duration(conf.Timeout) * time.Second
+	if timeout <= 0 {
+		timeout = defaultShutdownTimeout
+	}
+	return &Lifecycle{
+		propagationDelay: time.Duration(conf.PropagationDelay) * time.Second,
+		timeout:          timeout,
+	}
+}
+
+// RegisterStopHook adds a hook to the Lifecycle of the service being started.
+// ctx must be the context passed to Service.Start.
+func RegisterStopHook(ctx context.Context, phase Phase, name string, fn StopHook) {
+	if l, ok := ctx.Value(lifecycleCtxKey{}).(*Lifecycle); ok {
+		l.OnStop(phase, name, fn)
+	}
+}
+
+// WithContext returns a context through which RegisterStopHook reaches l.
+func (l *Lifecycle) WithContext(ctx context.Context) context.Context {
+	return context.WithValue(ctx, lifecycleCtxKey{}, l)
+}
+
+// OnStop adds a hook run in the given phase.
+func (l *Lifecycle) OnStop(phase Phase, name string, fn StopHook) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.hooks[phase] = append(l.hooks[phase], namedHook{name: name, fn: fn})
+}
+
+// Run blocks until SIGTERM or SIGINT is received or errCh yields a serving error, then stops the service.
+func (l *Lifecycle) Run(ctx context.Context, errCh <-chan error) error {
+	l.lock.Lock()
+	l.running = true
+	l.lock.Unlock()
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, syscall.SIGTERM, syscall.SIGINT)
+	defer signal.Stop(sigs)
+	select {
+	case sig := <-sigs:
+		program.SIGTERMExit()
+		log.ZInfo(ctx, "received signal, shutting down", "signal", sig.String())
+		return l.Stop(ctx)
+	case err := <-errCh:
+		if stopErr := l.Stop(ctx); stopErr != nil {
+			log.ZWarn(ctx, "shutdown after serving error failed", stopErr)
+		}
+		return err
+	}
+}
+
+// Stop runs the hooks phase by phase. A failing hook is logged and does not prevent the following ones.
+// Only the first call has an effect, the result is returned to every caller.
+func (l *Lifecycle) Stop(ctx context.Context) error {
+	l.once.Do(func() {
+		l.stopErr = l.stop(context.WithoutCancel(ctx))
+	})
+	return l.stopErr
+}
+
+func (l *Lifecycle) stop(ctx context.Context) error {
+	l.lock.Lock()
+	hooks := l.hooks
+	// The instance was announced when it was registered in discovery or started serving, the
+	// propagation delay is not waited when the startup failed before.
+	announced := l.running || len(hooks[PhaseDeregister]) > 0
+	l.lock.Unlock()
+	hooks[PhaseFlush] = append(append([]namedHook(nil), hooks[PhaseFlush]...), namedHook{name: "log", fn: syncLog})
+
+	ctx, cancel := context.WithTimeout(ctx, l.propagationDelay+l.timeout)
+	defer cancel()
+	var firstErr error
+	for phase := Phase(0); phase < phaseCount; phase++ {
+		phaseHooks := hooks[phase]
+		if phase == PhaseClose {
+			phaseHooks = reversed(phaseHooks)
+		}
+		for _, hook := range phaseHooks {
+			if err := hook.fn(ctx); err != nil {
+				log.ZWarn(ctx, "stop hook failed", err, "phase", phase.String(), "hook", hook.name)
+				if firstErr == nil {
+					firstErr = errs.WrapMsg(err, "stop hook failed", "phase", phase.String(), "hook", hook.name)
+				}
+				continue
+			}
+			log.ZDebug(ctx, "stop hook done", "phase", phase.String(), "hook", hook.name)
+		}
+		if phase == PhaseDeregister && l.propagationDelay > 0 && announced {
+			log.ZInfo(ctx, "waiting for deregistration to propagate", "delay", l.propagationDelay)
+			select {
+			case <-time.After(l.propagationDelay):
+			case <-ctx.Done():
+			}
+		}
+	}
+	return firstErr
+}
+
+// syncLog writes the buffered log entries, it runs last in PhaseFlush so that the entries of the other hooks are kept.
+func syncLog(ctx context.Context) error {
+	log.Flush()
+	return nil
+}
+
+func reversed(hooks []namedHook) []namedHook {
+	res := make([]namedHook, len(hooks))
+	for i, hook := range hooks {
+		res[len(hooks)-1-i] = hook
+	}
+	return res
+}
@@ -0,0 +1,79 @@
+// Copyright © 2024 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package startrpc
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/openimsdk/openim-project-template/pkg/common/config"
+)
+
+func TestLifecycleOrder(t *testing.T) {
+	l := NewLifecycle(&config.Shutdown{})
+	var order []string
+	hook := func(name string) StopHook {
+		return func(ctx context.Context) error {
+			order = append(order, name)
+			return nil
+		}
+	}
+	l.OnStop(PhaseClose, "close 1", hook("close 1"))
+	l.OnStop(PhaseClose, "close 2", hook("close 2"))
+	l.OnStop(PhaseFlush, "flush", hook("flush"))
+	l.OnStop(PhaseDrain, "drain", hook("drain"))
+	l.OnStop(PhaseDeregister, "deregister", hook("deregister"))
+	l.OnStop(PhaseUnhealthy, "unhealthy", hook("unhealthy"))
+	if err := l.Stop(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"unhealthy", "deregister", "drain", "flush", "close 2", "close 1"}
+	if len(order) != len(want) {
+		t.Fatalf("order %v, want %v", order, want)
+	}
+	for i := range want {
+		if order[i] != want[i] {
+			t.Fatalf("order %v, want %v", order, want)
+		}
+	}
+}
+
+func TestLifecyclePropagationDelay(t *testing.T) {
+	const delay = 200 * time.Millisecond
+	noop := func(ctx context.Context) error { return nil }
+
+	// A startup failing before the registration stops at once.
+	l := NewLifecycle(&config.Shutdown{PropagationDelay: 3600})
+	l.OnStop(PhaseUnhealthy, "health", noop)
+	start := time.Now()
+	if err := l.Stop(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("unregistered instance waited %s", elapsed)
+	}
+
+	l = NewLifecycle(&config.Shutdown{})
+	l.propagationDelay = delay
+	l.OnStop(PhaseDeregister, "discovery", noop)
+	start = time.Now()
+	if err := l.Stop(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed < delay {
+		t.Fatalf("registered instance waited %s, want %s", elapsed, delay)
+	}
+}
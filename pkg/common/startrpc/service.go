@@ -0,0 +1,231 @@
+// Copyright © 2024 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package startrpc
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/openimsdk/openim-project-template/pkg/common/audit"
+	"github.com/openimsdk/openim-project-template/pkg/common/config"
+	kdisc "github.com/openimsdk/openim-project-template/pkg/common/discoveryregister"
+	"github.com/openimsdk/openim-project-template/pkg/common/prommetrics"
+	"github.com/openimsdk/openim-project-template/pkg/common/storage/database/mgo"
+	"github.com/openimsdk/openim-project-template/pkg/common/tracing"
+	"github.com/openimsdk/openim-project-template/pkg/rpcclient"
+	"github.com/openimsdk/tools/db/redisutil"
+	"github.com/openimsdk/tools/discovery"
+	"github.com/openimsdk/tools/errs"
+	"github.com/openimsdk/tools/log"
+	"github.com/openimsdk/tools/mw"
+	"github.com/redis/go-redis/extra/redisotel/v9"
+	"github.com/redis/go-redis/v9"
+	"go.mongodb.org/mongo-driver/mongo"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// Modes of the schema migration at startup.
+const (
+	MigrationAuto   = "auto"
+	MigrationManual = "manual"
+)
+
+// Modes of the storage of mongodb.yml.
+const (
+	MongoModeMongo = "mongo"
+	// MongoModeMemory keeps the data in process memory instead of connecting to Mongo, it is lost on exit.
+	MongoModeMemory = "memory"
+)
+
+const defaultMigrationTimeout = 5 * time.Minute
+
+// Needs selects the shared clients constructed before a service starts.
+type Needs struct {
+	Mongo bool
+	Redis bool
+	// RedisCache is Redis used only as the cache of Mongo, it is not constructed in the memory mode of mongodb.yml.
+	RedisCache bool
+	// Audit records the calls of Service.Audit when the audit log is enabled, it requires Mongo.
+	Audit bool
+}
+
+// Service describes an RPC service so that it can be started by Run.
+type Service struct {
+	// Name identifies the service in logs.
+	Name string
+	// ConfigFiles maps the service specific config files to the fields they are loaded into.
+	ConfigFiles map[string]any
+	// RPC and Prometheus point to the listener and metrics sections of the loaded service config.
+	RPC        *config.RPC
+	Prometheus *config.Prometheus
+	// RegisterName returns the name the service registers in discovery with.
+	RegisterName func(share *config.Share) string
+	// Needs lists the shared clients the service uses.
+	Needs Needs
+	// MongoOnly marks a service without an in-memory storage, it is not available in the memory mode of mongodb.yml.
+	MongoOnly bool
+	// Audit lists the methods recorded in the audit log, keyed by full method name.
+	Audit map[string]audit.Method
+	// GrpcOptions are appended to the options of the gRPC server.
+	GrpcOptions []grpc.ServerOption
+	// Start registers the service implementation on server.
+	Start func(ctx context.Context, deps *Dependencies, server *grpc.Server) error
+}
+
+// Available reports whether the service can run with the storage of conf.
+func (s *Service) Available(conf *SharedConfig) bool {
+	return !s.MongoOnly || conf.Mongo.Mode != MongoModeMemory
+}
+
+// SharedConfig is the configuration common to every service, loaded by the bootstrap.
+type SharedConfig struct {
+	Discovery config.Discovery
+	Share     config.Share
+	Mongo     config.Mongo
+	Redis     config.Redis
+}
+
+// Dependencies are the clients shared by the services of a process. Clients that
+// no started service needs are nil.
+type Dependencies struct {
+	Discovery discovery.SvcDiscoveryRegistry
+	// Mongo is nil in the memory mode of mongodb.yml, the services then use the in-memory storage.
+	Mongo *mgo.Client
+	Redis redis.UniversalClient
+	// Audit is nil when the audit log is disabled, recording to it is then a no-op.
+	Audit *audit.Recorder
+	Share *config.Share
+
+	healthChecks map[string]HealthCheck
+}
+
+// NewDependencies constructs the discovery client from the config and the clients selected by needs.
+// Their health checks are installed on every server started with them, and they are closed by lifecycle.
+func NewDependencies(ctx context.Context, lifecycle *Lifecycle, needs Needs, conf *SharedConfig) (*Dependencies, error) {
+	client, err := kdisc.NewDiscoveryRegister(&conf.Discovery)
+	if err != nil {
+		return nil, err
+	}
+	return NewDependenciesWithDiscovery(ctx, lifecycle, client, needs, conf)
+}
+
+// NewDependenciesWithDiscovery is NewDependencies with an already constructed discovery client.
+func NewDependenciesWithDiscovery(ctx context.Context, lifecycle *Lifecycle, client discovery.SvcDiscoveryRegistry, needs Needs, conf *SharedConfig) (*Dependencies, error) {
+	deps := &Dependencies{
+		Share:        &conf.Share,
+		healthChecks: make(map[string]HealthCheck),
+	}
+	lifecycle.OnStop(PhaseClose, "discovery", func(ctx context.Context) error {
+		client.Close()
+		return nil
+	})
+	client.AddOption(mw.GrpcClient(), rpcclient.GrpcStreamClient(), tracing.DialOption(), grpc.WithTransportCredentials(insecure.NewCredentials()), grpc.WithDefaultServiceConfig(fmt.Sprintf(`{"LoadBalancingPolicy": "%s"}`, "round_robin")))
+	client.AddOption(prommetrics.GrpcClientDialOptions()...)
+	client.AddOption(audit.DialOption())
+	deps.Discovery = client
+
+	switch conf.Mongo.Mode {
+	case "", MongoModeMongo:
+	case MongoModeMemory:
+		if needs.Mongo {
+			log.ZWarn(ctx, "mongo is in memory mode, the data is lost on exit", nil)
+		}
+	default:
+		return nil, errs.New("unknown mongo mode", "mode", conf.Mongo.Mode).Wrap()
+	}
+
+	if needs.Mongo && conf.Mongo.Mode != MongoModeMemory {
+		mgoCli, err := mgo.NewClient(ctx, conf.Mongo.Build())
+		if err != nil {
+			return nil, err
+		}
+		lifecycle.OnStop(PhaseClose, "mongo", func(ctx context.Context) error {
+			return mgoCli.GetDB().Client().Disconnect(ctx)
+		})
+		deps.healthChecks["mongo"] = func(ctx context.Context) error {
+			return mgoCli.GetDB().Client().Ping(ctx, nil)
+		}
+		deps.Mongo = mgoCli
+		if conf.Share.Audit.Enable {
+			// Created before the migrations, which would create it with the default cap.
+			if err := mgo.CreateAuditCollection(ctx, mgoCli.GetDB(), conf.Share.Audit.CapSize); err != nil {
+				return nil, err
+			}
+		}
+		if err := migrateMongo(ctx, mgoCli.GetDB(), &conf.Mongo.Migration); err != nil {
+			return nil, err
+		}
+	}
+
+	if needs.Redis || (needs.RedisCache && conf.Mongo.Mode != MongoModeMemory) {
+		rdb, err := redisutil.NewRedisClient(ctx, conf.Redis.Build())
+		if err != nil {
+			return nil, err
+		}
+		if err := redisotel.InstrumentTracing(rdb); err != nil {
+			return nil, errs.WrapMsg(err, "redis tracing failed")
+		}
+		lifecycle.OnStop(PhaseClose, "redis", func(ctx context.Context) error {
+			return rdb.Close()
+		})
+		deps.healthChecks["redis"] = func(ctx context.Context) error {
+			return rdb.Ping(ctx).Err()
+		}
+		deps.Redis = rdb
+	}
+
+	if needs.Audit && conf.Share.Audit.Enable && conf.Mongo.Mode == MongoModeMemory {
+		log.ZWarn(ctx, "audit log disabled, it needs mongo", nil)
+	} else if needs.Audit && conf.Share.Audit.Enable {
+		if deps.Mongo == nil {
+			return nil, errs.New("audit log needs mongo").Wrap()
+		}
+		auditDB, err := mgo.NewAuditMongo(deps.Mongo.GetDB())
+		if err != nil {
+			return nil, err
+		}
+		recorder, err := audit.NewRecorder(&conf.Share.Audit, auditDB)
+		if err != nil {
+			return nil, err
+		}
+		// Flushed before mongo is closed.
+		lifecycle.OnStop(PhaseFlush, "audit", recorder.Close)
+		deps.Audit = recorder
+	}
+	return deps, nil
+}
+
+// migrateMongo applies the pending migrations in auto mode, then refuses a schema other than the one the binary supports.
+func migrateMongo(ctx context.Context, db *mongo.Database, conf *config.MongoMigration) error {
+	timeout := defaultMigrationTimeout
+	if conf.Timeout > 0 {
+		timeout = time.Duration(conf.Timeout) * time.Second
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	migrator := mgo.NewMigrator(db)
+	switch conf.Mode {
+	case "", MigrationAuto:
+		if _, err := migrator.Up(ctx, 0); err != nil {
+			return err
+		}
+	case MigrationManual:
+	default:
+		return errs.New("unknown migration mode", "mode", conf.Mode).Wrap()
+	}
+	return migrator.Check(ctx)
+}
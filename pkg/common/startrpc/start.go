@@ -16,148 +16,181 @@ package startrpc
 
 import (
 	"context"
-	"fmt"
-	config2 "github.com/openimsdk/openim-project-template/pkg/common/config"
 	"github.com/openimsdk/tools/utils/datautil"
-	"github.com/prometheus/client_golang/prometheus"
 	"net"
 	"net/http"
-	"os"
-	"os/signal"
 	"strconv"
-	"sync"
-	"syscall"
-	"time"
 
 	grpcprometheus "github.com/grpc-ecosystem/go-grpc-prometheus"
-	kdisc "github.com/openimsdk/openim-project-template/pkg/common/discoveryregister"
 	"github.com/openimsdk/openim-project-template/pkg/common/prommetrics"
-	"github.com/openimsdk/tools/discovery"
+	"github.com/openimsdk/openim-project-template/pkg/common/tracing"
 	"github.com/openimsdk/tools/errs"
 	"github.com/openimsdk/tools/log"
-	"github.com/openimsdk/tools/mw"
 	"github.com/openimsdk/tools/system/program"
 	"github.com/openimsdk/tools/utils/network"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/reflection"
 )
 
-// Start rpc server.
-func Start[T any](ctx context.Context, discovery *config2.Discovery, prometheusConfig *config2.Prometheus, listenIP,
-	registerIP string, rpcPorts []int, index int, rpcRegisterName string, config T, rpcFn func(ctx context.Context,
-	config T, client discovery.SvcDiscoveryRegistry, server *grpc.Server) error, cusMetrics []prometheus.Collector, options ...grpc.ServerOption) error {
+// Run constructs the dependencies of svc, serves it and blocks until the process is stopped.
+func Run(ctx context.Context, index int, svc *Service, conf *SharedConfig) error {
+	lifecycle := NewLifecycle(&conf.Share.Shutdown)
+	// Stops everything registered so far when starting fails, a no-op after a regular shutdown.
+	defer lifecycle.Stop(ctx)
 
-	rpcPort, err := datautil.GetElemByIndex(rpcPorts, index)
-	if err != nil {
+	if err := prommetrics.Init(program.GetProcessName(), index); err != nil {
 		return err
 	}
-	prometheusPort, err := datautil.GetElemByIndex(prometheusConfig.Ports, index)
+	shutdownTracing, err := tracing.Init(ctx, &conf.Share.Tracing, program.GetProcessName())
 	if err != nil {
 		return err
 	}
-	log.CInfo(ctx, "RPC server is initializing", "rpcRegisterName", rpcRegisterName, "rpcPort", rpcPort,
-		"prometheusPort", prometheusPort)
-	rpcTcpAddr := net.JoinHostPort(network.GetListenIP(listenIP), strconv.Itoa(rpcPort))
-	listener, err := net.Listen(
-		"tcp",
-		rpcTcpAddr,
-	)
+	lifecycle.OnStop(PhaseFlush, "tracing", shutdownTracing)
+
+	deps, err := NewDependencies(ctx, lifecycle, svc.Needs, conf)
 	if err != nil {
-		return errs.WrapMsg(err, "listen err", "rpcTcpAddr", rpcTcpAddr)
+		return err
+	}
+	netErr := make(chan error, 2)
+	if err := Serve(ctx, lifecycle, index, svc, deps, netErr); err != nil {
+		return err
 	}
+	return lifecycle.Run(ctx, netErr)
+}
 
-	defer listener.Close()
-	client, err := kdisc.NewDiscoveryRegister(discovery)
+// InProcessListener is implemented by discovery clients that connect services of the same process
+// in memory. Services are then served on the listener it returns instead of their TCP port.
+type InProcessListener interface {
+	Listen(serviceName string) net.Listener
+}
+
+// Serve starts svc on the instance selected by index and registers it in discovery.
+// It returns once the server is listening, serving errors are sent to errCh and
+// the server is stopped by lifecycle.
+func Serve(ctx context.Context, lifecycle *Lifecycle, index int, svc *Service, deps *Dependencies, errCh chan<- error) error {
+	rpcRegisterName := svc.RegisterName(deps.Share)
+	rpcPort, err := datautil.GetElemByIndex(svc.RPC.Ports, index)
 	if err != nil {
 		return err
 	}
-
-	defer client.Close()
-	client.AddOption(mw.GrpcClient(), grpc.WithTransportCredentials(insecure.NewCredentials()), grpc.WithDefaultServiceConfig(fmt.Sprintf(`{"LoadBalancingPolicy": "%s"}`, "round_robin")))
-	registerIP, err = network.GetRpcRegisterIP(registerIP)
+	prometheusPort, err := datautil.GetElemByIndex(svc.Prometheus.Ports, index)
 	if err != nil {
 		return err
 	}
+	log.CInfo(ctx, "RPC server is initializing", "rpcRegisterName", rpcRegisterName, "rpcPort", rpcPort,
+		"prometheusPort", prometheusPort)
+	rpcTcpAddr := net.JoinHostPort(network.GetListenIP(svc.RPC.ListenIP), strconv.Itoa(rpcPort))
+	var listener net.Listener
+	if inProcess, ok := deps.Discovery.(InProcessListener); ok {
+		listener = inProcess.Listen(rpcRegisterName)
+	} else {
+		listener, err = net.Listen(
+			"tcp",
+			rpcTcpAddr,
+		)
+		if err != nil {
+			return errs.WrapMsg(err, "listen err", "rpcTcpAddr", rpcTcpAddr)
+		}
+	}
+	registerIP, err := network.GetRpcRegisterIP(svc.RPC.RegisterIP)
+	if err != nil {
+		listener.Close()
+		return err
+	}
 
-	var reg *prometheus.Registry
-	var metric *grpcprometheus.ServerMetrics
-	if prometheusConfig.Enable {
-		reg, metric, _ = prommetrics.NewGrpcPromObj(cusMetrics)
-		options = append(options, mw.GrpcServer(), grpc.StreamInterceptor(metric.StreamServerInterceptor()),
+	options := append([]grpc.ServerOption{}, svc.GrpcOptions...)
+	var (
+		metric   *grpcprometheus.ServerMetrics
+		registry *prometheus.Registry
+	)
+	if svc.Prometheus.Enable {
+		if registry, err = prommetrics.Registry(); err != nil {
+			listener.Close()
+			return err
+		}
+		metric = prommetrics.GrpcServerMetrics()
+		options = append(options, grpc.StreamInterceptor(metric.StreamServerInterceptor()),
 			grpc.UnaryInterceptor(metric.UnaryServerInterceptor()))
-	} else {
-		options = append(options, mw.GrpcServer())
+	}
+	options = append(options, grpcServerOptions()...)
+	// After grpcServerOptions, the operationID of the span is read from the context mw fills.
+	options = append(options, tracing.ServerOptions()...)
+	if deps.Audit != nil && len(svc.Audit) > 0 {
+		options = append(options, grpc.ChainUnaryInterceptor(deps.Audit.UnaryServerInterceptor(svc.Audit)))
 	}
 
 	srv := grpc.NewServer(options...)
-	once := sync.Once{}
-	defer func() {
-		once.Do(srv.GracefulStop)
-	}()
+	// Stopping the server closes the listener.
+	lifecycle.OnStop(PhaseDrain, svc.Name, func(ctx context.Context) error {
+		if err := gracefulStopWithCtx(ctx, srv.GracefulStop); err != nil {
+			srv.Stop()
+			return err
+		}
+		return nil
+	})
 
-	err = rpcFn(ctx, config, client, srv)
-	if err != nil {
+	healthSrv := newHealthService()
+	for name, check := range deps.healthChecks {
+		healthSrv.add(name, check)
+	}
+	lifecycle.OnStop(PhaseUnhealthy, svc.Name, func(ctx context.Context) error {
+		healthSrv.shutdown()
+		return nil
+	})
+	if err := svc.Start(lifecycle.WithContext(healthSrv.withContext(ctx)), deps, srv); err != nil {
+		listener.Close()
 		return err
 	}
+	healthSrv.register(ctx, srv)
+	if svc.RPC.Reflection {
+		reflection.Register(srv)
+	}
 
-	err = client.Register(
+	err = deps.Discovery.Register(
 		rpcRegisterName,
 		registerIP,
 		rpcPort,
 		grpc.WithTransportCredentials(insecure.NewCredentials()),
 	)
 	if err != nil {
+		listener.Close()
 		return err
 	}
-
-	var (
-		netDone    = make(chan struct{}, 2)
-		netErr     error
-		httpServer *http.Server
-	)
-
-	go func() {
-		if prometheusConfig.Enable && prometheusPort != 0 {
-			metric.InitializeMetrics(srv)
-			// Create a HTTP server for prometheus.
-			httpServer = &http.Server{Handler: promhttp.HandlerFor(reg, promhttp.HandlerOpts{}), Addr: fmt.Sprintf("0.0.0.0:%d", prometheusPort)}
+	lifecycle.OnStop(PhaseDeregister, svc.Name, func(ctx context.Context) error {
+		return deps.Discovery.UnRegister()
+	})
+
+	if svc.Prometheus.Enable && prometheusPort != 0 {
+		metric.InitializeMetrics(srv)
+		// Create a HTTP server for prometheus.
+		mux := http.NewServeMux()
+		mux.Handle(prommetrics.MetricsPath(svc.Prometheus.Path), prommetrics.AuthHandler(&svc.Prometheus.Auth, promhttp.HandlerFor(registry, promhttp.HandlerOpts{})))
+		httpServer := &http.Server{Handler: mux, Addr: net.JoinHostPort(network.GetListenIP(svc.Prometheus.ListenIP), strconv.Itoa(prometheusPort))}
+		// Kept until the flush phase so that metrics of the drain can still be scraped.
+		lifecycle.OnStop(PhaseFlush, svc.Name, httpServer.Shutdown)
+		go func() {
 			if err := httpServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-				netErr = errs.WrapMsg(err, "prometheus start err", httpServer.Addr)
-				netDone <- struct{}{}
+				errCh <- errs.WrapMsg(err, "prometheus start err", httpServer.Addr)
 			}
-		}
-	}()
+		}()
+	}
+	if svc.Prometheus.Enable && svc.Prometheus.PushGateway.URL != "" {
+		pushGateway := prommetrics.NewPushGateway(&svc.Prometheus.PushGateway, registry, index)
+		pushGateway.Start()
+		// Pushes the metrics of the drain as a final batch.
+		lifecycle.OnStop(PhaseFlush, svc.Name+" pushgateway", pushGateway.Stop)
+	}
 
 	go func() {
 		err := srv.Serve(listener)
 		if err != nil {
-			netErr = errs.WrapMsg(err, "rpc start err: ", rpcTcpAddr)
-			netDone <- struct{}{}
+			errCh <- errs.WrapMsg(err, "rpc start err: ", rpcTcpAddr)
 		}
 	}()
-
-	sigs := make(chan os.Signal, 1)
-	signal.Notify(sigs, syscall.SIGTERM)
-	select {
-	case <-sigs:
-		program.SIGTERMExit()
-		ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
-		defer cancel()
-		if err := gracefulStopWithCtx(ctx, srv.GracefulStop); err != nil {
-			return err
-		}
-		ctx, cancel = context.WithTimeout(context.Background(), 15*time.Second)
-		defer cancel()
-		err := httpServer.Shutdown(ctx)
-		if err != nil {
-			return errs.WrapMsg(err, "shutdown err")
-		}
-		return nil
-	case <-netDone:
-		close(netDone)
-		return netErr
-	}
+	return nil
 }
 
 func gracefulStopWithCtx(ctx context.Context, f func()) error {
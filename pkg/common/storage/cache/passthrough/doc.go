@@ -0,0 +1,17 @@
+// Copyright © 2024 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package passthrough implements the cache interfaces without caching, every lookup reads the database.
+// It goes with the in-memory database, which a shared cache would not match.
+package passthrough // import "github.com/openimsdk/openim-project-template/pkg/common/storage/cache/passthrough"
@@ -0,0 +1,75 @@
+// Copyright © 2024 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package passthrough
+
+import (
+	"context"
+
+	"github.com/openimsdk/openim-project-template/pkg/common/storage/cache"
+	"github.com/openimsdk/openim-project-template/pkg/common/storage/database"
+	"github.com/openimsdk/openim-project-template/pkg/common/storage/model"
+	"github.com/openimsdk/tools/errs"
+	"github.com/openimsdk/tools/mw/specialerror"
+)
+
+type User struct {
+	cache.BatchDeleter
+	userDB database.User
+}
+
+func NewUser(userDB database.User) cache.User {
+	return &User{BatchDeleter: batchDeleter{}, userDB: userDB}
+}
+
+func (u *User) CloneUserCache() cache.User {
+	return u
+}
+
+// GetUsersInfo returns the users of userIDs found in the database.
+func (u *User) GetUsersInfo(ctx context.Context, userIDs []string) ([]*model.User, error) {
+	users := make([]*model.User, 0, len(userIDs))
+	for _, userID := range userIDs {
+		user, err := u.userDB.Take(ctx, userID)
+		if err != nil {
+			if errs.ErrRecordNotFound.Is(specialerror.ErrCode(errs.Unwrap(err))) {
+				continue
+			}
+			return nil, err
+		}
+		users = append(users, user)
+	}
+	return users, nil
+}
+
+func (u *User) DelUsersInfo(userIDs ...string) cache.User {
+	return u
+}
+
+// batchDeleter has nothing to delete.
+type batchDeleter struct{}
+
+func (batchDeleter) ChainExecDel(ctx context.Context) error {
+	return nil
+}
+
+func (batchDeleter) ExecDelWithKeys(ctx context.Context, keys []string) error {
+	return nil
+}
+
+func (b batchDeleter) Clone() cache.BatchDeleter {
+	return b
+}
+
+func (batchDeleter) AddKeys(keys ...string) {}
@@ -19,12 +19,14 @@ import (
 	"encoding/json"
 	"fmt"
 	"github.com/dtm-labs/rockscache"
+	"github.com/openimsdk/openim-project-template/pkg/common/prommetrics"
 	"github.com/openimsdk/openim-project-template/pkg/common/storage/cache"
 	"github.com/openimsdk/tools/errs"
 	"github.com/openimsdk/tools/log"
 	"github.com/openimsdk/tools/mw/specialerror"
 	"github.com/openimsdk/tools/utils/datautil"
 	"github.com/redis/go-redis/v9"
+	"strings"
 	"time"
 )
 
@@ -69,6 +71,7 @@ func (c *BatchDeleterRedis) execDel(ctx context.Context, keys []string) error {
 		for slot, singleSlotKeys := range slotMapKeys {
 			if err := c.rocksClient.TagAsDeletedBatch2(ctx, singleSlotKeys); err != nil {
 				log.ZWarn(ctx, "Batch delete cache failed", err, "slot", slot, "keys", singleSlotKeys)
+				prommetrics.CacheDeleteFailedCounter.Add(float64(len(singleSlotKeys)))
 				continue
 			}
 		}
@@ -131,10 +134,28 @@ func groupKeysBySlot(ctx context.Context, redisClient redis.UniversalClient, key
 	return slots, nil
 }
 
-func getCache[T any](ctx context.Context, rcClient *rockscache.Client, key string, expire time.Duration, fn func(ctx context.Context) (T, error)) (T, error) {
+// cacheName returns the prefix of key before the first ':', used to label cache metrics without the key's id.
+func cacheName(key string) string {
+	name, _, _ := strings.Cut(key, ":")
+	return name
+}
+
+func getCache[T any](ctx context.Context, rcClient *rockscache.Client, key string, expire time.Duration, fn func(ctx context.Context) (T, error)) (_ T, err error) {
 	var t T
-	var write bool
+	var write, miss bool
+	start := time.Now()
+	defer func() {
+		result := prommetrics.CacheHit
+		switch {
+		case err != nil && !errs.ErrRecordNotFound.Is(specialerror.ErrCode(errs.Unwrap(err))):
+			result = prommetrics.CacheError
+		case miss:
+			result = prommetrics.CacheMiss
+		}
+		prommetrics.ObserveCacheRequest(cacheName(key), result, time.Since(start))
+	}()
 	v, err := rcClient.Fetch2(ctx, key, expire, func() (s string, err error) {
+		miss = true
 		t, err = fn(ctx)
 		if err != nil {
 			return "", err
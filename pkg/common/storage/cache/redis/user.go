@@ -16,11 +16,14 @@ package redis
 
 import (
 	"context"
-	"github.com/openimsdk/openim-project-template/pkg/common/storage/database"
-	"github.com/openimsdk/openim-project-template/pkg/common/storage/model"
 	"time"
 
+	"github.com/dtm-labs/rockscache"
+	"github.com/openimsdk/openim-project-template/pkg/common/cachekey"
 	"github.com/openimsdk/openim-project-template/pkg/common/storage/cache"
+	"github.com/openimsdk/openim-project-template/pkg/common/storage/database"
+	"github.com/openimsdk/openim-project-template/pkg/common/storage/model"
+	"github.com/redis/go-redis/v9"
 )
 
 const (
@@ -28,21 +31,49 @@ const (
 )
 
 type User struct {
+	cache.BatchDeleter
 	userDB     database.User
 	expireTime time.Duration
+	rcClient   *rockscache.Client
 }
 
-func NewUser(userDB database.User) cache.User {
+func NewUser(rdb redis.UniversalClient, userDB database.User, options *rockscache.Options) cache.User {
 	return &User{
-		userDB:     userDB,
-		expireTime: userExpireTime,
+		BatchDeleter: NewBatchDeleterRedis(rdb, options, nil),
+		userDB:       userDB,
+		expireTime:   userExpireTime,
+		rcClient:     rockscache.NewClient(rdb, *options),
 	}
 }
 
+func (u *User) CloneUserCache() cache.User {
+	return &User{
+		BatchDeleter: u.BatchDeleter.Clone(),
+		userDB:       u.userDB,
+		expireTime:   u.expireTime,
+		rcClient:     u.rcClient,
+	}
+}
+
+func (u *User) getUserInfoKey(userID string) string {
+	return cachekey.GetUserInfoKey(userID)
+}
+
+// GetUsersInfo returns the users of userIDs found, loading those missing from the cache from the database.
 func (u *User) GetUsersInfo(ctx context.Context, userIDs []string) ([]*model.User, error) {
-	userID := userIDs[0]
-	r, err := u.userDB.Take(ctx, userID)
-	return []*model.User{r}, err
+	return batchGetCache(ctx, u.rcClient, u.expireTime, userIDs, u.getUserInfoKey, func(ctx context.Context, userID string) (*model.User, error) {
+		return u.userDB.Take(ctx, userID)
+	})
+}
+
+func (u *User) DelUsersInfo(userIDs ...string) cache.User {
+	keys := make([]string, 0, len(userIDs))
+	for _, userID := range userIDs {
+		keys = append(keys, u.getUserInfoKey(userID))
+	}
+	c := u.CloneUserCache()
+	c.AddKeys(keys...)
+	return c
 }
 
 type Comparable interface {
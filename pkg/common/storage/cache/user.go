@@ -20,5 +20,9 @@ import (
 )
 
 type User interface {
+	BatchDeleter
+	CloneUserCache() User
 	GetUsersInfo(ctx context.Context, userIDs []string) ([]*model.User, error)
+	// DelUsersInfo returns a copy of the cache with the keys of userIDs added, call ChainExecDel on it to delete them.
+	DelUsersInfo(userIDs ...string) User
 }
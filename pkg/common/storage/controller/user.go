@@ -16,6 +16,7 @@ package controller
 
 import (
 	"context"
+	"github.com/openimsdk/openim-project-template/pkg/common/outbox"
 	"github.com/openimsdk/openim-project-template/pkg/common/storage/cache"
 	"github.com/openimsdk/openim-project-template/pkg/common/storage/database"
 	"github.com/openimsdk/openim-project-template/pkg/common/storage/model"
@@ -28,17 +29,24 @@ type User interface {
 	FindWithError(ctx context.Context, userIDs []string) (users []*model.User, err error) //1
 	// Create Insert multiple external guarantees that the userID is not repeated and does not exist in the storage
 	Create(ctx context.Context, users []*model.User) (err error) //1
-
+	// Update replaces the nickname of a registered user and deletes its cache entry.
+	Update(ctx context.Context, user *model.User) (err error)
+	// Find returns the users of userIDs that are registered.
+	Find(ctx context.Context, userIDs []string) (users []*model.User, err error)
+	// Scan calls fn with each user matching filter, in the order of their userID, from the database.
+	Scan(ctx context.Context, filter *database.UserFilter, fn func(user *model.User) error) error
 }
 
 type UserStorageManager struct {
-	tx    tx.Tx
-	db    database.User
-	cache cache.User
+	tx     tx.Tx
+	db     database.User
+	cache  cache.User
+	events database.Outbox
 }
 
-func NewUser(userDB database.User, cache cache.User, tx tx.Tx) User {
-	return &UserStorageManager{db: userDB, cache: cache, tx: tx}
+// NewUser returns the user storage, changes are published to events in the same transaction. A nil events publishes nothing.
+func NewUser(userDB database.User, cache cache.User, events database.Outbox, tx tx.Tx) User {
+	return &UserStorageManager{db: userDB, cache: cache, events: events, tx: tx}
 }
 
 // FindWithError Get the information of the specified user and return an error if the userID is not found.
@@ -53,7 +61,60 @@ func (u *UserStorageManager) FindWithError(ctx context.Context, userIDs []string
 	return
 }
 
+// Find returns the users of userIDs that are registered.
+func (u *UserStorageManager) Find(ctx context.Context, userIDs []string) (users []*model.User, err error) {
+	return u.cache.GetUsersInfo(ctx, userIDs)
+}
+
+// Scan calls fn with each user matching filter, in the order of their userID. The cache is not used,
+// the users are read through a cursor of the database.
+func (u *UserStorageManager) Scan(ctx context.Context, filter *database.UserFilter, fn func(user *model.User) error) error {
+	return u.db.Scan(ctx, filter, fn)
+}
+
 // Create Insert multiple external guarantees that the userID is not repeated and does not exist in the storage.
+// A user.registered event is written to the outbox for each user.
 func (u *UserStorageManager) Create(ctx context.Context, users []*model.User) (err error) {
-	return u.db.Create(ctx, users)
+	if u.events == nil {
+		return u.db.Create(ctx, users)
+	}
+	events := make([]*model.OutboxEvent, 0, len(users))
+	for _, user := range users {
+		event, err := outbox.NewEvent(outbox.UserRegistered, user.UserID, &outbox.UserPayload{UserID: user.UserID, Nickname: user.Nickname})
+		if err != nil {
+			return err
+		}
+		events = append(events, event)
+	}
+	return u.tx.Transaction(ctx, func(ctx context.Context) error {
+		if err := u.db.Create(ctx, users); err != nil {
+			return err
+		}
+		return u.events.Create(ctx, events)
+	})
+}
+
+// Update replaces the nickname of a registered user and deletes its cache entry.
+// A user.updated event is written to the outbox in the same transaction.
+func (u *UserStorageManager) Update(ctx context.Context, user *model.User) error {
+	if u.events == nil {
+		if err := u.db.Update(ctx, user); err != nil {
+			return err
+		}
+		return u.cache.DelUsersInfo(user.UserID).ChainExecDel(ctx)
+	}
+	event, err := outbox.NewEvent(outbox.UserUpdated, user.UserID, &outbox.UserPayload{UserID: user.UserID, Nickname: user.Nickname})
+	if err != nil {
+		return err
+	}
+	err = u.tx.Transaction(ctx, func(ctx context.Context) error {
+		if err := u.db.Update(ctx, user); err != nil {
+			return err
+		}
+		return u.events.Create(ctx, []*model.OutboxEvent{event})
+	})
+	if err != nil {
+		return err
+	}
+	return u.cache.DelUsersInfo(user.UserID).ChainExecDel(ctx)
 }
@@ -0,0 +1,30 @@
+// Copyright © 2024 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/openimsdk/openim-project-template/pkg/common/storage/model"
+	"github.com/openimsdk/tools/db/pagination"
+)
+
+type Audit interface {
+	Create(ctx context.Context, logs []*model.AuditLog) (err error)
+	// Search returns the logs of actorUserID about targetID created in [start, end), newest first.
+	// Empty IDs and zero times do not filter.
+	Search(ctx context.Context, actorUserID string, targetID string, start time.Time, end time.Time, pagination pagination.Pagination) (total int64, logs []*model.AuditLog, err error)
+}
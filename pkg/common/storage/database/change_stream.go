@@ -0,0 +1,33 @@
+// Copyright © 2024 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package database
+
+import (
+	"context"
+	"time"
+)
+
+// ChangeStreamToken keeps the position of change stream watchers so they resume where they stopped.
+// Each stream is consumed by the single watcher holding its lease.
+type ChangeStreamToken interface {
+	// Get returns the resume token saved for stream, nil when there is none.
+	Get(ctx context.Context, stream string) (token []byte, err error)
+	// Save records the token to resume stream after, nil forgets it.
+	Save(ctx context.Context, stream string, token []byte) (err error)
+	// AcquireLease takes or renews the lease of stream for owner until ttl elapses, it reports whether owner holds it.
+	AcquireLease(ctx context.Context, stream string, owner string, ttl time.Duration) (ok bool, err error)
+	// ReleaseLease gives up the lease of stream held by owner.
+	ReleaseLease(ctx context.Context, stream string, owner string) (err error)
+}
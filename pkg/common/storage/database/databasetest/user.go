@@ -0,0 +1,180 @@
+// Copyright © 2024 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package databasetest
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/openimsdk/openim-project-template/pkg/common/storage/database"
+	"github.com/openimsdk/openim-project-template/pkg/common/storage/model"
+	"github.com/openimsdk/tools/errs"
+	"github.com/openimsdk/tools/mw/specialerror"
+)
+
+// TestUser runs the contract of database.User against the empty stores returned by newDB.
+func TestUser(t *testing.T, newDB func(t *testing.T) database.User) {
+	ctx := context.Background()
+
+	t.Run("CreateTake", func(t *testing.T) {
+		db := newDB(t)
+		if err := db.Create(ctx, []*model.User{{UserID: "u1", Nickname: "one"}, {UserID: "u2", Nickname: "two"}}); err != nil {
+			t.Fatal(err)
+		}
+		for _, want := range []string{"u1", "u2"} {
+			user, err := db.Take(ctx, want)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if user.UserID != want {
+				t.Fatalf("take %s returned %s", want, user.UserID)
+			}
+		}
+		user, _ := db.Take(ctx, "u1")
+		if user.Nickname != "one" {
+			t.Fatalf("nickname %q, want one", user.Nickname)
+		}
+	})
+
+	t.Run("TakeNotFound", func(t *testing.T) {
+		db := newDB(t)
+		_, err := db.Take(ctx, "missing")
+		assertCode(t, err, errs.ErrRecordNotFound)
+	})
+
+	t.Run("DuplicateExisting", func(t *testing.T) {
+		db := newDB(t)
+		if err := db.Create(ctx, []*model.User{{UserID: "u1", Nickname: "one"}}); err != nil {
+			t.Fatal(err)
+		}
+		err := db.Create(ctx, []*model.User{{UserID: "u1", Nickname: "other"}})
+		assertCode(t, err, errs.ErrDuplicateKey)
+		user, err := db.Take(ctx, "u1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if user.Nickname != "one" {
+			t.Fatalf("duplicate overwrote the user, nickname %q", user.Nickname)
+		}
+	})
+
+	t.Run("DuplicateOrdered", func(t *testing.T) {
+		db := newDB(t)
+		err := db.Create(ctx, []*model.User{{UserID: "u1"}, {UserID: "u2"}, {UserID: "u1"}, {UserID: "u3"}})
+		assertCode(t, err, errs.ErrDuplicateKey)
+		// The users before the duplicate are inserted, the ones after it are not.
+		for _, userID := range []string{"u1", "u2"} {
+			if _, err := db.Take(ctx, userID); err != nil {
+				t.Fatalf("take %s: %v", userID, err)
+			}
+		}
+		_, err = db.Take(ctx, "u3")
+		assertCode(t, err, errs.ErrRecordNotFound)
+	})
+
+	t.Run("Update", func(t *testing.T) {
+		db := newDB(t)
+		if err := db.Create(ctx, []*model.User{{UserID: "u1", Nickname: "one"}}); err != nil {
+			t.Fatal(err)
+		}
+		if err := db.Update(ctx, &model.User{UserID: "u1", Nickname: "renamed"}); err != nil {
+			t.Fatal(err)
+		}
+		user, err := db.Take(ctx, "u1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if user.Nickname != "renamed" {
+			t.Fatalf("nickname %q, want renamed", user.Nickname)
+		}
+		err = db.Update(ctx, &model.User{UserID: "missing", Nickname: "x"})
+		assertCode(t, err, errs.ErrRecordNotFound)
+	})
+
+	t.Run("Scan", func(t *testing.T) {
+		db := newDB(t)
+		if err := db.Create(ctx, []*model.User{
+			{UserID: "b2", Nickname: "Bob"}, {UserID: "a1", Nickname: "alice"}, {UserID: "b1", Nickname: "bobby"}, {UserID: "c.1", Nickname: "a.b"},
+		}); err != nil {
+			t.Fatal(err)
+		}
+		tests := []struct {
+			name   string
+			filter database.UserFilter
+			want   []string
+		}{
+			{name: "All", want: []string{"a1", "b1", "b2", "c.1"}},
+			{name: "Prefix", filter: database.UserFilter{UserIDPrefix: "b"}, want: []string{"b1", "b2"}},
+			{name: "Nickname", filter: database.UserFilter{Nickname: "bob"}, want: []string{"b1"}},
+			{name: "Literal", filter: database.UserFilter{UserIDPrefix: "c.", Nickname: "."}, want: []string{"c.1"}},
+			{name: "None", filter: database.UserFilter{UserIDPrefix: "z"}},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				var got []string
+				if err := db.Scan(ctx, &tt.filter, func(user *model.User) error {
+					got = append(got, user.UserID)
+					return nil
+				}); err != nil {
+					t.Fatal(err)
+				}
+				if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+					t.Fatalf("got %v, want %v", got, tt.want)
+				}
+			})
+		}
+		stop := errs.New("stop")
+		calls := 0
+		err := db.Scan(ctx, &database.UserFilter{}, func(user *model.User) error {
+			calls++
+			return stop
+		})
+		if err != stop || calls != 1 {
+			t.Fatalf("scan returned %v after %d calls, want the error of fn after 1", err, calls)
+		}
+	})
+
+	t.Run("Copies", func(t *testing.T) {
+		db := newDB(t)
+		created := &model.User{UserID: "u1", Nickname: "one"}
+		if err := db.Create(ctx, []*model.User{created}); err != nil {
+			t.Fatal(err)
+		}
+		created.Nickname = "changed"
+		user, err := db.Take(ctx, "u1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		user.Nickname = "changed"
+		user, err = db.Take(ctx, "u1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if user.Nickname != "one" {
+			t.Fatalf("stored user changed through a pointer, nickname %q", user.Nickname)
+		}
+	})
+}
+
+func assertCode(t *testing.T, err error, want errs.CodeError) {
+	t.Helper()
+	if err == nil {
+		t.Fatalf("got no error, want %s", want.Msg())
+	}
+	if code := specialerror.ErrCode(errs.Unwrap(err)); code == nil || code.Code() != want.Code() {
+		t.Fatalf("got %v, want %s", err, want.Msg())
+	}
+}
@@ -0,0 +1,32 @@
+// Copyright © 2024 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package memory
+
+import (
+	"context"
+
+	"github.com/openimsdk/tools/db/tx"
+)
+
+// NewTx returns a tx.Tx running the functions directly, changes made before an error are kept.
+func NewTx() tx.Tx {
+	return noopTx{}
+}
+
+type noopTx struct{}
+
+func (noopTx) Transaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
@@ -0,0 +1,96 @@
+// Copyright © 2024 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package memory
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/openimsdk/openim-project-template/pkg/common/storage/database"
+	"github.com/openimsdk/openim-project-template/pkg/common/storage/model"
+	"github.com/openimsdk/tools/errs"
+)
+
+// NewUser returns an empty user store with the semantics of the user collection and its unique user_id index.
+func NewUser() database.User {
+	return &User{users: make(map[string]*model.User)}
+}
+
+type User struct {
+	lock  sync.RWMutex
+	users map[string]*model.User
+}
+
+// Create inserts users in order like an ordered insertMany: on a duplicate userID it stops,
+// keeping the users inserted before it, and returns errs.ErrDuplicateKey.
+func (u *User) Create(ctx context.Context, users []*model.User) error {
+	u.lock.Lock()
+	defer u.lock.Unlock()
+	for _, user := range users {
+		if _, ok := u.users[user.UserID]; ok {
+			return errs.ErrDuplicateKey.WrapMsg("userID already exists", "userID", user.UserID)
+		}
+		c := *user
+		u.users[user.UserID] = &c
+	}
+	return nil
+}
+
+func (u *User) Take(ctx context.Context, userID string) (*model.User, error) {
+	u.lock.RLock()
+	defer u.lock.RUnlock()
+	user, ok := u.users[userID]
+	if !ok {
+		return nil, errs.ErrRecordNotFound.WrapMsg("user not found", "userID", userID)
+	}
+	c := *user
+	return &c, nil
+}
+
+func (u *User) Update(ctx context.Context, user *model.User) error {
+	u.lock.Lock()
+	defer u.lock.Unlock()
+	stored, ok := u.users[user.UserID]
+	if !ok {
+		return errs.ErrRecordNotFound.WrapMsg("user not found", "userID", user.UserID)
+	}
+	stored.Nickname = user.Nickname
+	return nil
+}
+
+// Scan calls fn with copies of the users taken when it starts, so fn may change the store.
+func (u *User) Scan(ctx context.Context, filter *database.UserFilter, fn func(user *model.User) error) error {
+	u.lock.RLock()
+	users := make([]*model.User, 0, len(u.users))
+	for _, user := range u.users {
+		if strings.HasPrefix(user.UserID, filter.UserIDPrefix) && strings.Contains(user.Nickname, filter.Nickname) {
+			c := *user
+			users = append(users, &c)
+		}
+	}
+	u.lock.RUnlock()
+	sort.Slice(users, func(i, j int) bool { return users[i].UserID < users[j].UserID })
+	for _, user := range users {
+		if err := ctx.Err(); err != nil {
+			return errs.WrapMsg(err, "scan canceled")
+		}
+		if err := fn(user); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+// Copyright © 2024 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mgo
+
+import (
+	"context"
+	"time"
+
+	"github.com/openimsdk/openim-project-template/pkg/common/storage/database"
+	"github.com/openimsdk/openim-project-template/pkg/common/storage/model"
+	"github.com/openimsdk/tools/db/mongoutil"
+	"github.com/openimsdk/tools/db/pagination"
+	"github.com/openimsdk/tools/errs"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// namespaceExistsCode is returned by mongo when creating a collection that exists.
+const namespaceExistsCode = 48
+
+// DefaultAuditCapSize is the cap in bytes of the audit log collection created by the migrations.
+const DefaultAuditCapSize = 1 << 30
+
+// CreateAuditCollection creates the audit log collection capped to capSize bytes when it is missing.
+// The cap of an existing collection is not changed.
+func CreateAuditCollection(ctx context.Context, db *mongo.Database, capSize int64) error {
+	err := db.CreateCollection(ctx, "audit_log", options.CreateCollection().SetCapped(true).SetSizeInBytes(capSize))
+	if err != nil && !hasErrorCode(err, namespaceExistsCode) {
+		return errs.WrapMsg(err, "create audit log collection failed")
+	}
+	return nil
+}
+
+// NewAuditMongo returns the audit log of db, its collection and indexes are created by the migrations.
+func NewAuditMongo(db *mongo.Database) (database.Audit, error) {
+	return &AuditMgo{coll: db.Collection("audit_log")}, nil
+}
+
+type AuditMgo struct {
+	coll *mongo.Collection
+}
+
+func (a *AuditMgo) Create(ctx context.Context, logs []*model.AuditLog) (err error) {
+	done := observe(a.coll, "insertMany")
+	defer func() { done(err) }()
+	return mongoutil.InsertMany(ctx, a.coll, logs)
+}
+
+func (a *AuditMgo) Search(ctx context.Context, actorUserID string, targetID string, start time.Time, end time.Time, pagination pagination.Pagination) (total int64, logs []*model.AuditLog, err error) {
+	done := observe(a.coll, "find")
+	defer func() { done(err) }()
+	filter := bson.M{}
+	if actorUserID != "" {
+		filter["actor_user_id"] = actorUserID
+	}
+	if targetID != "" {
+		filter["target_ids"] = targetID
+	}
+	createTime := bson.M{}
+	if !start.IsZero() {
+		createTime["$gte"] = start
+	}
+	if !end.IsZero() {
+		createTime["$lt"] = end
+	}
+	if len(createTime) > 0 {
+		filter["create_time"] = createTime
+	}
+	return mongoutil.FindPage[*model.AuditLog](ctx, a.coll, filter, pagination, options.Find().SetSort(bson.D{{Key: "create_time", Value: -1}}))
+}
@@ -0,0 +1,72 @@
+// Copyright © 2024 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mgo
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/openimsdk/openim-project-template/pkg/common/storage/database"
+	"github.com/openimsdk/tools/errs"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func NewChangeStreamTokenMongo(db *mongo.Database) (database.ChangeStreamToken, error) {
+	return &ChangeStreamTokenMgo{coll: db.Collection("change_stream_token")}, nil
+}
+
+// ChangeStreamTokenMgo keeps a document per stream holding its resume token and the lease of its watcher.
+type ChangeStreamTokenMgo struct {
+	coll *mongo.Collection
+}
+
+func (c *ChangeStreamTokenMgo) Get(ctx context.Context, stream string) (token []byte, err error) {
+	done := observe(c.coll, "findOne")
+	defer func() { done(err) }()
+	var doc struct {
+		Token bson.Raw `bson:"token"`
+	}
+	if err := c.coll.FindOne(ctx, bson.M{"_id": stream}).Decode(&doc); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil
+		}
+		return nil, errs.Wrap(err)
+	}
+	return doc.Token, nil
+}
+
+func (c *ChangeStreamTokenMgo) Save(ctx context.Context, stream string, token []byte) (err error) {
+	done := observe(c.coll, "updateOne")
+	defer func() { done(err) }()
+	update := bson.M{"$set": bson.M{"token": bson.Raw(token), "update_time": time.Now()}}
+	if token == nil {
+		update = bson.M{"$unset": bson.M{"token": ""}, "$set": bson.M{"update_time": time.Now()}}
+	}
+	if _, err := c.coll.UpdateOne(ctx, bson.M{"_id": stream}, update, options.Update().SetUpsert(true)); err != nil {
+		return errs.Wrap(err)
+	}
+	return nil
+}
+
+func (c *ChangeStreamTokenMgo) AcquireLease(ctx context.Context, stream string, owner string, ttl time.Duration) (ok bool, err error) {
+	return acquireLease(ctx, c.coll, stream, owner, ttl)
+}
+
+func (c *ChangeStreamTokenMgo) ReleaseLease(ctx context.Context, stream string, owner string) (err error) {
+	return releaseLease(ctx, c.coll, stream, owner)
+}
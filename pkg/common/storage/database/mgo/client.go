@@ -0,0 +1,145 @@
+// Copyright © 2024 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mgo
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/openimsdk/tools/db/mongoutil"
+	"github.com/openimsdk/tools/db/tx"
+	"github.com/openimsdk/tools/errs"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.opentelemetry.io/contrib/instrumentation/go.mongodb.org/mongo-driver/mongo/otelmongo"
+)
+
+const connectRetryInterval = time.Second
+
+// Client is the Mongo database of a configuration. Its commands are traced by the otelmongo monitor of the
+// driver, so that every operation gets a span whether or not its method observes it.
+type Client struct {
+	db *mongo.Database
+	tx tx.Tx
+}
+
+// NewClient connects to the database of conf as mongoutil.NewMongoDB does, trying MaxRetry times.
+func NewClient(ctx context.Context, conf *mongoutil.Config) (*Client, error) {
+	uri := conf.Uri
+	if uri == "" {
+		uri = buildURI(conf)
+	}
+	opts := options.Client().ApplyURI(uri).SetMonitor(otelmongo.NewMonitor())
+	if conf.MaxPoolSize > 0 {
+		opts.SetMaxPoolSize(uint64(conf.MaxPoolSize))
+	}
+	var (
+		client *mongo.Client
+		err    error
+	)
+	for attempt := 0; attempt <= conf.MaxRetry; attempt++ {
+		if client, err = connect(ctx, opts); err == nil {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			return nil, errs.WrapMsg(ctx.Err(), "connect mongo canceled", "lastError", err.Error())
+		case <-time.After(connectRetryInterval):
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	mtx, err := newTx(ctx, client)
+	if err != nil {
+		_ = client.Disconnect(ctx)
+		return nil, err
+	}
+	return &Client{db: client.Database(conf.Database), tx: mtx}, nil
+}
+
+func (c *Client) GetDB() *mongo.Database {
+	return c.db
+}
+
+func (c *Client) GetTx() tx.Tx {
+	return c.tx
+}
+
+func connect(ctx context.Context, opts *options.ClientOptions) (*mongo.Client, error) {
+	client, err := mongo.Connect(ctx, opts)
+	if err != nil {
+		return nil, errs.WrapMsg(err, "connect mongo failed")
+	}
+	if err := client.Ping(ctx, nil); err != nil {
+		_ = client.Disconnect(ctx)
+		return nil, errs.WrapMsg(err, "ping mongo failed")
+	}
+	return client, nil
+}
+
+// buildURI returns the URI of the addresses and credentials of conf, authenticated against the admin database.
+func buildURI(conf *mongoutil.Config) string {
+	uri := url.URL{
+		Scheme:   "mongodb",
+		Host:     strings.Join(conf.Address, ","),
+		Path:     "/" + conf.Database,
+		RawQuery: "authSource=admin",
+	}
+	if conf.Username != "" {
+		uri.User = url.UserPassword(conf.Username, conf.Password)
+	}
+	return uri.String()
+}
+
+// newTx returns the transactions of client. A standalone server has none, its transactions run the function directly.
+func newTx(ctx context.Context, client *mongo.Client) (tx.Tx, error) {
+	var hello struct {
+		SetName string `bson:"setName"`
+		Msg     string `bson:"msg"`
+	}
+	if err := client.Database("admin").RunCommand(ctx, bson.D{{Key: "hello", Value: 1}}).Decode(&hello); err != nil {
+		return nil, errs.WrapMsg(err, "mongo hello failed")
+	}
+	if hello.SetName == "" && hello.Msg != "isdbgrid" {
+		return directTx{}, nil
+	}
+	return &mongoTx{client: client}, nil
+}
+
+type mongoTx struct {
+	client *mongo.Client
+}
+
+func (t *mongoTx) Transaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	session, err := t.client.StartSession()
+	if err != nil {
+		return errs.WrapMsg(err, "start mongo session failed")
+	}
+	defer session.EndSession(ctx)
+	_, err = session.WithTransaction(ctx, func(ctx mongo.SessionContext) (any, error) {
+		return nil, fn(ctx)
+	})
+	return err
+}
+
+type directTx struct{}
+
+func (directTx) Transaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
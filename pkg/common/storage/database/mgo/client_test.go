@@ -0,0 +1,42 @@
+// Copyright © 2024 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mgo
+
+import (
+	"testing"
+
+	"github.com/openimsdk/tools/db/mongoutil"
+)
+
+func TestBuildURI(t *testing.T) {
+	tests := []struct {
+		conf mongoutil.Config
+		want string
+	}{
+		{
+			conf: mongoutil.Config{Address: []string{"localhost:37017"}, Database: "openim_v3"},
+			want: "mongodb://localhost:37017/openim_v3?authSource=admin",
+		},
+		{
+			conf: mongoutil.Config{Address: []string{"m1:27017", "m2:27017"}, Database: "openim_v3", Username: "root", Password: "p@ss:word"},
+			want: "mongodb://root:p%40ss%3Aword@m1:27017,m2:27017/openim_v3?authSource=admin",
+		},
+	}
+	for _, tt := range tests {
+		if got := buildURI(&tt.conf); got != tt.want {
+			t.Errorf("buildURI(%v) = %s, want %s", tt.conf.Address, got, tt.want)
+		}
+	}
+}
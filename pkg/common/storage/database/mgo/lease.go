@@ -0,0 +1,58 @@
+// Copyright © 2024 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mgo
+
+import (
+	"context"
+	"time"
+
+	"github.com/openimsdk/tools/db/mongoutil"
+	"github.com/openimsdk/tools/errs"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// acquireLease takes or renews the lease kept in the document id of coll for owner until ttl elapses.
+// It reports whether owner holds the lease.
+func acquireLease(ctx context.Context, coll *mongo.Collection, id string, owner string, ttl time.Duration) (ok bool, err error) {
+	done := observe(coll, "updateOne")
+	defer func() { done(err) }()
+	now := time.Now()
+	filter := bson.M{
+		"_id": id,
+		"$or": bson.A{
+			bson.M{"owner": owner},
+			bson.M{"expire_time": bson.M{"$lt": now}},
+		},
+	}
+	update := bson.M{"$set": bson.M{"owner": owner, "expire_time": now.Add(ttl)}}
+	// The upsert only inserts when no lease exists, a lease held by another owner makes it fail on the _id.
+	_, err = coll.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return false, nil
+		}
+		return false, errs.Wrap(err)
+	}
+	return true, nil
+}
+
+// releaseLease expires the lease of the document id of coll held by owner, the other fields of the document are kept.
+func releaseLease(ctx context.Context, coll *mongo.Collection, id string, owner string) (err error) {
+	done := observe(coll, "updateOne")
+	defer func() { done(err) }()
+	return mongoutil.UpdateOne(ctx, coll, bson.M{"_id": id, "owner": owner}, bson.M{"$set": bson.M{"expire_time": time.Time{}}}, false)
+}
@@ -0,0 +1,294 @@
+// Copyright © 2024 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mgo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/openimsdk/tools/errs"
+	"github.com/openimsdk/tools/log"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	migrationLockID       = "migrate"
+	migrationLockTTL      = 10 * time.Minute
+	migrationLockInterval = time.Second
+	// migrationLockRenewal is how often the lock is renewed while migrating, so that it does not expire
+	// during a long migration.
+	migrationLockRenewal = migrationLockTTL / 3
+)
+
+// Migration changes the schema from Version-1 to Version, Down reverts it. A migration that fails half way
+// is run again, so both must be idempotent.
+type Migration struct {
+	Version int
+	Name    string
+	Up      func(ctx context.Context, db *mongo.Database) error
+	// Down is nil when the migration can not be reverted.
+	Down func(ctx context.Context, db *mongo.Database) error
+}
+
+// MigrationStatus tells whether a migration was applied and when.
+type MigrationStatus struct {
+	Version     int
+	Name        string
+	Applied     bool
+	AppliedTime time.Time
+}
+
+type appliedMigration struct {
+	Version     int       `bson:"_id"`
+	Name        string    `bson:"name"`
+	AppliedTime time.Time `bson:"applied_time"`
+}
+
+// LatestSchemaVersion returns the schema version this binary supports.
+func LatestSchemaVersion() int {
+	if len(migrations) == 0 {
+		return 0
+	}
+	return migrations[len(migrations)-1].Version
+}
+
+// Migrator applies the migrations to db and tracks them in the migrations collection.
+// Instances take a lock before changing the schema, so only one migrates at a time.
+type Migrator struct {
+	db    *mongo.Database
+	coll  *mongo.Collection
+	lock  *mongo.Collection
+	owner string
+}
+
+func NewMigrator(db *mongo.Database) *Migrator {
+	host, _ := os.Hostname()
+	return &Migrator{
+		db:    db,
+		coll:  db.Collection("migrations"),
+		lock:  db.Collection("migration_lock"),
+		owner: fmt.Sprintf("%s-%d-%s", host, os.Getpid(), primitive.NewObjectID().Hex()),
+	}
+}
+
+// Version returns the version of the last applied migration, 0 when none was.
+func (m *Migrator) Version(ctx context.Context) (version int, err error) {
+	done := observe(m.coll, "findOne")
+	defer func() { done(err) }()
+	var last appliedMigration
+	err = m.coll.FindOne(ctx, bson.M{}, options.FindOne().SetSort(bson.D{{Key: "_id", Value: -1}})).Decode(&last)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, errs.WrapMsg(err, "get schema version failed")
+	}
+	return last.Version, nil
+}
+
+// Check returns an error unless the schema version is the one this binary supports.
+func (m *Migrator) Check(ctx context.Context) error {
+	version, err := m.Version(ctx)
+	if err != nil {
+		return err
+	}
+	if latest := LatestSchemaVersion(); version != latest {
+		if version > latest {
+			return errs.New("schema version is newer than this binary supports, upgrade the binary", "schemaVersion", version, "supported", latest).Wrap()
+		}
+		return errs.New("schema version is older than this binary needs, run migrate up", "schemaVersion", version, "supported", latest).Wrap()
+	}
+	return nil
+}
+
+// Up applies the pending migrations up to version target, 0 meaning the latest, and returns those applied.
+func (m *Migrator) Up(ctx context.Context, target int) (applied []*Migration, err error) {
+	latest := LatestSchemaVersion()
+	if target <= 0 {
+		target = latest
+	}
+	if target > latest {
+		return nil, errs.New("unknown schema version", "target", target, "supported", latest).Wrap()
+	}
+	err = m.withLock(ctx, func(ctx context.Context) error {
+		version, err := m.Version(ctx)
+		if err != nil {
+			return err
+		}
+		if version > latest {
+			return errs.New("schema version is newer than this binary supports", "schemaVersion", version, "supported", latest).Wrap()
+		}
+		for _, migration := range migrations {
+			if migration.Version <= version || migration.Version > target {
+				continue
+			}
+			log.ZInfo(ctx, "apply migration", "version", migration.Version, "name", migration.Name)
+			if err := migration.Up(ctx, m.db); err != nil {
+				return errs.WrapMsg(err, "apply migration failed", "version", migration.Version, "name", migration.Name)
+			}
+			if err := m.record(ctx, migration); err != nil {
+				return err
+			}
+			applied = append(applied, migration)
+		}
+		return nil
+	})
+	return applied, err
+}
+
+// Down reverts the last steps applied migrations and returns those reverted.
+func (m *Migrator) Down(ctx context.Context, steps int) (reverted []*Migration, err error) {
+	err = m.withLock(ctx, func(ctx context.Context) error {
+		version, err := m.Version(ctx)
+		if err != nil {
+			return err
+		}
+		if version > LatestSchemaVersion() {
+			return errs.New("schema version is newer than this binary supports", "schemaVersion", version, "supported", LatestSchemaVersion()).Wrap()
+		}
+		for i := len(migrations) - 1; i >= 0 && len(reverted) < steps; i-- {
+			migration := migrations[i]
+			if migration.Version > version {
+				continue
+			}
+			if migration.Down == nil {
+				return errs.New("migration can not be reverted", "version", migration.Version, "name", migration.Name).Wrap()
+			}
+			log.ZInfo(ctx, "revert migration", "version", migration.Version, "name", migration.Name)
+			if err := migration.Down(ctx, m.db); err != nil {
+				return errs.WrapMsg(err, "revert migration failed", "version", migration.Version, "name", migration.Name)
+			}
+			if err := m.forget(ctx, migration); err != nil {
+				return err
+			}
+			reverted = append(reverted, migration)
+		}
+		return nil
+	})
+	return reverted, err
+}
+
+// Status returns the state of every migration known to this binary and the schema version.
+func (m *Migrator) Status(ctx context.Context) (status []*MigrationStatus, version int, err error) {
+	version, err = m.Version(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+	cur, err := m.coll.Find(ctx, bson.M{})
+	if err != nil {
+		return nil, 0, errs.Wrap(err)
+	}
+	var applied []*appliedMigration
+	if err := cur.All(ctx, &applied); err != nil {
+		return nil, 0, errs.Wrap(err)
+	}
+	appliedTime := make(map[int]time.Time, len(applied))
+	for _, a := range applied {
+		appliedTime[a.Version] = a.AppliedTime
+	}
+	for _, migration := range migrations {
+		t, ok := appliedTime[migration.Version]
+		status = append(status, &MigrationStatus{Version: migration.Version, Name: migration.Name, Applied: ok, AppliedTime: t})
+	}
+	return status, version, nil
+}
+
+func (m *Migrator) record(ctx context.Context, migration *Migration) (err error) {
+	done := observe(m.coll, "updateOne")
+	defer func() { done(err) }()
+	update := bson.M{"$set": bson.M{"name": migration.Name, "applied_time": time.Now()}}
+	if _, err := m.coll.UpdateOne(ctx, bson.M{"_id": migration.Version}, update, options.Update().SetUpsert(true)); err != nil {
+		return errs.WrapMsg(err, "record migration failed", "version", migration.Version)
+	}
+	return nil
+}
+
+func (m *Migrator) forget(ctx context.Context, migration *Migration) (err error) {
+	done := observe(m.coll, "deleteOne")
+	defer func() { done(err) }()
+	if _, err := m.coll.DeleteOne(ctx, bson.M{"_id": migration.Version}); err != nil {
+		return errs.WrapMsg(err, "forget migration failed", "version", migration.Version)
+	}
+	return nil
+}
+
+// withLock runs fn holding the migration lock, waiting for another instance to release it until ctx is done.
+// The lock is renewed while fn runs, the context of fn is canceled if it is lost.
+func (m *Migrator) withLock(ctx context.Context, fn func(ctx context.Context) error) error {
+	for {
+		ok, err := acquireLease(ctx, m.lock, migrationLockID, m.owner, migrationLockTTL)
+		if err != nil {
+			return err
+		}
+		if ok {
+			break
+		}
+		log.ZInfo(ctx, "waiting for the migration lock held by another instance")
+		select {
+		case <-ctx.Done():
+			return errs.WrapMsg(ctx.Err(), "migration lock not acquired")
+		case <-time.After(migrationLockInterval):
+		}
+	}
+	defer func() {
+		if err := releaseLease(context.WithoutCancel(ctx), m.lock, migrationLockID, m.owner); err != nil {
+			log.ZWarn(ctx, "release migration lock failed", err)
+		}
+	}()
+	lockCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	lost := make(chan bool, 1)
+	go func() {
+		lost <- m.keepLock(lockCtx, cancel)
+	}()
+	err := fn(lockCtx)
+	cancel()
+	if <-lost && err != nil {
+		return errs.WrapMsg(err, "migration lock taken by another instance")
+	}
+	return err
+}
+
+// keepLock renews the migration lock until ctx is done, it cancels ctx and reports true when another instance
+// took the lock. A failed renewal is retried, the lock only expires after migrationLockTTL.
+func (m *Migrator) keepLock(ctx context.Context, cancel context.CancelFunc) (lost bool) {
+	ticker := time.NewTicker(migrationLockRenewal)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return false
+		case <-ticker.C:
+		}
+		ok, err := acquireLease(ctx, m.lock, migrationLockID, m.owner, migrationLockTTL)
+		if err != nil {
+			if ctx.Err() == nil {
+				log.ZWarn(ctx, "renew migration lock failed", err)
+			}
+			continue
+		}
+		if !ok {
+			log.ZWarn(ctx, "migration lock taken by another instance", nil)
+			cancel()
+			return true
+		}
+	}
+}
@@ -0,0 +1,157 @@
+// Copyright © 2024 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mgo
+
+import (
+	"context"
+
+	"github.com/openimsdk/tools/errs"
+	"github.com/openimsdk/tools/log"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// migrations lists the schema changes in version order. Append new ones with the next version,
+// never change or remove one that was released.
+var migrations = []*Migration{
+	{
+		Version: 1,
+		Name:    "create user_id unique index",
+		Up: func(ctx context.Context, db *mongo.Database) error {
+			_, err := db.Collection("user").Indexes().CreateOne(ctx, mongo.IndexModel{
+				Keys:    bson.D{{Key: "user_id", Value: 1}},
+				Options: options.Index().SetUnique(true).SetName("user_id_1"),
+			})
+			return errs.Wrap(err)
+		},
+		Down: func(ctx context.Context, db *mongo.Database) error {
+			return dropIndex(ctx, db.Collection("user"), "user_id_1")
+		},
+	},
+	{
+		Version: 2,
+		Name:    "create outbox pending index",
+		Up: func(ctx context.Context, db *mongo.Database) error {
+			_, err := db.Collection("outbox").Indexes().CreateOne(ctx, mongo.IndexModel{
+				Keys:    bson.D{{Key: "dead", Value: 1}, {Key: "_id", Value: 1}},
+				Options: options.Index().SetName("dead_1__id_1"),
+			})
+			return errs.Wrap(err)
+		},
+		Down: func(ctx context.Context, db *mongo.Database) error {
+			return dropIndex(ctx, db.Collection("outbox"), "dead_1__id_1")
+		},
+	},
+	{
+		Version: 3,
+		Name:    "create audit log indexes",
+		Up: func(ctx context.Context, db *mongo.Database) error {
+			// Services with the audit log enabled create the collection with their cap before migrating.
+			if err := CreateAuditCollection(ctx, db, DefaultAuditCapSize); err != nil {
+				return err
+			}
+			_, err := db.Collection("audit_log").Indexes().CreateMany(ctx, []mongo.IndexModel{
+				{
+					Keys:    bson.D{{Key: "create_time", Value: -1}},
+					Options: options.Index().SetName("create_time_-1"),
+				},
+				{
+					Keys:    bson.D{{Key: "actor_user_id", Value: 1}, {Key: "create_time", Value: -1}},
+					Options: options.Index().SetName("actor_user_id_1_create_time_-1"),
+				},
+				{
+					Keys:    bson.D{{Key: "target_ids", Value: 1}, {Key: "create_time", Value: -1}},
+					Options: options.Index().SetName("target_ids_1_create_time_-1"),
+				},
+			})
+			return errs.Wrap(err)
+		},
+		Down: func(ctx context.Context, db *mongo.Database) error {
+			for _, name := range []string{"create_time_-1", "actor_user_id_1_create_time_-1", "target_ids_1_create_time_-1"} {
+				if err := dropIndex(ctx, db.Collection("audit_log"), name); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	},
+	{
+		Version: 4,
+		Name:    "enable user change stream pre-images",
+		Up: func(ctx context.Context, db *mongo.Database) error {
+			return setUserPreImages(ctx, db, true)
+		},
+		Down: func(ctx context.Context, db *mongo.Database) error {
+			return setUserPreImages(ctx, db, false)
+		},
+	},
+	{
+		Version: 5,
+		Name:    "create outbox key sequence index",
+		Up: func(ctx context.Context, db *mongo.Database) error {
+			_, err := db.Collection("outbox").Indexes().CreateOne(ctx, mongo.IndexModel{
+				Keys:    bson.D{{Key: "key", Value: 1}, {Key: "seq", Value: 1}},
+				Options: options.Index().SetName("key_1_seq_1"),
+			})
+			return errs.Wrap(err)
+		},
+		Down: func(ctx context.Context, db *mongo.Database) error {
+			return dropIndex(ctx, db.Collection("outbox"), "key_1_seq_1")
+		},
+	},
+}
+
+// setUserPreImages lets the change stream of the user collection carry the deleted documents, and so the
+// userID of a deletion, for the cache watcher. The option needs mongo 6.0 or later, older servers are left
+// as they are and the watcher skips deletions.
+func setUserPreImages(ctx context.Context, db *mongo.Database, enabled bool) error {
+	var info struct {
+		VersionArray []int32 `bson:"versionArray"`
+	}
+	if err := db.RunCommand(ctx, bson.D{{Key: "buildInfo", Value: 1}}).Decode(&info); err != nil {
+		return errs.WrapMsg(err, "read mongo version failed")
+	}
+	if len(info.VersionArray) == 0 || info.VersionArray[0] < 6 {
+		log.ZWarn(ctx, "change stream pre-images need mongo 6.0 or later, the cache watcher will not see deletions", nil, "version", info.VersionArray)
+		return nil
+	}
+	if enabled {
+		if err := db.CreateCollection(ctx, "user"); err != nil && !hasErrorCode(err, namespaceExistsCode) {
+			return errs.WrapMsg(err, "create user collection failed")
+		}
+	}
+	cmd := bson.D{
+		{Key: "collMod", Value: "user"},
+		{Key: "changeStreamPreAndPostImages", Value: bson.D{{Key: "enabled", Value: enabled}}},
+	}
+	if err := db.RunCommand(ctx, cmd).Err(); err != nil && !hasErrorCode(err, namespaceNotFoundCode) {
+		return errs.WrapMsg(err, "set change stream pre-images of the user collection failed", "enabled", enabled)
+	}
+	return nil
+}
+
+// Error codes returned by mongo when dropping an index that does not exist, or an index of a missing collection.
+const (
+	namespaceNotFoundCode = 26
+	indexNotFoundCode     = 27
+)
+
+func dropIndex(ctx context.Context, coll *mongo.Collection, name string) error {
+	if _, err := coll.Indexes().DropOne(ctx, name); err != nil && !hasErrorCode(err, indexNotFoundCode, namespaceNotFoundCode) {
+		return errs.WrapMsg(err, "drop index failed", "collection", coll.Name(), "index", name)
+	}
+	return nil
+}
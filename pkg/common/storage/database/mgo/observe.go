@@ -0,0 +1,37 @@
+// Copyright © 2023 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mgo
+
+import (
+	"errors"
+	"time"
+
+	"github.com/openimsdk/openim-project-template/pkg/common/prommetrics"
+	"github.com/openimsdk/tools/errs"
+	"github.com/openimsdk/tools/mw/specialerror"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// observe measures an operation on coll. The returned func must be called with the result of the operation.
+// A missing document is not counted as a failure. The commands of the operation are traced by the
+// monitor installed by NewClient.
+func observe(coll *mongo.Collection, operation string) func(err error) {
+	start := time.Now()
+	return func(err error) {
+		failed := err != nil && !errors.Is(errs.Unwrap(err), mongo.ErrNoDocuments) &&
+			!errs.ErrRecordNotFound.Is(specialerror.ErrCode(errs.Unwrap(err)))
+		prommetrics.ObserveMongoOperation(coll.Name(), operation, time.Since(start), failed)
+	}
+}
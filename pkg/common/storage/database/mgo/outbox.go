@@ -0,0 +1,151 @@
+// Copyright © 2024 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mgo
+
+import (
+	"context"
+	"time"
+
+	"github.com/openimsdk/openim-project-template/pkg/common/storage/database"
+	"github.com/openimsdk/openim-project-template/pkg/common/storage/model"
+	"github.com/openimsdk/tools/db/mongoutil"
+	"github.com/openimsdk/tools/errs"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// outboxLeaseID is the ID of the single lease document, one relay delivers at a time to keep events ordered.
+const outboxLeaseID = "relay"
+
+// NewOutboxMongo returns the outbox of db, its indexes are created by the migrations.
+func NewOutboxMongo(db *mongo.Database) (database.Outbox, error) {
+	return &OutboxMgo{coll: db.Collection(outboxCollection), seq: db.Collection("outbox_seq"), lease: db.Collection("outbox_lease")}, nil
+}
+
+type OutboxMgo struct {
+	coll *mongo.Collection
+	// seq holds the last sequence taken for each key.
+	seq   *mongo.Collection
+	lease *mongo.Collection
+}
+
+func (o *OutboxMgo) Create(ctx context.Context, events []*model.OutboxEvent) (err error) {
+	done := observe(o.coll, "insertMany")
+	defer func() { done(err) }()
+	if mongo.SessionFromContext(ctx) != nil {
+		return o.create(ctx, events)
+	}
+	// The sequences only follow the commit order when they are taken in the transaction of the insert.
+	session, err := o.coll.Database().Client().StartSession()
+	if err != nil {
+		return errs.WrapMsg(err, "start outbox session failed")
+	}
+	defer session.EndSession(ctx)
+	_, err = session.WithTransaction(ctx, func(ctx mongo.SessionContext) (any, error) {
+		return nil, o.create(ctx, events)
+	})
+	return errs.Wrap(err)
+}
+
+// create takes the next sequence of the key of each event and inserts them. Concurrent transactions taking
+// the sequence of the same key conflict on its counter, so the later one commits with the next sequence.
+func (o *OutboxMgo) create(ctx context.Context, events []*model.OutboxEvent) error {
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+	for _, event := range events {
+		var counter struct {
+			Seq int64 `bson:"seq"`
+		}
+		err := o.seq.FindOneAndUpdate(ctx, bson.M{"_id": event.Key}, bson.M{"$inc": bson.M{"seq": int64(1)}}, opts).Decode(&counter)
+		if err != nil {
+			return errs.WrapMsg(err, "take outbox sequence failed", "key", event.Key)
+		}
+		event.Seq = counter.Seq
+	}
+	return mongoutil.InsertMany(ctx, o.coll, events)
+}
+
+func (o *OutboxMgo) FindPending(ctx context.Context, after primitive.ObjectID, excludeKeys []string, limit int) (events []*model.OutboxEvent, err error) {
+	done := observe(o.coll, "find")
+	defer func() { done(err) }()
+	filter := bson.M{"dead": false}
+	if !after.IsZero() {
+		filter["_id"] = bson.M{"$gt": after}
+	}
+	if len(excludeKeys) > 0 {
+		filter["key"] = bson.M{"$nin": excludeKeys}
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}).SetLimit(int64(limit))
+	return mongoutil.Find[*model.OutboxEvent](ctx, o.coll, filter, opts)
+}
+
+func (o *OutboxMgo) FirstPending(ctx context.Context, keys []string) (seqs map[string]int64, err error) {
+	seqs = make(map[string]int64)
+	if len(keys) == 0 {
+		return seqs, nil
+	}
+	done := observe(o.coll, "aggregate")
+	defer func() { done(err) }()
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"key": bson.M{"$in": keys}, "dead": false, "seq": bson.M{"$gt": 0}}}},
+		{{Key: "$group", Value: bson.M{"_id": "$key", "seq": bson.M{"$min": "$seq"}}}},
+	}
+	cursor, err := o.coll.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, errs.WrapMsg(err, "aggregate outbox sequences failed")
+	}
+	var res []struct {
+		Key string `bson:"_id"`
+		Seq int64  `bson:"seq"`
+	}
+	if err := cursor.All(ctx, &res); err != nil {
+		return nil, errs.WrapMsg(err, "read outbox sequences failed")
+	}
+	for _, r := range res {
+		seqs[r.Key] = r.Seq
+	}
+	return seqs, nil
+}
+
+func (o *OutboxMgo) Delete(ctx context.Context, ids []primitive.ObjectID) (err error) {
+	if len(ids) == 0 {
+		return nil
+	}
+	done := observe(o.coll, "deleteMany")
+	defer func() { done(err) }()
+	return mongoutil.DeleteMany(ctx, o.coll, bson.M{"_id": bson.M{"$in": ids}})
+}
+
+func (o *OutboxMgo) UpdateAttempt(ctx context.Context, event *model.OutboxEvent) (err error) {
+	done := observe(o.coll, "updateOne")
+	defer func() { done(err) }()
+	update := bson.M{"$set": bson.M{
+		"delivered":         event.Delivered,
+		"attempts":          event.Attempts,
+		"next_attempt_time": event.NextAttemptTime,
+		"last_error":        event.LastError,
+		"dead":              event.Dead,
+	}}
+	return mongoutil.UpdateOne(ctx, o.coll, bson.M{"_id": event.ID}, update, false)
+}
+
+func (o *OutboxMgo) AcquireLease(ctx context.Context, owner string, ttl time.Duration) (ok bool, err error) {
+	return acquireLease(ctx, o.lease, outboxLeaseID, owner, ttl)
+}
+
+func (o *OutboxMgo) ReleaseLease(ctx context.Context, owner string) (err error) {
+	return releaseLease(ctx, o.lease, outboxLeaseID, owner)
+}
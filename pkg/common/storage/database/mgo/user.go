@@ -16,6 +16,7 @@ package mgo
 
 import (
 	"context"
+	"errors"
 	"github.com/openimsdk/openim-project-template/pkg/common/storage/database"
 	"github.com/openimsdk/openim-project-template/pkg/common/storage/model"
 	"github.com/openimsdk/tools/db/mongoutil"
@@ -23,30 +24,83 @@ import (
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
+	"regexp"
 )
 
+// scanBatchSize is the number of users a cursor of Scan fetches at once.
+const scanBatchSize = 1000
+
+// NewUserMongo returns the users of db, its indexes are created by the migrations.
 func NewUserMongo(db *mongo.Database) (database.User, error) {
-	coll := db.Collection("user")
-	_, err := coll.Indexes().CreateOne(context.Background(), mongo.IndexModel{
-		Keys: bson.D{
-			{Key: "user_id", Value: 1},
-		},
-		Options: options.Index().SetUnique(true),
-	})
-	if err != nil {
-		return nil, errs.Wrap(err)
-	}
-	return &UserMgo{coll: coll}, nil
+	return &UserMgo{coll: db.Collection("user")}, nil
 }
 
 type UserMgo struct {
 	coll *mongo.Collection
 }
 
-func (u *UserMgo) Create(ctx context.Context, users []*model.User) error {
-	return mongoutil.InsertMany(ctx, u.coll, users)
+func (u *UserMgo) Create(ctx context.Context, users []*model.User) (err error) {
+	done := observe(u.coll, "insertMany")
+	defer func() { done(err) }()
+	if err := mongoutil.InsertMany(ctx, u.coll, users); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return errs.ErrDuplicateKey.WrapMsg(err.Error())
+		}
+		return err
+	}
+	return nil
 }
 
 func (u *UserMgo) Take(ctx context.Context, userID string) (user *model.User, err error) {
-	return mongoutil.FindOne[*model.User](ctx, u.coll, bson.M{"user_id": userID})
+	done := observe(u.coll, "findOne")
+	defer func() { done(err) }()
+	user, err = mongoutil.FindOne[*model.User](ctx, u.coll, bson.M{"user_id": userID})
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, errs.ErrRecordNotFound.WrapMsg("user not found", "userID", userID)
+	}
+	return user, err
+}
+
+func (u *UserMgo) Update(ctx context.Context, user *model.User) (err error) {
+	done := observe(u.coll, "updateOne")
+	defer func() { done(err) }()
+	res, err := u.coll.UpdateOne(ctx, bson.M{"user_id": user.UserID}, bson.M{"$set": bson.M{"nickname": user.Nickname}})
+	if err != nil {
+		return errs.WrapMsg(err, "update user failed", "userID", user.UserID)
+	}
+	if res.MatchedCount == 0 {
+		return errs.ErrRecordNotFound.WrapMsg("user not found", "userID", user.UserID)
+	}
+	return nil
+}
+
+func (u *UserMgo) Scan(ctx context.Context, filter *database.UserFilter, fn func(user *model.User) error) (err error) {
+	done := observe(u.coll, "find")
+	defer func() { done(err) }()
+	query := bson.M{}
+	if filter.UserIDPrefix != "" {
+		// Anchored, the regex is served by the user_id index.
+		query["user_id"] = bson.M{"$regex": "^" + regexp.QuoteMeta(filter.UserIDPrefix)}
+	}
+	if filter.Nickname != "" {
+		query["nickname"] = bson.M{"$regex": regexp.QuoteMeta(filter.Nickname)}
+	}
+	cursor, err := u.coll.Find(ctx, query, options.Find().SetSort(bson.D{{Key: "user_id", Value: 1}}).SetBatchSize(scanBatchSize))
+	if err != nil {
+		return errs.WrapMsg(err, "find users failed")
+	}
+	defer cursor.Close(ctx)
+	for cursor.Next(ctx) {
+		var user model.User
+		if err := cursor.Decode(&user); err != nil {
+			return errs.WrapMsg(err, "decode user failed")
+		}
+		if err := fn(&user); err != nil {
+			return err
+		}
+	}
+	if err := cursor.Err(); err != nil {
+		return errs.WrapMsg(err, "iterate users failed")
+	}
+	return nil
 }
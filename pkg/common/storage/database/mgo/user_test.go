@@ -0,0 +1,66 @@
+// Copyright © 2024 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mgo
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/openimsdk/openim-project-template/pkg/common/storage/database"
+	"github.com/openimsdk/openim-project-template/pkg/common/storage/database/databasetest"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// testMongoURI names the environment variable with the URI of the Mongo the tests run against, they are skipped without it.
+const testMongoURI = "OPENIM_TEST_MONGO_URI"
+
+// newTestDB returns a migrated database of its own, dropped when the test ends.
+func newTestDB(t *testing.T) *mongo.Database {
+	uri := os.Getenv(testMongoURI)
+	if uri == "" {
+		t.Skipf("%s is not set", testMongoURI)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(uri))
+	if err != nil {
+		t.Fatal(err)
+	}
+	db := client.Database(fmt.Sprintf("openim_test_%d", time.Now().UnixNano()))
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		defer cancel()
+		_ = db.Drop(ctx)
+		_ = client.Disconnect(ctx)
+	})
+	if _, err := NewMigrator(db).Up(ctx, 0); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func TestUser(t *testing.T) {
+	databasetest.TestUser(t, func(t *testing.T) database.User {
+		db, err := NewUserMongo(newTestDB(t))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return db
+	})
+}
@@ -0,0 +1,331 @@
+// Copyright © 2024 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mgo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/openimsdk/openim-project-template/pkg/common/storage/database"
+	"github.com/openimsdk/openim-project-template/pkg/common/storage/model"
+	"github.com/openimsdk/tools/errs"
+	"github.com/openimsdk/tools/log"
+	"github.com/openimsdk/tools/mcontext"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	userStream             = "user"
+	outboxCollection       = "outbox"
+	watchRetryInterval     = 5 * time.Second
+	watchTokenSaveInterval = 10 * time.Second
+	defaultWatchLeaseTTL   = 30 * time.Second
+)
+
+// Server error codes of change streams.
+const (
+	// changeStreamNotSupportedCode is returned when watching a standalone server.
+	changeStreamNotSupportedCode = 40573
+	invalidResumeTokenCode       = 260
+	changeStreamFatalErrorCode   = 280
+	changeStreamHistoryLostCode  = 286
+)
+
+// UserWatcher follows the change stream of the user collection and passes every change to a handler,
+// including the changes written around the controller. The outbox inserts are followed as well, to tell
+// the changes the controller published. The position is saved after each change so a
+// restarted watcher resumes after the last handled one, changes are then handled at least once.
+// Only the instance holding the lease watches.
+type UserWatcher struct {
+	db       *mongo.Database
+	coll     *mongo.Collection
+	tokens   database.ChangeStreamToken
+	handler  func(ctx context.Context, change *model.UserChange) error
+	owner    string
+	leaseTTL time.Duration
+
+	stopOnce sync.Once
+	ctx      context.Context
+	cancel   context.CancelFunc
+	done     chan struct{}
+}
+
+// NewUserWatcher returns a watcher passing the changes of the user collection to handler, it does nothing until started.
+// A handler error makes the change be handled again.
+func NewUserWatcher(db *mongo.Database, tokens database.ChangeStreamToken, leaseTTL time.Duration, handler func(ctx context.Context, change *model.UserChange) error) *UserWatcher {
+	if leaseTTL <= 0 {
+		leaseTTL = defaultWatchLeaseTTL
+	}
+	host, _ := os.Hostname()
+	ctx, cancel := context.WithCancel(mcontext.NewCtx("user_watcher"))
+	return &UserWatcher{
+		db:       db,
+		coll:     db.Collection("user"),
+		tokens:   tokens,
+		handler:  handler,
+		owner:    fmt.Sprintf("%s-%d-%s", host, os.Getpid(), primitive.NewObjectID().Hex()),
+		leaseTTL: leaseTTL,
+		ctx:      ctx,
+		cancel:   cancel,
+		done:     make(chan struct{}),
+	}
+}
+
+// Start watches in the background until Stop. Without a replica set it logs a warning and gives up,
+// the cache entries are then only refreshed when they expire.
+func (w *UserWatcher) Start() {
+	go w.run()
+}
+
+// Stop stops watching and gives up the lease, the saved position lets the next watcher resume.
+func (w *UserWatcher) Stop(ctx context.Context) error {
+	w.stopOnce.Do(w.cancel)
+	select {
+	case <-w.done:
+	case <-ctx.Done():
+		return errs.WrapMsg(ctx.Err(), "user watcher not stopped")
+	}
+	return w.tokens.ReleaseLease(ctx, userStream, w.owner)
+}
+
+func (w *UserWatcher) run() {
+	defer close(w.done)
+	for {
+		ok, err := w.tokens.AcquireLease(w.ctx, userStream, w.owner, w.leaseTTL)
+		switch {
+		case w.ctx.Err() != nil:
+			return
+		case err != nil:
+			log.ZError(w.ctx, "acquire user watcher lease failed", err)
+		case ok:
+			err := w.watch(w.ctx)
+			if w.ctx.Err() != nil {
+				return
+			}
+			if hasErrorCode(err, changeStreamNotSupportedCode) {
+				log.ZWarn(w.ctx, "change streams need mongo to run as a replica set, user cache entries are only refreshed on expiry", err)
+				return
+			}
+			log.ZError(w.ctx, "watch user changes failed", err)
+		}
+		select {
+		case <-w.ctx.Done():
+			return
+		case <-time.After(watchRetryInterval):
+		}
+	}
+}
+
+// watch handles the changes until ctx is done, an error occurs or the lease is lost.
+func (w *UserWatcher) watch(ctx context.Context) error {
+	token, err := w.tokens.Get(ctx, userStream)
+	if err != nil {
+		return err
+	}
+	opts := options.ChangeStream().
+		SetFullDocument(options.UpdateLookup).
+		SetFullDocumentBeforeChange(options.WhenAvailable).
+		SetMaxAwaitTime(time.Second)
+	if token != nil {
+		opts.SetResumeAfter(bson.Raw(token))
+	}
+	// The outbox inserts tell which transactions of the user collection were written by the controller.
+	pipeline := mongo.Pipeline{{{Key: "$match", Value: bson.M{"$or": bson.A{
+		bson.M{"ns.coll": w.coll.Name(), "operationType": bson.M{"$in": bson.A{"insert", "update", "replace", "delete"}}},
+		bson.M{"ns.coll": outboxCollection, "operationType": "insert"},
+	}}}}}
+	cs, err := w.db.Watch(ctx, pipeline, opts)
+	if err != nil {
+		if token != nil && hasErrorCode(err, invalidResumeTokenCode, changeStreamFatalErrorCode, changeStreamHistoryLostCode) {
+			log.ZError(ctx, "user change stream can not resume, the changes since are missed, watching from now", err)
+			if err := w.tokens.Save(ctx, userStream, nil); err != nil {
+				return err
+			}
+			return w.watch(ctx)
+		}
+		return errs.WrapMsg(err, "watch user collection failed")
+	}
+	defer cs.Close(context.Background())
+	var txn txnChanges
+	renewed, saved := time.Now(), time.Now()
+	for {
+		if cs.TryNext(ctx) {
+			key, change, outbox, err := w.decode(ctx, cs)
+			if err != nil {
+				return err
+			}
+			if err := w.handle(ctx, txn.add(key, change, outbox)); err != nil {
+				return err
+			}
+			// The position is kept before a transaction until all its changes are handled.
+			if !txn.pending() {
+				if err := w.tokens.Save(ctx, userStream, cs.ResumeToken()); err != nil {
+					return err
+				}
+				saved = time.Now()
+			}
+		} else if err := cs.Err(); err != nil {
+			return errs.WrapMsg(err, "read user change stream failed")
+		} else if ctx.Err() != nil {
+			return ctx.Err()
+		} else if txn.pending() || time.Since(saved) >= watchTokenSaveInterval {
+			// The stream has no further event of the pending transaction. While idle, the position keeps
+			// moving so that it does not fall out of the oplog.
+			if err := w.handle(ctx, txn.flush()); err != nil {
+				return err
+			}
+			if err := w.tokens.Save(ctx, userStream, cs.ResumeToken()); err != nil {
+				return err
+			}
+			saved = time.Now()
+		}
+		if time.Since(renewed) >= w.leaseTTL/3 {
+			ok, err := w.tokens.AcquireLease(ctx, userStream, w.owner, w.leaseTTL)
+			if err != nil {
+				return err
+			}
+			if !ok {
+				return errs.New("user watcher lease lost").Wrap()
+			}
+			renewed = time.Now()
+		}
+	}
+}
+
+func (w *UserWatcher) handle(ctx context.Context, changes []*model.UserChange) error {
+	for _, change := range changes {
+		if err := w.handler(ctx, change); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// decode returns the transaction key of the current event, empty outside a transaction, and either its user
+// change or whether it is an outbox insert. The change is nil for an outbox insert or a change without document.
+func (w *UserWatcher) decode(ctx context.Context, cs *mongo.ChangeStream) (string, *model.UserChange, bool, error) {
+	var event struct {
+		OperationType string `bson:"operationType"`
+		NS            struct {
+			Coll string `bson:"coll"`
+		} `bson:"ns"`
+		FullDocument             bson.Raw `bson:"fullDocument"`
+		FullDocumentBeforeChange bson.Raw `bson:"fullDocumentBeforeChange"`
+		LSID                     bson.Raw `bson:"lsid"`
+		TxnNumber                *int64   `bson:"txnNumber"`
+	}
+	if err := cs.Decode(&event); err != nil {
+		return "", nil, false, errs.WrapMsg(err, "decode user change failed")
+	}
+	var key string
+	if event.TxnNumber != nil {
+		key = fmt.Sprintf("%x-%d", []byte(event.LSID), *event.TxnNumber)
+	}
+	if event.NS.Coll == outboxCollection {
+		return key, nil, true, nil
+	}
+	change := &model.UserChange{}
+	switch event.OperationType {
+	case "insert":
+		change.Operation = model.ChangeInsert
+	case "delete":
+		change.Operation = model.ChangeDelete
+	default:
+		change.Operation = model.ChangeUpdate
+	}
+	switch {
+	case event.FullDocument != nil:
+		change.User = &model.User{}
+		if err := bson.Unmarshal(event.FullDocument, change.User); err != nil {
+			return "", nil, false, errs.WrapMsg(err, "decode changed user failed")
+		}
+		change.UserID = change.User.UserID
+	case event.FullDocumentBeforeChange != nil:
+		var user model.User
+		if err := bson.Unmarshal(event.FullDocumentBeforeChange, &user); err != nil {
+			return "", nil, false, errs.WrapMsg(err, "decode deleted user failed")
+		}
+		change.UserID = user.UserID
+	default:
+		log.ZWarn(ctx, "user change without document is skipped", nil, "operation", event.OperationType)
+		return key, nil, false, nil
+	}
+	return key, change, false, nil
+}
+
+// txnChanges holds back the user changes of a transaction until the stream has passed its last event, the
+// change stream reports a transaction as consecutive events sharing its lsid and txnNumber. The changes of a
+// transaction that also inserted into the outbox were written by the controller and are marked published.
+type txnChanges struct {
+	key       string
+	changes   []*model.UserChange
+	published bool
+}
+
+// add takes the next event of the stream and returns the changes ready to be handled: those of the pending
+// transaction the event does not belong to, and the change of the event when it is not in a transaction.
+func (t *txnChanges) add(key string, change *model.UserChange, outbox bool) []*model.UserChange {
+	var ready []*model.UserChange
+	if t.key != "" && t.key != key {
+		ready = t.flush()
+	}
+	if key == "" {
+		if change != nil {
+			ready = append(ready, change)
+		}
+		return ready
+	}
+	t.key = key
+	t.published = t.published || outbox
+	if change != nil {
+		t.changes = append(t.changes, change)
+	}
+	return ready
+}
+
+// flush returns the changes of the pending transaction, the stream has no further event of it.
+func (t *txnChanges) flush() []*model.UserChange {
+	changes := t.changes
+	for _, change := range changes {
+		change.Published = t.published
+	}
+	*t = txnChanges{}
+	return changes
+}
+
+// pending reports whether changes of a transaction wait for its last event.
+func (t *txnChanges) pending() bool {
+	return t.key != ""
+}
+
+func hasErrorCode(err error, codes ...int) bool {
+	var se mongo.ServerError
+	if !errors.As(err, &se) {
+		return false
+	}
+	for _, code := range codes {
+		if se.HasErrorCode(code) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,64 @@
+// Copyright © 2024 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mgo
+
+import (
+	"testing"
+
+	"github.com/openimsdk/openim-project-template/pkg/common/storage/model"
+)
+
+func TestTxnChanges(t *testing.T) {
+	var txn txnChanges
+	manual := &model.UserChange{UserID: "u1"}
+	if ready := txn.add("a-1", manual, false); len(ready) != 0 {
+		t.Fatalf("got %v before the transaction ended", ready)
+	}
+	if !txn.pending() {
+		t.Fatal("transaction not pending")
+	}
+	// The next transaction writes a user with its outbox event, ending the first one.
+	controller := &model.UserChange{UserID: "u2"}
+	ready := txn.add("a-2", controller, false)
+	if len(ready) != 1 || ready[0] != manual || manual.Published {
+		t.Fatalf("got %v, want the unpublished change of the first transaction", ready)
+	}
+	if ready := txn.add("a-2", nil, true); len(ready) != 0 {
+		t.Fatalf("got %v before the transaction ended", ready)
+	}
+	// A change outside a transaction ends the second one and is ready at once.
+	direct := &model.UserChange{UserID: "u3"}
+	ready = txn.add("", direct, false)
+	if len(ready) != 2 || ready[0] != controller || !controller.Published || ready[1] != direct || direct.Published {
+		t.Fatalf("got %v, want the published change of the second transaction and the direct change", ready)
+	}
+	if txn.pending() {
+		t.Fatal("transaction still pending")
+	}
+}
+
+func TestTxnChangesFlush(t *testing.T) {
+	var txn txnChanges
+	change := &model.UserChange{UserID: "u1"}
+	txn.add("a-1", nil, true)
+	txn.add("a-1", change, false)
+	ready := txn.flush()
+	if len(ready) != 1 || !change.Published {
+		t.Fatalf("got %v, want the published change", ready)
+	}
+	if txn.pending() || len(txn.flush()) != 0 {
+		t.Fatal("flush kept the transaction")
+	}
+}
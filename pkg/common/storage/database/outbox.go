@@ -0,0 +1,43 @@
+// Copyright © 2024 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/openimsdk/openim-project-template/pkg/common/storage/model"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type Outbox interface {
+	// Create inserts events after assigning the next sequence of their key to each. Written in the transaction
+	// of the change, the sequences of a key follow the order the transactions commit.
+	Create(ctx context.Context, events []*model.OutboxEvent) (err error)
+	// FindPending returns up to limit events not delivered yet and not dead, oldest first. Only the events
+	// after the given ID are returned unless it is zero, and the events of excludeKeys are skipped.
+	FindPending(ctx context.Context, after primitive.ObjectID, excludeKeys []string, limit int) (events []*model.OutboxEvent, err error)
+	// FirstPending returns the lowest sequence of the events not dead of each key of keys, keys without
+	// such events are left out.
+	FirstPending(ctx context.Context, keys []string) (seqs map[string]int64, err error)
+	// Delete removes the events delivered to every sink.
+	Delete(ctx context.Context, ids []primitive.ObjectID) (err error)
+	// UpdateAttempt records a failed delivery of event, with its Delivered, Attempts, NextAttemptTime, LastError and Dead.
+	UpdateAttempt(ctx context.Context, event *model.OutboxEvent) (err error)
+	// AcquireLease takes or renews the relay lease for owner until ttl elapses, it reports whether owner holds it.
+	AcquireLease(ctx context.Context, owner string, ttl time.Duration) (ok bool, err error)
+	// ReleaseLease gives up the lease held by owner.
+	ReleaseLease(ctx context.Context, owner string) (err error)
+}
@@ -8,4 +8,16 @@ import (
 type User interface {
 	Create(ctx context.Context, users []*model.User) (err error)
 	Take(ctx context.Context, userID string) (user *model.User, err error)
+	// Update replaces the nickname of the user, errs.ErrRecordNotFound when it does not exist.
+	Update(ctx context.Context, user *model.User) (err error)
+	// Scan calls fn with the users matching filter in userID order, until fn returns an error.
+	Scan(ctx context.Context, filter *UserFilter, fn func(user *model.User) error) (err error)
+}
+
+// UserFilter selects users, its zero value matches every user.
+type UserFilter struct {
+	// UserIDPrefix matches the users whose userID starts with it.
+	UserIDPrefix string
+	// Nickname matches the users whose nickname contains it.
+	Nickname string
 }
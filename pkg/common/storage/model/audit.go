@@ -0,0 +1,29 @@
+// Copyright © 2024 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import "time"
+
+// AuditLog records an operation changing state, who made it and its result.
+type AuditLog struct {
+	ActorUserID string    `bson:"actor_user_id" json:"actorUserID"`
+	OperationID string    `bson:"operation_id" json:"operationID"`
+	Action      string    `bson:"action" json:"action"`
+	TargetIDs   []string  `bson:"target_ids" json:"targetIDs"`
+	Request     string    `bson:"request" json:"request"`
+	Code        int       `bson:"code" json:"code"`
+	IP          string    `bson:"ip" json:"ip"`
+	CreateTime  time.Time `bson:"create_time" json:"createTime"`
+}
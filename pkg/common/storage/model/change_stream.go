@@ -0,0 +1,33 @@
+// Copyright © 2024 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+// Operations of a UserChange.
+const (
+	ChangeInsert = "insert"
+	ChangeUpdate = "update"
+	ChangeDelete = "delete"
+)
+
+// UserChange is a change of the user collection seen by its change stream, whatever made it.
+type UserChange struct {
+	Operation string
+	UserID    string
+	// User is the document after the change, nil for a deletion.
+	User *User
+	// Published tells whether the transaction of the change also wrote outbox events, as the controller
+	// writes its changes with the events publishing them.
+	Published bool
+}
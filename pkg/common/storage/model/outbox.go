@@ -0,0 +1,43 @@
+// Copyright © 2024 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// OutboxEvent is a domain event written with the change it describes and delivered to the sinks by the relay.
+type OutboxEvent struct {
+	ID primitive.ObjectID `bson:"_id"`
+	// Type names the event, e.g. user.registered.
+	Type string `bson:"type"`
+	// Key orders the delivery, events of a key are delivered in the order of Seq.
+	Key string `bson:"key"`
+	// Seq numbers the events of Key from 1 in the order their transactions committed, it is assigned by
+	// Outbox.Create. Zero for the events written before sequences, which are delivered in the order of their ID.
+	Seq int64 `bson:"seq"`
+	// Payload is the JSON body of the event.
+	Payload    string    `bson:"payload"`
+	CreateTime time.Time `bson:"create_time"`
+	// Delivered lists the sinks the event was sent to, it is not sent to them again on retry.
+	Delivered       []string  `bson:"delivered"`
+	Attempts        int       `bson:"attempts"`
+	NextAttemptTime time.Time `bson:"next_attempt_time"`
+	LastError       string    `bson:"last_error"`
+	// Dead is set once the attempts are exhausted, the event is kept for inspection and no longer delivered.
+	Dead bool `bson:"dead"`
+}
@@ -0,0 +1,94 @@
+// Copyright © 2024 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tracing
+
+import (
+	"context"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/openimsdk/tools/mcontext"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/stats"
+)
+
+// OperationIDKey is the span attribute carrying the operationID of the request.
+const OperationIDKey = attribute.Key("openim.operation_id")
+
+// GinMiddlewares returns the middlewares creating a span per route. They must be installed
+// after mw.GinParseOperationID, and the engine must have ContextWithFallback set so that
+// handlers passing the gin.Context to RPC clients propagate the span.
+func GinMiddlewares(serviceName string) []gin.HandlerFunc {
+	return []gin.HandlerFunc{
+		otelgin.Middleware(serviceName),
+		func(c *gin.Context) {
+			setOperationID(c.Request.Context(), mcontext.GetOperationID(c))
+			c.Next()
+		},
+	}
+}
+
+// ServerOptions returns the gRPC server options creating a span per call, except for health checks.
+func ServerOptions() []grpc.ServerOption {
+	return []grpc.ServerOption{
+		grpc.StatsHandler(skipHealth{Handler: otelgrpc.NewServerHandler()}),
+		grpc.ChainUnaryInterceptor(func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+			setOperationID(ctx, mcontext.GetOperationID(ctx))
+			return handler(ctx, req)
+		}),
+		grpc.ChainStreamInterceptor(func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+			setOperationID(ss.Context(), mcontext.GetOperationID(ss.Context()))
+			return handler(srv, ss)
+		}),
+	}
+}
+
+// DialOption returns the gRPC dial option creating a span per client call, except for health checks.
+func DialOption() grpc.DialOption {
+	return grpc.WithStatsHandler(skipHealth{Handler: otelgrpc.NewClientHandler()})
+}
+
+func setOperationID(ctx context.Context, operationID string) {
+	if operationID == "" {
+		return
+	}
+	trace.SpanFromContext(ctx).SetAttributes(OperationIDKey.String(operationID))
+}
+
+type skipHealthKey struct{}
+
+// skipHealth leaves out the calls of the health service, probed every few seconds.
+type skipHealth struct {
+	stats.Handler
+}
+
+func (h skipHealth) TagRPC(ctx context.Context, info *stats.RPCTagInfo) context.Context {
+	if strings.HasPrefix(info.FullMethodName, "/"+grpc_health_v1.Health_ServiceDesc.ServiceName+"/") {
+		return context.WithValue(ctx, skipHealthKey{}, struct{}{})
+	}
+	return h.Handler.TagRPC(ctx, info)
+}
+
+func (h skipHealth) HandleRPC(ctx context.Context, s stats.RPCStats) {
+	if ctx.Value(skipHealthKey{}) != nil {
+		return
+	}
+	h.Handler.HandleRPC(ctx, s)
+}
@@ -0,0 +1,118 @@
+// Copyright © 2024 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tracing
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/openimsdk/openim-project-template/pkg/common/config"
+	"github.com/openimsdk/tools/errs"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+)
+
+const (
+	ExporterOTLP   = "otlp"
+	ExporterStdout = "stdout"
+	ExporterFile   = "file"
+)
+
+// Init installs the global tracer provider of the process named serviceName.
+// The returned function flushes and stops the exporter, it is a no-op when tracing is disabled.
+func Init(ctx context.Context, conf *config.Tracing, serviceName string) (func(ctx context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	if !conf.Enable {
+		return func(ctx context.Context) error { return nil }, nil
+	}
+	exporter, closeFn, err := newExporter(ctx, conf)
+	if err != nil {
+		return nil, err
+	}
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(semconv.SchemaURL,
+		semconv.ServiceName(serviceName),
+		semconv.ServiceVersion(config.Version),
+	))
+	if err != nil {
+		return nil, errs.WrapMsg(err, "tracing resource failed")
+	}
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(sampleRatio(conf, serviceName)))),
+	)
+	otel.SetTracerProvider(provider)
+	return func(ctx context.Context) error {
+		err := provider.Shutdown(ctx)
+		if closeFn != nil {
+			if closeErr := closeFn(); err == nil {
+				err = closeErr
+			}
+		}
+		return err
+	}, nil
+}
+
+// sampleRatio returns the ratio configured for serviceName, viper lowercases the keys of services.
+func sampleRatio(conf *config.Tracing, serviceName string) float64 {
+	if ratio, ok := conf.Services[strings.ToLower(serviceName)]; ok {
+		return ratio
+	}
+	return conf.SampleRatio
+}
+
+func newExporter(ctx context.Context, conf *config.Tracing) (sdktrace.SpanExporter, func() error, error) {
+	switch conf.Exporter {
+	case ExporterOTLP, "":
+		opts := []otlptracegrpc.Option{otlptracegrpc.WithEndpoint(conf.Endpoint)}
+		if conf.Insecure {
+			opts = append(opts, otlptracegrpc.WithInsecure())
+		}
+		exporter, err := otlptracegrpc.New(ctx, opts...)
+		if err != nil {
+			return nil, nil, errs.WrapMsg(err, "otlp exporter failed", "endpoint", conf.Endpoint)
+		}
+		return exporter, nil, nil
+	case ExporterStdout:
+		exporter, err := stdouttrace.New(stdouttrace.WithPrettyPrint())
+		if err != nil {
+			return nil, nil, errs.WrapMsg(err, "stdout exporter failed")
+		}
+		return exporter, nil, nil
+	case ExporterFile:
+		if err := os.MkdirAll(filepath.Dir(conf.FilePath), 0755); err != nil {
+			return nil, nil, errs.WrapMsg(err, "create trace directory failed", "filePath", conf.FilePath)
+		}
+		file, err := os.OpenFile(conf.FilePath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			return nil, nil, errs.WrapMsg(err, "open trace file failed", "filePath", conf.FilePath)
+		}
+		exporter, err := stdouttrace.New(stdouttrace.WithWriter(file))
+		if err != nil {
+			file.Close()
+			return nil, nil, errs.WrapMsg(err, "file exporter failed")
+		}
+		return exporter, file.Close, nil
+	default:
+		return nil, nil, errs.New("unsupported trace exporter", "exporter", conf.Exporter).Wrap()
+	}
+}
@@ -0,0 +1,64 @@
+// Copyright © 2024 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package usercsv
+
+import (
+	"encoding/csv"
+	"io"
+	"strings"
+
+	pbuser "github.com/openimsdk/openim-project-template/pkg/protocol/user"
+	"github.com/openimsdk/tools/errs"
+)
+
+// Reader reads the users of a CSV file of userID,nickname rows, the nickname column being optional.
+// A first row starting with userID is a header and skipped.
+type Reader struct {
+	csv  *csv.Reader
+	line int
+}
+
+// NewReader returns a reader of the users of r.
+func NewReader(r io.Reader) *Reader {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	return &Reader{csv: reader}
+}
+
+// Read returns the user of the next row, io.EOF at the end of the file.
+func (r *Reader) Read() (*pbuser.UserInfo, error) {
+	for {
+		record, err := r.csv.Read()
+		if err == io.EOF {
+			return nil, err
+		}
+		if err != nil {
+			return nil, errs.WrapMsg(err, "read csv failed")
+		}
+		r.line++
+		if r.line == 1 && strings.EqualFold(record[0], "userID") {
+			continue
+		}
+		if len(record) > 2 {
+			return nil, errs.New("too many columns", "line", r.line).Wrap()
+		}
+		user := &pbuser.UserInfo{UserID: record[0]}
+		if len(record) == 2 {
+			user.Nickname = record[1]
+		}
+		return user, nil
+	}
+}
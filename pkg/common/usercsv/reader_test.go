@@ -0,0 +1,62 @@
+// Copyright © 2024 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package usercsv
+
+import (
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestReader(t *testing.T) {
+	r := NewReader(strings.NewReader("userID,nickname\nu1, one\nu2\n"))
+	var got []string
+	for {
+		user, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, user.UserID+":"+user.Nickname)
+	}
+	if want := "u1:one u2:"; strings.Join(got, " ") != want {
+		t.Errorf("got %v, want %s", got, want)
+	}
+}
+
+func TestReaderHeaderOnlyFirst(t *testing.T) {
+	r := NewReader(strings.NewReader("u1\nuserID\n"))
+	for _, want := range []string{"u1", "userID"} {
+		user, err := r.Read()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if user.UserID != want {
+			t.Errorf("got %s, want %s", user.UserID, want)
+		}
+	}
+}
+
+func TestReaderTooManyColumns(t *testing.T) {
+	r := NewReader(strings.NewReader("u1,one\nu2,two,extra\n"))
+	if _, err := r.Read(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Read(); err == nil || !strings.Contains(err.Error(), "too many columns") {
+		t.Fatalf("got %v, want too many columns", err)
+	}
+}
@@ -0,0 +1,199 @@
+// Copyright © 2024 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/openimsdk/openim-project-template/pkg/callbackstruct"
+	"github.com/openimsdk/openim-project-template/pkg/common/config"
+	"github.com/openimsdk/openim-project-template/pkg/common/prommetrics"
+	"github.com/openimsdk/openim-project-template/pkg/common/servererrs"
+	"github.com/openimsdk/tools/errs"
+	"github.com/openimsdk/tools/log"
+	"github.com/openimsdk/tools/mcontext"
+)
+
+const (
+	defaultTimeout = 5 * time.Second
+	asyncQueueSize = 1000
+	asyncWorkers   = 4
+	maxRespSize    = 1 << 20
+)
+
+// Client calls the webhooks configured in webhooks.yml. Services call it from hooks around their operations:
+// SyncPost before the operation, which the answer can reject or rewrite, and AsyncPost after it.
+type Client struct {
+	url    string
+	secret string
+	client *http.Client
+
+	lock   sync.RWMutex
+	closed bool
+	queue  chan func()
+	wg     sync.WaitGroup
+}
+
+// NewWebhookClient returns a client posting to the webhooks of conf, it must be closed to send the pending notifications.
+func NewWebhookClient(conf *config.Webhooks) *Client {
+	c := &Client{
+		url:    strings.TrimRight(conf.URL, "/"),
+		secret: conf.Secret,
+		client: &http.Client{},
+		queue:  make(chan func(), asyncQueueSize),
+	}
+	c.wg.Add(asyncWorkers)
+	for i := 0; i < asyncWorkers; i++ {
+		go func() {
+			defer c.wg.Done()
+			for task := range c.queue {
+				task()
+			}
+		}()
+	}
+	return c
+}
+
+// SyncPost posts req to the command webhook and decodes the answer into resp when before is enabled.
+// It returns the rejection carried by resp, or the failure of the call unless before.FailedContinue is set.
+func (c *Client) SyncPost(ctx context.Context, command string, req callbackstruct.CallbackReq, resp callbackstruct.CallbackResp, before *config.BeforeConfig) error {
+	if !before.Enable {
+		return nil
+	}
+	if err := c.post(ctx, command, req, resp, before.Timeout); err != nil {
+		prommetrics.WebhookRequestCounter.WithLabelValues(command, prommetrics.WebhookFailed).Inc()
+		if before.FailedContinue {
+			log.ZWarn(ctx, "webhook failed, continue", err, "command", command)
+			return nil
+		}
+		return servererrs.ErrCallback.WrapMsg("webhook failed", "command", command, "err", err.Error())
+	}
+	if err := resp.Parse(); err != nil {
+		prommetrics.WebhookRequestCounter.WithLabelValues(command, prommetrics.WebhookRejected).Inc()
+		return err
+	}
+	prommetrics.WebhookRequestCounter.WithLabelValues(command, prommetrics.WebhookSuccess).Inc()
+	return nil
+}
+
+// AsyncPost queues the post of req to the command webhook when after is enabled, the answer is only logged.
+// Notifications are dropped when the queue is full.
+func (c *Client) AsyncPost(ctx context.Context, command string, req callbackstruct.CallbackReq, resp callbackstruct.CallbackResp, after *config.AfterConfig) {
+	if !after.Enable {
+		return
+	}
+	ctx = context.WithoutCancel(ctx)
+	task := func() {
+		if err := c.post(ctx, command, req, resp, after.Timeout); err != nil {
+			prommetrics.WebhookRequestCounter.WithLabelValues(command, prommetrics.WebhookFailed).Inc()
+			log.ZWarn(ctx, "webhook failed", err, "command", command)
+			return
+		}
+		if err := resp.Parse(); err != nil {
+			prommetrics.WebhookRequestCounter.WithLabelValues(command, prommetrics.WebhookRejected).Inc()
+			log.ZWarn(ctx, "webhook answered an error", err, "command", command)
+			return
+		}
+		prommetrics.WebhookRequestCounter.WithLabelValues(command, prommetrics.WebhookSuccess).Inc()
+	}
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	if c.closed {
+		log.ZWarn(ctx, "webhook client is closed", nil, "command", command)
+		prommetrics.WebhookRequestCounter.WithLabelValues(command, prommetrics.WebhookDropped).Inc()
+		return
+	}
+	select {
+	case c.queue <- task:
+	default:
+		log.ZWarn(ctx, "webhook queue is full", nil, "command", command)
+		prommetrics.WebhookRequestCounter.WithLabelValues(command, prommetrics.WebhookDropped).Inc()
+	}
+}
+
+// Close stops queueing notifications and waits for the queued ones to be sent.
+func (c *Client) Close(ctx context.Context) error {
+	c.lock.Lock()
+	if !c.closed {
+		c.closed = true
+		close(c.queue)
+	}
+	c.lock.Unlock()
+	done := make(chan struct{})
+	go func() {
+		c.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return errs.WrapMsg(ctx.Err(), "webhook notifications not sent")
+	}
+}
+
+// post posts req to the command webhook and decodes the answer into resp.
+func (c *Client) post(ctx context.Context, command string, req callbackstruct.CallbackReq, resp callbackstruct.CallbackResp, timeout int) error {
+	start := time.Now()
+	defer func() {
+		prommetrics.WebhookRequestDuration.WithLabelValues(command).Observe(time.Since(start).Seconds())
+	}()
+	body, err := json.Marshal(req)
+	if err != nil {
+		return errs.WrapMsg(err, "marshal webhook request failed")
+	}
+	d := defaultTimeout
+	if timeout > 0 {
+		d = time.Duration(timeout) * time.Second
+	}
+	ctx, cancel := context.WithTimeout(ctx, d)
+	defer cancel()
+	url := c.url + "/" + command
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return errs.WrapMsg(err, "new webhook request failed", "url", url)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("operationID", mcontext.GetOperationID(ctx))
+	if c.secret != "" {
+		timestamp := time.Now().Unix()
+		httpReq.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+		httpReq.Header.Set(HeaderSignature, Sign(c.secret, timestamp, body))
+	}
+	httpResp, err := c.client.Do(httpReq)
+	if err != nil {
+		return errs.WrapMsg(err, "post webhook failed", "url", url)
+	}
+	defer httpResp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(httpResp.Body, maxRespSize))
+	if err != nil {
+		return errs.WrapMsg(err, "read webhook response failed", "url", url)
+	}
+	if httpResp.StatusCode != http.StatusOK {
+		return errs.New("webhook responded with an error", "url", url, "status", httpResp.StatusCode).Wrap()
+	}
+	if err := json.Unmarshal(data, resp); err != nil {
+		return errs.WrapMsg(err, "unmarshal webhook response failed", "url", url, "body", string(data))
+	}
+	return nil
+}
@@ -0,0 +1,133 @@
+// Copyright © 2024 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/openimsdk/openim-project-template/pkg/callbackstruct"
+	"github.com/openimsdk/openim-project-template/pkg/common/config"
+	"github.com/openimsdk/openim-project-template/pkg/common/servererrs"
+)
+
+func newTestClient(t *testing.T, conf *config.Webhooks, handler http.HandlerFunc) *Client {
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	conf.URL = srv.URL + "/callback"
+	c := NewWebhookClient(conf)
+	t.Cleanup(func() { _ = c.Close(context.Background()) })
+	return c
+}
+
+func registerReq() *callbackstruct.CallbackBeforeUserRegisterReq {
+	return &callbackstruct.CallbackBeforeUserRegisterReq{
+		CommonCallbackReq: callbackstruct.CommonCallbackReq{CallbackCommand: callbackstruct.CallbackBeforeUserRegisterCommand},
+	}
+}
+
+func TestSyncPostSigns(t *testing.T) {
+	c := newTestClient(t, &config.Webhooks{Secret: "secret"}, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/callback/"+callbackstruct.CallbackBeforeUserRegisterCommand {
+			t.Errorf("path %s", r.URL.Path)
+		}
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			t.Error(err)
+		}
+		if !Verify("secret", r.Header.Get(HeaderTimestamp), body, r.Header.Get(HeaderSignature), time.Minute) {
+			t.Error("request not signed")
+		}
+		_, _ = w.Write([]byte(`{}`))
+	})
+	err := c.SyncPost(context.Background(), callbackstruct.CallbackBeforeUserRegisterCommand, registerReq(),
+		&callbackstruct.CallbackBeforeUserRegisterResp{}, &config.BeforeConfig{Enable: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSyncPostRejected(t *testing.T) {
+	c := newTestClient(t, &config.Webhooks{}, func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(&callbackstruct.CommonCallbackResp{ActionCode: 1, ErrMsg: "denied"})
+	})
+	// FailedContinue only lets failed calls through, not rejections.
+	err := c.SyncPost(context.Background(), callbackstruct.CallbackBeforeUserRegisterCommand, registerReq(),
+		&callbackstruct.CallbackBeforeUserRegisterResp{}, &config.BeforeConfig{Enable: true, FailedContinue: true})
+	if !servererrs.ErrCallback.Is(err) {
+		t.Fatalf("got %v, want the rejection", err)
+	}
+}
+
+func TestSyncPostFailedContinue(t *testing.T) {
+	c := newTestClient(t, &config.Webhooks{}, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+	before := &config.BeforeConfig{Enable: true}
+	err := c.SyncPost(context.Background(), callbackstruct.CallbackBeforeUserRegisterCommand, registerReq(),
+		&callbackstruct.CallbackBeforeUserRegisterResp{}, before)
+	if !servererrs.ErrCallback.Is(err) {
+		t.Fatalf("got %v, want the failure denied", err)
+	}
+	before.FailedContinue = true
+	err = c.SyncPost(context.Background(), callbackstruct.CallbackBeforeUserRegisterCommand, registerReq(),
+		&callbackstruct.CallbackBeforeUserRegisterResp{}, before)
+	if err != nil {
+		t.Fatalf("got %v, want the failure continued", err)
+	}
+}
+
+func TestSyncPostTimeout(t *testing.T) {
+	release := make(chan struct{})
+	c := newTestClient(t, &config.Webhooks{}, func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	})
+	defer close(release)
+	start := time.Now()
+	err := c.SyncPost(context.Background(), callbackstruct.CallbackBeforeUserRegisterCommand, registerReq(),
+		&callbackstruct.CallbackBeforeUserRegisterResp{}, &config.BeforeConfig{Enable: true, Timeout: 1})
+	if !servererrs.ErrCallback.Is(err) {
+		t.Fatalf("got %v, want the timeout", err)
+	}
+	if elapsed := time.Since(start); elapsed > 3*time.Second {
+		t.Fatalf("timed out after %s, want 1s", elapsed)
+	}
+}
+
+func TestAsyncPostSentOnClose(t *testing.T) {
+	received := make(chan struct{}, 1)
+	c := newTestClient(t, &config.Webhooks{}, func(w http.ResponseWriter, r *http.Request) {
+		received <- struct{}{}
+		_, _ = w.Write([]byte(`{}`))
+	})
+	c.AsyncPost(context.Background(), callbackstruct.CallbackAfterUserRegisterCommand, &callbackstruct.CallbackAfterUserRegisterReq{},
+		&callbackstruct.CallbackAfterUserRegisterResp{}, &config.AfterConfig{Enable: true})
+	if err := c.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-received:
+	default:
+		t.Fatal("notification not sent before Close returned")
+	}
+}
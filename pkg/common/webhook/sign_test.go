@@ -0,0 +1,50 @@
+// Copyright © 2024 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhook
+
+import (
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestVerify(t *testing.T) {
+	body := []byte(`{"userID":"u1"}`)
+	now := time.Now().Unix()
+	signature := Sign("secret", now, body)
+	timestamp := strconv.FormatInt(now, 10)
+	if !Verify("secret", timestamp, body, signature, time.Minute) {
+		t.Fatal("signature not verified")
+	}
+	if Verify("other", timestamp, body, signature, time.Minute) {
+		t.Fatal("signature verified with another secret")
+	}
+	if Verify("secret", timestamp, []byte(`{"userID":"u2"}`), signature, time.Minute) {
+		t.Fatal("signature verified for another body")
+	}
+	if Verify("secret", "x", body, signature, time.Minute) {
+		t.Fatal("signature verified with an invalid timestamp")
+	}
+}
+
+func TestVerifyRejectsStaleTimestamps(t *testing.T) {
+	body := []byte(`{}`)
+	for _, sent := range []time.Time{time.Now().Add(-2 * time.Minute), time.Now().Add(2 * time.Minute)} {
+		signature := Sign("secret", sent.Unix(), body)
+		if Verify("secret", strconv.FormatInt(sent.Unix(), 10), body, signature, time.Minute) {
+			t.Fatalf("signature of %s verified", sent)
+		}
+	}
+}
@@ -0,0 +1,35 @@
+// Copyright © 2024 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package audit
+
+import (
+	"errors"
+)
+
+func (x *SearchAuditLogsReq) Check() error {
+	if x.Pagination == nil {
+		return errors.New("pagination is empty")
+	}
+	if x.Pagination.PageNumber < 1 {
+		return errors.New("pageNumber is invalid")
+	}
+	if x.Pagination.ShowNumber < 1 || x.Pagination.ShowNumber > 1000 {
+		return errors.New("showNumber is invalid")
+	}
+	if x.StartTime < 0 || x.EndTime < 0 || (x.EndTime > 0 && x.StartTime > x.EndTime) {
+		return errors.New("time range is invalid")
+	}
+	return nil
+}
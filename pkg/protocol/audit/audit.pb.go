@@ -0,0 +1,578 @@
+// Copyright © 2024 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.33.0
+// 	protoc        v5.26.0
+// source: pkg/protocol/audit/audit.proto
+
+package audit
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type AuditLog struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	ActorUserID string   `protobuf:"bytes,1,opt,name=actorUserID,proto3" json:"actorUserID"`
+	OperationID string   `protobuf:"bytes,2,opt,name=operationID,proto3" json:"operationID"`
+	Action      string   `protobuf:"bytes,3,opt,name=action,proto3" json:"action"`
+	TargetIDs   []string `protobuf:"bytes,4,rep,name=targetIDs,proto3" json:"targetIDs"`
+	Request     string   `protobuf:"bytes,5,opt,name=request,proto3" json:"request"`
+	Code        int32    `protobuf:"varint,6,opt,name=code,proto3" json:"code"`
+	Ip          string   `protobuf:"bytes,7,opt,name=ip,proto3" json:"ip"`
+	// unix milliseconds
+	CreateTime int64 `protobuf:"varint,8,opt,name=createTime,proto3" json:"createTime"`
+}
+
+func (x *AuditLog) Reset() {
+	*x = AuditLog{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pkg_protocol_audit_audit_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *AuditLog) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AuditLog) ProtoMessage() {}
+
+func (x *AuditLog) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_protocol_audit_audit_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AuditLog.ProtoReflect.Descriptor instead.
+func (*AuditLog) Descriptor() ([]byte, []int) {
+	return file_pkg_protocol_audit_audit_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *AuditLog) GetActorUserID() string {
+	if x != nil {
+		return x.ActorUserID
+	}
+	return ""
+}
+
+func (x *AuditLog) GetOperationID() string {
+	if x != nil {
+		return x.OperationID
+	}
+	return ""
+}
+
+func (x *AuditLog) GetAction() string {
+	if x != nil {
+		return x.Action
+	}
+	return ""
+}
+
+func (x *AuditLog) GetTargetIDs() []string {
+	if x != nil {
+		return x.TargetIDs
+	}
+	return nil
+}
+
+func (x *AuditLog) GetRequest() string {
+	if x != nil {
+		return x.Request
+	}
+	return ""
+}
+
+func (x *AuditLog) GetCode() int32 {
+	if x != nil {
+		return x.Code
+	}
+	return 0
+}
+
+func (x *AuditLog) GetIp() string {
+	if x != nil {
+		return x.Ip
+	}
+	return ""
+}
+
+func (x *AuditLog) GetCreateTime() int64 {
+	if x != nil {
+		return x.CreateTime
+	}
+	return 0
+}
+
+type RequestPagination struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	PageNumber int32 `protobuf:"varint,1,opt,name=pageNumber,proto3" json:"pageNumber"`
+	ShowNumber int32 `protobuf:"varint,2,opt,name=showNumber,proto3" json:"showNumber"`
+}
+
+func (x *RequestPagination) Reset() {
+	*x = RequestPagination{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pkg_protocol_audit_audit_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *RequestPagination) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RequestPagination) ProtoMessage() {}
+
+func (x *RequestPagination) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_protocol_audit_audit_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RequestPagination.ProtoReflect.Descriptor instead.
+func (*RequestPagination) Descriptor() ([]byte, []int) {
+	return file_pkg_protocol_audit_audit_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *RequestPagination) GetPageNumber() int32 {
+	if x != nil {
+		return x.PageNumber
+	}
+	return 0
+}
+
+func (x *RequestPagination) GetShowNumber() int32 {
+	if x != nil {
+		return x.ShowNumber
+	}
+	return 0
+}
+
+type SearchAuditLogsReq struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	ActorUserID string `protobuf:"bytes,1,opt,name=actorUserID,proto3" json:"actorUserID"`
+	TargetID    string `protobuf:"bytes,2,opt,name=targetID,proto3" json:"targetID"`
+	// unix milliseconds, 0 for no bound
+	StartTime  int64              `protobuf:"varint,3,opt,name=startTime,proto3" json:"startTime"`
+	EndTime    int64              `protobuf:"varint,4,opt,name=endTime,proto3" json:"endTime"`
+	Pagination *RequestPagination `protobuf:"bytes,5,opt,name=pagination,proto3" json:"pagination"`
+}
+
+func (x *SearchAuditLogsReq) Reset() {
+	*x = SearchAuditLogsReq{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pkg_protocol_audit_audit_proto_msgTypes[2]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SearchAuditLogsReq) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SearchAuditLogsReq) ProtoMessage() {}
+
+func (x *SearchAuditLogsReq) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_protocol_audit_audit_proto_msgTypes[2]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SearchAuditLogsReq.ProtoReflect.Descriptor instead.
+func (*SearchAuditLogsReq) Descriptor() ([]byte, []int) {
+	return file_pkg_protocol_audit_audit_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *SearchAuditLogsReq) GetActorUserID() string {
+	if x != nil {
+		return x.ActorUserID
+	}
+	return ""
+}
+
+func (x *SearchAuditLogsReq) GetTargetID() string {
+	if x != nil {
+		return x.TargetID
+	}
+	return ""
+}
+
+func (x *SearchAuditLogsReq) GetStartTime() int64 {
+	if x != nil {
+		return x.StartTime
+	}
+	return 0
+}
+
+func (x *SearchAuditLogsReq) GetEndTime() int64 {
+	if x != nil {
+		return x.EndTime
+	}
+	return 0
+}
+
+func (x *SearchAuditLogsReq) GetPagination() *RequestPagination {
+	if x != nil {
+		return x.Pagination
+	}
+	return nil
+}
+
+type SearchAuditLogsResp struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Total int64       `protobuf:"varint,1,opt,name=total,proto3" json:"total"`
+	Logs  []*AuditLog `protobuf:"bytes,2,rep,name=logs,proto3" json:"logs"`
+}
+
+func (x *SearchAuditLogsResp) Reset() {
+	*x = SearchAuditLogsResp{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pkg_protocol_audit_audit_proto_msgTypes[3]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SearchAuditLogsResp) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SearchAuditLogsResp) ProtoMessage() {}
+
+func (x *SearchAuditLogsResp) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_protocol_audit_audit_proto_msgTypes[3]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SearchAuditLogsResp.ProtoReflect.Descriptor instead.
+func (*SearchAuditLogsResp) Descriptor() ([]byte, []int) {
+	return file_pkg_protocol_audit_audit_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *SearchAuditLogsResp) GetTotal() int64 {
+	if x != nil {
+		return x.Total
+	}
+	return 0
+}
+
+func (x *SearchAuditLogsResp) GetLogs() []*AuditLog {
+	if x != nil {
+		return x.Logs
+	}
+	return nil
+}
+
+var File_pkg_protocol_audit_audit_proto protoreflect.FileDescriptor
+
+var file_pkg_protocol_audit_audit_proto_rawDesc = []byte{
+	0x0a, 0x1e, 0x70, 0x6b, 0x67, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x2f, 0x61,
+	0x75, 0x64, 0x69, 0x74, 0x2f, 0x61, 0x75, 0x64, 0x69, 0x74, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f,
+	0x12, 0x0c, 0x6f, 0x70, 0x65, 0x6e, 0x69, 0x6d, 0x2e, 0x61, 0x75, 0x64, 0x69, 0x74, 0x22, 0xe2,
+	0x01, 0x0a, 0x08, 0x41, 0x75, 0x64, 0x69, 0x74, 0x4c, 0x6f, 0x67, 0x12, 0x20, 0x0a, 0x0b, 0x61,
+	0x63, 0x74, 0x6f, 0x72, 0x55, 0x73, 0x65, 0x72, 0x49, 0x44, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x0b, 0x61, 0x63, 0x74, 0x6f, 0x72, 0x55, 0x73, 0x65, 0x72, 0x49, 0x44, 0x12, 0x20, 0x0a,
+	0x0b, 0x6f, 0x70, 0x65, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x49, 0x44, 0x18, 0x02, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x0b, 0x6f, 0x70, 0x65, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x49, 0x44, 0x12,
+	0x16, 0x0a, 0x06, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x06, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x1c, 0x0a, 0x09, 0x74, 0x61, 0x72, 0x67, 0x65,
+	0x74, 0x49, 0x44, 0x73, 0x18, 0x04, 0x20, 0x03, 0x28, 0x09, 0x52, 0x09, 0x74, 0x61, 0x72, 0x67,
+	0x65, 0x74, 0x49, 0x44, 0x73, 0x12, 0x18, 0x0a, 0x07, 0x72, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74,
+	0x18, 0x05, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x72, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12,
+	0x12, 0x0a, 0x04, 0x63, 0x6f, 0x64, 0x65, 0x18, 0x06, 0x20, 0x01, 0x28, 0x05, 0x52, 0x04, 0x63,
+	0x6f, 0x64, 0x65, 0x12, 0x0e, 0x0a, 0x02, 0x69, 0x70, 0x18, 0x07, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x02, 0x69, 0x70, 0x12, 0x1e, 0x0a, 0x0a, 0x63, 0x72, 0x65, 0x61, 0x74, 0x65, 0x54, 0x69, 0x6d,
+	0x65, 0x18, 0x08, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0a, 0x63, 0x72, 0x65, 0x61, 0x74, 0x65, 0x54,
+	0x69, 0x6d, 0x65, 0x22, 0x53, 0x0a, 0x11, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x50, 0x61,
+	0x67, 0x69, 0x6e, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x1e, 0x0a, 0x0a, 0x70, 0x61, 0x67, 0x65,
+	0x4e, 0x75, 0x6d, 0x62, 0x65, 0x72, 0x18, 0x01, 0x20, 0x01, 0x28, 0x05, 0x52, 0x0a, 0x70, 0x61,
+	0x67, 0x65, 0x4e, 0x75, 0x6d, 0x62, 0x65, 0x72, 0x12, 0x1e, 0x0a, 0x0a, 0x73, 0x68, 0x6f, 0x77,
+	0x4e, 0x75, 0x6d, 0x62, 0x65, 0x72, 0x18, 0x02, 0x20, 0x01, 0x28, 0x05, 0x52, 0x0a, 0x73, 0x68,
+	0x6f, 0x77, 0x4e, 0x75, 0x6d, 0x62, 0x65, 0x72, 0x22, 0xcb, 0x01, 0x0a, 0x12, 0x73, 0x65, 0x61,
+	0x72, 0x63, 0x68, 0x41, 0x75, 0x64, 0x69, 0x74, 0x4c, 0x6f, 0x67, 0x73, 0x52, 0x65, 0x71, 0x12,
+	0x20, 0x0a, 0x0b, 0x61, 0x63, 0x74, 0x6f, 0x72, 0x55, 0x73, 0x65, 0x72, 0x49, 0x44, 0x18, 0x01,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x0b, 0x61, 0x63, 0x74, 0x6f, 0x72, 0x55, 0x73, 0x65, 0x72, 0x49,
+	0x44, 0x12, 0x1a, 0x0a, 0x08, 0x74, 0x61, 0x72, 0x67, 0x65, 0x74, 0x49, 0x44, 0x18, 0x02, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x08, 0x74, 0x61, 0x72, 0x67, 0x65, 0x74, 0x49, 0x44, 0x12, 0x1c, 0x0a,
+	0x09, 0x73, 0x74, 0x61, 0x72, 0x74, 0x54, 0x69, 0x6d, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x03,
+	0x52, 0x09, 0x73, 0x74, 0x61, 0x72, 0x74, 0x54, 0x69, 0x6d, 0x65, 0x12, 0x18, 0x0a, 0x07, 0x65,
+	0x6e, 0x64, 0x54, 0x69, 0x6d, 0x65, 0x18, 0x04, 0x20, 0x01, 0x28, 0x03, 0x52, 0x07, 0x65, 0x6e,
+	0x64, 0x54, 0x69, 0x6d, 0x65, 0x12, 0x3f, 0x0a, 0x0a, 0x70, 0x61, 0x67, 0x69, 0x6e, 0x61, 0x74,
+	0x69, 0x6f, 0x6e, 0x18, 0x05, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1f, 0x2e, 0x6f, 0x70, 0x65, 0x6e,
+	0x69, 0x6d, 0x2e, 0x61, 0x75, 0x64, 0x69, 0x74, 0x2e, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74,
+	0x50, 0x61, 0x67, 0x69, 0x6e, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x0a, 0x70, 0x61, 0x67, 0x69,
+	0x6e, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x22, 0x57, 0x0a, 0x13, 0x73, 0x65, 0x61, 0x72, 0x63, 0x68,
+	0x41, 0x75, 0x64, 0x69, 0x74, 0x4c, 0x6f, 0x67, 0x73, 0x52, 0x65, 0x73, 0x70, 0x12, 0x14, 0x0a,
+	0x05, 0x74, 0x6f, 0x74, 0x61, 0x6c, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x05, 0x74, 0x6f,
+	0x74, 0x61, 0x6c, 0x12, 0x2a, 0x0a, 0x04, 0x6c, 0x6f, 0x67, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28,
+	0x0b, 0x32, 0x16, 0x2e, 0x6f, 0x70, 0x65, 0x6e, 0x69, 0x6d, 0x2e, 0x61, 0x75, 0x64, 0x69, 0x74,
+	0x2e, 0x41, 0x75, 0x64, 0x69, 0x74, 0x4c, 0x6f, 0x67, 0x52, 0x04, 0x6c, 0x6f, 0x67, 0x73, 0x32,
+	0x5f, 0x0a, 0x05, 0x61, 0x75, 0x64, 0x69, 0x74, 0x12, 0x56, 0x0a, 0x0f, 0x73, 0x65, 0x61, 0x72,
+	0x63, 0x68, 0x41, 0x75, 0x64, 0x69, 0x74, 0x4c, 0x6f, 0x67, 0x73, 0x12, 0x20, 0x2e, 0x6f, 0x70,
+	0x65, 0x6e, 0x69, 0x6d, 0x2e, 0x61, 0x75, 0x64, 0x69, 0x74, 0x2e, 0x73, 0x65, 0x61, 0x72, 0x63,
+	0x68, 0x41, 0x75, 0x64, 0x69, 0x74, 0x4c, 0x6f, 0x67, 0x73, 0x52, 0x65, 0x71, 0x1a, 0x21, 0x2e,
+	0x6f, 0x70, 0x65, 0x6e, 0x69, 0x6d, 0x2e, 0x61, 0x75, 0x64, 0x69, 0x74, 0x2e, 0x73, 0x65, 0x61,
+	0x72, 0x63, 0x68, 0x41, 0x75, 0x64, 0x69, 0x74, 0x4c, 0x6f, 0x67, 0x73, 0x52, 0x65, 0x73, 0x70,
+	0x42, 0x41, 0x5a, 0x3f, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x6f,
+	0x70, 0x65, 0x6e, 0x69, 0x6d, 0x73, 0x64, 0x6b, 0x2f, 0x6f, 0x70, 0x65, 0x6e, 0x69, 0x6d, 0x2d,
+	0x70, 0x72, 0x6f, 0x6a, 0x65, 0x63, 0x74, 0x2d, 0x74, 0x65, 0x6d, 0x70, 0x6c, 0x61, 0x74, 0x65,
+	0x2f, 0x70, 0x6b, 0x67, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x2f, 0x61, 0x75,
+	0x64, 0x69, 0x74, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_pkg_protocol_audit_audit_proto_rawDescOnce sync.Once
+	file_pkg_protocol_audit_audit_proto_rawDescData = file_pkg_protocol_audit_audit_proto_rawDesc
+)
+
+func file_pkg_protocol_audit_audit_proto_rawDescGZIP() []byte {
+	file_pkg_protocol_audit_audit_proto_rawDescOnce.Do(func() {
+		file_pkg_protocol_audit_audit_proto_rawDescData = protoimpl.X.CompressGZIP(file_pkg_protocol_audit_audit_proto_rawDescData)
+	})
+	return file_pkg_protocol_audit_audit_proto_rawDescData
+}
+
+var file_pkg_protocol_audit_audit_proto_msgTypes = make([]protoimpl.MessageInfo, 4)
+var file_pkg_protocol_audit_audit_proto_goTypes = []interface{}{
+	(*AuditLog)(nil),            // 0: openim.audit.AuditLog
+	(*RequestPagination)(nil),   // 1: openim.audit.RequestPagination
+	(*SearchAuditLogsReq)(nil),  // 2: openim.audit.searchAuditLogsReq
+	(*SearchAuditLogsResp)(nil), // 3: openim.audit.searchAuditLogsResp
+}
+var file_pkg_protocol_audit_audit_proto_depIdxs = []int32{
+	1, // 0: openim.audit.searchAuditLogsReq.pagination:type_name -> openim.audit.RequestPagination
+	0, // 1: openim.audit.searchAuditLogsResp.logs:type_name -> openim.audit.AuditLog
+	2, // 2: openim.audit.audit.searchAuditLogs:input_type -> openim.audit.searchAuditLogsReq
+	3, // 3: openim.audit.audit.searchAuditLogs:output_type -> openim.audit.searchAuditLogsResp
+	3, // [3:4] is the sub-list for method output_type
+	2, // [2:3] is the sub-list for method input_type
+	2, // [2:2] is the sub-list for extension type_name
+	2, // [2:2] is the sub-list for extension extendee
+	0, // [0:2] is the sub-list for field type_name
+}
+
+func init() { file_pkg_protocol_audit_audit_proto_init() }
+func file_pkg_protocol_audit_audit_proto_init() {
+	if File_pkg_protocol_audit_audit_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_pkg_protocol_audit_audit_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*AuditLog); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pkg_protocol_audit_audit_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*RequestPagination); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pkg_protocol_audit_audit_proto_msgTypes[2].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SearchAuditLogsReq); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pkg_protocol_audit_audit_proto_msgTypes[3].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SearchAuditLogsResp); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_pkg_protocol_audit_audit_proto_rawDesc,
+			NumEnums:      0,
+			NumMessages:   4,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_pkg_protocol_audit_audit_proto_goTypes,
+		DependencyIndexes: file_pkg_protocol_audit_audit_proto_depIdxs,
+		MessageInfos:      file_pkg_protocol_audit_audit_proto_msgTypes,
+	}.Build()
+	File_pkg_protocol_audit_audit_proto = out.File
+	file_pkg_protocol_audit_audit_proto_rawDesc = nil
+	file_pkg_protocol_audit_audit_proto_goTypes = nil
+	file_pkg_protocol_audit_audit_proto_depIdxs = nil
+}
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ context.Context
+var _ grpc.ClientConnInterface
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+const _ = grpc.SupportPackageIsVersion6
+
+// AuditClient is the client API for Audit service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
+type AuditClient interface {
+	// Search the audit log by actor, target and time range, newest first
+	SearchAuditLogs(ctx context.Context, in *SearchAuditLogsReq, opts ...grpc.CallOption) (*SearchAuditLogsResp, error)
+}
+
+type auditClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewAuditClient(cc grpc.ClientConnInterface) AuditClient {
+	return &auditClient{cc}
+}
+
+func (c *auditClient) SearchAuditLogs(ctx context.Context, in *SearchAuditLogsReq, opts ...grpc.CallOption) (*SearchAuditLogsResp, error) {
+	out := new(SearchAuditLogsResp)
+	err := c.cc.Invoke(ctx, "/openim.audit.audit/searchAuditLogs", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// AuditServer is the server API for Audit service.
+type AuditServer interface {
+	// Search the audit log by actor, target and time range, newest first
+	SearchAuditLogs(context.Context, *SearchAuditLogsReq) (*SearchAuditLogsResp, error)
+}
+
+// UnimplementedAuditServer can be embedded to have forward compatible implementations.
+type UnimplementedAuditServer struct {
+}
+
+func (*UnimplementedAuditServer) SearchAuditLogs(context.Context, *SearchAuditLogsReq) (*SearchAuditLogsResp, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SearchAuditLogs not implemented")
+}
+
+func RegisterAuditServer(s *grpc.Server, srv AuditServer) {
+	s.RegisterService(&_Audit_serviceDesc, srv)
+}
+
+func _Audit_SearchAuditLogs_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SearchAuditLogsReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AuditServer).SearchAuditLogs(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/openim.audit.audit/SearchAuditLogs",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AuditServer).SearchAuditLogs(ctx, req.(*SearchAuditLogsReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+var _Audit_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "openim.audit.audit",
+	HandlerType: (*AuditServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "searchAuditLogs",
+			Handler:    _Audit_SearchAuditLogs_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "pkg/protocol/audit/audit.proto",
+}
@@ -14,6 +14,7 @@
 
 PROTO_NAMES=(
     "user"
+    "audit"
 )
 
 for name in "${PROTO_NAMES[@]}"; do
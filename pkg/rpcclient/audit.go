@@ -0,0 +1,40 @@
+// Copyright © 2024 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rpcclient
+
+import (
+	"context"
+
+	"github.com/openimsdk/openim-project-template/pkg/protocol/audit"
+	"github.com/openimsdk/tools/discovery"
+	"github.com/openimsdk/tools/system/program"
+	"google.golang.org/grpc"
+)
+
+// Audit represents a structure holding connection details for the Audit RPC client.
+type Audit struct {
+	conn   grpc.ClientConnInterface
+	Client audit.AuditClient
+	Discov discovery.SvcDiscoveryRegistry
+}
+
+// NewAudit initializes and returns an Audit instance based on the provided service discovery registry.
+func NewAudit(discov discovery.SvcDiscoveryRegistry, rpcRegisterName string) *Audit {
+	conn, err := discov.GetConn(context.Background(), rpcRegisterName)
+	if err != nil {
+		program.ExitWithError(err)
+	}
+	return &Audit{Discov: discov, Client: audit.NewAuditClient(conn), conn: conn}
+}
@@ -0,0 +1,61 @@
+// Copyright © 2024 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rpcclient
+
+import (
+	"context"
+	"io"
+
+	"github.com/openimsdk/tools/mw"
+	"google.golang.org/grpc"
+)
+
+// GrpcStreamClient returns the dial option running streams through mw.RpcClientInterceptor, which only
+// intercepts unary calls. It is added next to mw.GrpcClient so that streams carry the operationID and
+// return the error codes of the other calls.
+func GrpcStreamClient() grpc.DialOption {
+	return grpc.WithChainStreamInterceptor(streamClientInterceptor)
+}
+
+func streamClientInterceptor(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	var stream grpc.ClientStream
+	err := mw.RpcClientInterceptor(ctx, method, nil, nil, cc, func(ctx context.Context, method string, _, _ any, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		var err error
+		stream, err = streamer(ctx, desc, cc, method, opts...)
+		return err
+	}, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &clientStream{ClientStream: stream, ctx: ctx, cc: cc, method: method}, nil
+}
+
+// clientStream converts the errors of the messages received as mw.RpcClientInterceptor does.
+type clientStream struct {
+	grpc.ClientStream
+	ctx    context.Context
+	cc     *grpc.ClientConn
+	method string
+}
+
+func (s *clientStream) RecvMsg(m any) error {
+	err := s.ClientStream.RecvMsg(m)
+	if err == nil || err == io.EOF {
+		return err
+	}
+	return mw.RpcClientInterceptor(s.ctx, s.method, nil, m, s.cc, func(context.Context, string, any, any, *grpc.ClientConn, ...grpc.CallOption) error {
+		return err
+	})
+}
@@ -23,6 +23,7 @@ import (
 	"github.com/openimsdk/tools/system/program"
 	"github.com/openimsdk/tools/utils/datautil"
 	"google.golang.org/grpc"
+	"io"
 	"strings"
 )
 
@@ -79,6 +80,49 @@ func (u *UserRpcClient) GetUsersInfo(ctx context.Context, userIDs []string) ([]*
 	return resp.UsersInfo, nil
 }
 
+// GetUsersInfoStream retrieves information for multiple users through StreamUsers, for lists of user IDs whose
+// response would exceed the message size of GetDesignateUsers.
+func (u *UserRpcClient) GetUsersInfoStream(ctx context.Context, userIDs []string) ([]*user.UserInfo, error) {
+	if len(userIDs) == 0 {
+		return []*user.UserInfo{}, nil
+	}
+	users := make([]*user.UserInfo, 0, len(userIDs))
+	if err := u.StreamUsers(ctx, &user.StreamUsersReq{UserIDs: userIDs}, func(chunk []*user.UserInfo) error {
+		users = append(users, chunk...)
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	if ids := datautil.Single(userIDs, datautil.Slice(users, func(e *user.UserInfo) string {
+		return e.UserID
+	})); len(ids) > 0 {
+		return nil, servererrs.ErrUserIDNotFound.WrapMsg(strings.Join(ids, ","))
+	}
+	return users, nil
+}
+
+// StreamUsers calls fn with each chunk of the users streamed for req. The stream is canceled when fn returns an error.
+func (u *UserRpcClient) StreamUsers(ctx context.Context, req *user.StreamUsersReq, fn func(users []*user.UserInfo) error) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	stream, err := u.Client.StreamUsers(ctx, req)
+	if err != nil {
+		return err
+	}
+	for {
+		resp, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := fn(resp.UsersInfo); err != nil {
+			return err
+		}
+	}
+}
+
 // GetUserInfo retrieves information for a single user based on the provided user ID.
 func (u *UserRpcClient) GetUserInfo(ctx context.Context, userID string) (*user.UserInfo, error) {
 	users, err := u.GetUsersInfo(ctx, []string{userID})
@@ -0,0 +1,57 @@
+// Copyright © 2024 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package e2e
+
+import (
+	"context"
+	"testing"
+
+	"github.com/openimsdk/openim-project-template/pkg/apiclient"
+	pbuser "github.com/openimsdk/openim-project-template/pkg/protocol/user"
+	"github.com/openimsdk/tools/errs"
+)
+
+func TestAPIClient(t *testing.T) {
+	s := Start(t)
+	c := apiclient.New(s.URL, apiclient.WithToken(s.Client(t, "admin").Token))
+	ctx := context.Background()
+
+	if _, err := c.UserRegister(ctx, &pbuser.UserRegisterReq{Users: []*pbuser.UserInfo{{UserID: "u1", Nickname: "one"}}}); err != nil {
+		t.Fatal(err)
+	}
+	resp, err := c.GetUsersInfo(ctx, &pbuser.GetDesignateUsersReq{UserIDs: []string{"u1"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.UsersInfo) != 1 || resp.UsersInfo[0].Nickname != "one" {
+		t.Fatalf("got %v", resp.UsersInfo)
+	}
+	_, err = c.UserRegister(ctx, &pbuser.UserRegisterReq{Users: []*pbuser.UserInfo{{UserID: "u1"}}})
+	if !errs.ErrDuplicateKey.Is(err) {
+		t.Fatalf("got %v, want ErrDuplicateKey", err)
+	}
+
+	var streamed []string
+	err = c.StreamUsers(ctx, &pbuser.StreamUsersReq{UserIDs: []string{"u1"}}, func(user *pbuser.UserInfo) error {
+		streamed = append(streamed, user.UserID)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(streamed) != 1 || streamed[0] != "u1" {
+		t.Fatalf("streamed %v, want [u1]", streamed)
+	}
+}
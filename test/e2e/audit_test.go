@@ -0,0 +1,61 @@
+// Copyright © 2024 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package e2e
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/openimsdk/openim-project-template/internal/rpc/audit"
+	"github.com/openimsdk/openim-project-template/internal/rpc/user"
+	"github.com/openimsdk/openim-project-template/pkg/common/cmd"
+	"github.com/openimsdk/openim-project-template/pkg/common/config"
+	"github.com/openimsdk/openim-project-template/pkg/common/discoveryregister/local"
+	"github.com/openimsdk/openim-project-template/pkg/common/startrpc"
+)
+
+// TestAuditServiceInMemoryMode starts the audit service as openim-rpc-audit would in the memory mode of
+// mongodb.yml, it is refused instead of panicking, and openim-server leaves it out.
+func TestAuditServiceInMemoryMode(t *testing.T) {
+	ctx := context.Background()
+	share := config.Share{RpcRegisterName: config.RpcRegisterName{User: "User", Audit: "Audit"}, Shutdown: config.Shutdown{Timeout: 5}}
+	shared := &startrpc.SharedConfig{Share: share, Mongo: config.Mongo{Mode: startrpc.MongoModeMemory}}
+	svc := audit.NewService()
+	auditConf := svc.ConfigFiles[cmd.OpenIMRPCAuditCfgFileName].(*config.AuditRPC)
+	auditConf.RPC.Ports = []int{0}
+	auditConf.Prometheus.Ports = []int{0}
+
+	lifecycle := startrpc.NewLifecycle(&share.Shutdown)
+	t.Cleanup(func() {
+		if err := lifecycle.Stop(ctx); err != nil {
+			t.Errorf("stop: %v", err)
+		}
+	})
+	deps, err := startrpc.NewDependenciesWithDiscovery(ctx, lifecycle, local.NewConnManager(), svc.Needs, shared)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = startrpc.Serve(ctx, lifecycle, 0, svc, deps, make(chan error, 2))
+	if err == nil || !strings.Contains(err.Error(), "audit service needs mongo") {
+		t.Fatalf("got %v, want the audit service refused", err)
+	}
+	if svc.Available(shared) {
+		t.Fatal("audit service available in memory mode, openim-server would start it")
+	}
+	if !user.NewService().Available(shared) {
+		t.Fatal("user service unavailable in memory mode")
+	}
+}
@@ -0,0 +1,220 @@
+// Copyright © 2024 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package e2e
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/openimsdk/openim-project-template/internal/api"
+	"github.com/openimsdk/openim-project-template/internal/rpc/user"
+	"github.com/openimsdk/openim-project-template/pkg/common/cmd"
+	"github.com/openimsdk/openim-project-template/pkg/common/config"
+	"github.com/openimsdk/openim-project-template/pkg/common/discoveryregister/local"
+	"github.com/openimsdk/openim-project-template/pkg/common/startrpc"
+	"github.com/openimsdk/tools/errs"
+	"github.com/openimsdk/tools/tokenverify"
+)
+
+const (
+	secret       = "openIM123"
+	readyTimeout = 10 * time.Second
+)
+
+// Server is the API and the user RPC started by Start.
+type Server struct {
+	// URL is the base URL of the API.
+	URL string
+
+	secret string
+	http   *http.Client
+}
+
+// Start serves the API on an ephemeral port and the user RPC in memory with the memory mode of mongodb.yml,
+// Redis is not used. They are stopped when the test ends.
+func Start(t testing.TB) *Server {
+	t.Helper()
+	ctx := context.Background()
+	share := config.Share{
+		RpcRegisterName: config.RpcRegisterName{User: "User", Audit: "Audit"},
+		IMAdminUserID:   []string{"admin"},
+		// Stopped without waiting for load balancers.
+		Shutdown: config.Shutdown{Timeout: 5},
+	}
+	shared := &startrpc.SharedConfig{Share: share, Mongo: config.Mongo{Mode: startrpc.MongoModeMemory}}
+	svc := user.NewService()
+	userConf := svc.ConfigFiles[cmd.OpenIMRPCUserCfgFileName].(*config.User)
+	// In memory the port is only the index of the instance.
+	userConf.RPC.Ports = []int{0}
+	userConf.Prometheus.Ports = []int{0}
+
+	lifecycle := startrpc.NewLifecycle(&share.Shutdown)
+	t.Cleanup(func() {
+		if err := lifecycle.Stop(ctx); err != nil {
+			t.Errorf("stop: %v", err)
+		}
+	})
+	deps, err := startrpc.NewDependenciesWithDiscovery(ctx, lifecycle, local.NewConnManager(), svc.Needs, shared)
+	if err != nil {
+		t.Fatal(err)
+	}
+	errCh := make(chan error, 4)
+	if err := startrpc.Serve(ctx, lifecycle, 0, svc, deps, errCh); err != nil {
+		t.Fatal(err)
+	}
+
+	port := freePort(t)
+	apiConf := &api.Config{Share: share}
+	apiConf.API.Secret = secret
+	apiConf.API.Api.ListenIP = "127.0.0.1"
+	apiConf.API.Api.Ports = []int{port}
+	apiConf.API.Prometheus.Ports = []int{0}
+	if err := api.Serve(ctx, lifecycle, 0, apiConf, deps.Discovery, errCh); err != nil {
+		t.Fatal(err)
+	}
+	transport := &http.Transport{}
+	// Runs before the lifecycle stops, the API waits for the connections that are not idle.
+	t.Cleanup(transport.CloseIdleConnections)
+	s := &Server{
+		URL:    "http://" + net.JoinHostPort("127.0.0.1", strconv.Itoa(port)),
+		secret: secret,
+		http:   &http.Client{Transport: transport},
+	}
+	s.waitReady(t, errCh)
+	return s
+}
+
+// waitReady polls the readiness of the API, which requires the user RPC to be serving.
+func (s *Server) waitReady(t testing.TB, errCh <-chan error) {
+	t.Helper()
+	deadline := time.Now().Add(readyTimeout)
+	for {
+		select {
+		case err := <-errCh:
+			t.Fatal(err)
+		default:
+		}
+		resp, err := s.http.Get(s.URL + "/readyz")
+		if err == nil {
+			resp.Body.Close()
+			if resp.StatusCode == http.StatusOK {
+				return
+			}
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("api not ready after %s", readyTimeout)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+// Client returns a client sending the requests as userID.
+func (s *Server) Client(t testing.TB, userID string) *Client {
+	t.Helper()
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, tokenverify.BuildClaims(userID, 1, 1)).SignedString([]byte(s.secret))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &Client{URL: s.URL, Token: token, HTTP: s.http}
+}
+
+// Client sends API requests with the operationID and token headers.
+type Client struct {
+	URL   string
+	Token string
+	HTTP  *http.Client
+
+	seq atomic.Int64
+}
+
+// APIError is a response whose errCode is not zero.
+type APIError struct {
+	ErrCode int    `json:"errCode"`
+	ErrMsg  string `json:"errMsg"`
+	ErrDlt  string `json:"errDlt"`
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("api error %d: %s %s", e.ErrCode, e.ErrMsg, e.ErrDlt)
+}
+
+// Do sends req as JSON to path with a new operationID and the token of the client, the caller closes the body.
+func (c *Client) Do(ctx context.Context, path string, req any) (*http.Response, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, errs.WrapMsg(err, "marshal request failed")
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, c.URL+path, bytes.NewReader(body))
+	if err != nil {
+		return nil, errs.WrapMsg(err, "new request failed")
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("operationID", fmt.Sprintf("e2e-%d-%d", time.Now().UnixNano(), c.seq.Add(1)))
+	httpReq.Header.Set("token", c.Token)
+	resp, err := c.HTTP.Do(httpReq)
+	if err != nil {
+		return nil, errs.WrapMsg(err, "post failed", "path", path)
+	}
+	return resp, nil
+}
+
+// Post sends req as JSON to path with a new operationID and decodes the data of the response into data,
+// which may be nil. A response with a non-zero errCode returns an *APIError.
+func (c *Client) Post(ctx context.Context, path string, req, data any) error {
+	resp, err := c.Do(ctx, path, req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return errs.New("unexpected status", "path", path, "status", resp.Status).Wrap()
+	}
+	var apiResp struct {
+		APIError
+		Data json.RawMessage `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&apiResp); err != nil {
+		return errs.WrapMsg(err, "decode response failed", "path", path)
+	}
+	if apiResp.ErrCode != 0 {
+		return &apiResp.APIError
+	}
+	if data == nil || len(apiResp.Data) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(apiResp.Data, data); err != nil {
+		return errs.WrapMsg(err, "decode data failed", "path", path)
+	}
+	return nil
+}
+
+func freePort(t testing.TB) int {
+	t.Helper()
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer lis.Close()
+	return lis.Addr().(*net.TCPAddr).Port
+}
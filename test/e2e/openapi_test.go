@@ -0,0 +1,58 @@
+// Copyright © 2024 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package e2e
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/openimsdk/openim-project-template/internal/api"
+	"github.com/openimsdk/openim-project-template/pkg/common/openapi"
+)
+
+func TestOpenAPI(t *testing.T) {
+	s := Start(t)
+	resp, err := s.http.Get(s.URL + "/openapi.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status %s", resp.Status)
+	}
+	var doc openapi.Document
+	if err := json.NewDecoder(resp.Body).Decode(&doc); err != nil {
+		t.Fatal(err)
+	}
+	for _, route := range api.Routes() {
+		op, ok := doc.Paths[route.Path][strings.ToLower(route.Method)]
+		if !ok {
+			t.Errorf("%s is not documented", route.Path)
+			continue
+		}
+		name := string(route.Req.ProtoReflect().Descriptor().FullName())
+		if _, ok := doc.Components.Schemas[name]; !ok {
+			t.Errorf("schema %s of %s is missing", name, route.Path)
+		}
+		if op.RequestBody == nil {
+			t.Errorf("%s has no request body", route.Path)
+		}
+	}
+	if len(doc.Components.Schemas["ErrCode"].ErrorCodes) < 2 {
+		t.Errorf("error codes are missing")
+	}
+}
@@ -0,0 +1,135 @@
+// Copyright © 2024 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package e2e
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	pbuser "github.com/openimsdk/openim-project-template/pkg/protocol/user"
+	"github.com/openimsdk/tools/errs"
+)
+
+// streamUsers posts req to /user/stream_users and returns the users of the NDJSON lines.
+func streamUsers(t *testing.T, c *Client, req *pbuser.StreamUsersReq) []*pbuser.UserInfo {
+	t.Helper()
+	resp, err := c.Do(context.Background(), "/user/stream_users", req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if contentType := resp.Header.Get("Content-Type"); contentType != "application/x-ndjson" {
+		t.Fatalf("content type %q", contentType)
+	}
+	var users []*pbuser.UserInfo
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		var user pbuser.UserInfo
+		if err := json.Unmarshal(scanner.Bytes(), &user); err != nil {
+			t.Fatalf("line %q: %v", scanner.Text(), err)
+		}
+		users = append(users, &user)
+	}
+	if err := scanner.Err(); err != nil {
+		t.Fatal(err)
+	}
+	return users
+}
+
+func TestStreamUsers(t *testing.T) {
+	s := Start(t)
+	c := s.Client(t, "admin")
+	register := &pbuser.UserRegisterReq{}
+	for i := 1; i <= 10; i++ {
+		register.Users = append(register.Users, &pbuser.UserInfo{UserID: fmt.Sprintf("a%02d", i), Nickname: fmt.Sprintf("nick%d", i)})
+	}
+	register.Users = append(register.Users, &pbuser.UserInfo{UserID: "b1", Nickname: "nick1"})
+	if err := c.Post(context.Background(), "/user/user_register", register, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("Prefix", func(t *testing.T) {
+		users := streamUsers(t, c, &pbuser.StreamUsersReq{UserIDPrefix: "a", ChunkSize: 3})
+		if len(users) != 10 {
+			t.Fatalf("got %d users", len(users))
+		}
+		for i, user := range users {
+			if want := fmt.Sprintf("a%02d", i+1); user.UserID != want {
+				t.Fatalf("user %d is %s, want %s", i, user.UserID, want)
+			}
+		}
+	})
+	t.Run("Nickname", func(t *testing.T) {
+		users := streamUsers(t, c, &pbuser.StreamUsersReq{Nickname: "nick1"})
+		if len(users) != 3 || users[0].UserID != "a01" || users[1].UserID != "a10" || users[2].UserID != "b1" {
+			t.Fatalf("got users %v", users)
+		}
+	})
+	t.Run("IDs", func(t *testing.T) {
+		users := streamUsers(t, c, &pbuser.StreamUsersReq{UserIDs: []string{"a02", "missing", "b1", "a02"}, ChunkSize: 1})
+		got := make(map[string]string)
+		for _, user := range users {
+			got[user.UserID] = user.Nickname
+		}
+		if len(users) != 2 || got["a02"] != "nick2" || got["b1"] != "nick1" {
+			t.Fatalf("got users %v", users)
+		}
+	})
+	t.Run("Empty", func(t *testing.T) {
+		if users := streamUsers(t, c, &pbuser.StreamUsersReq{UserIDPrefix: "z"}); len(users) != 0 {
+			t.Fatalf("got users %v", users)
+		}
+	})
+}
+
+func TestStreamUsersErrors(t *testing.T) {
+	s := Start(t)
+	c := s.Client(t, "admin")
+	tests := []struct {
+		name string
+		req  *pbuser.StreamUsersReq
+	}{
+		{name: "ChunkSize", req: &pbuser.StreamUsersReq{ChunkSize: 100000}},
+		{name: "IDsAndFilter", req: &pbuser.StreamUsersReq{UserIDs: []string{"a"}, UserIDPrefix: "a"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := c.Post(context.Background(), "/user/stream_users", tt.req, nil)
+			apiErr, ok := err.(*APIError)
+			if !ok || apiErr.ErrCode != errs.ArgsError {
+				t.Fatalf("got error %v, want ArgsError", err)
+			}
+		})
+	}
+}
+
+func TestStreamUsersFilterNeedsAdmin(t *testing.T) {
+	s := Start(t)
+	register := &pbuser.UserRegisterReq{Users: []*pbuser.UserInfo{{UserID: "u1", Nickname: "one"}}}
+	if err := s.Client(t, "admin").Post(context.Background(), "/user/user_register", register, nil); err != nil {
+		t.Fatal(err)
+	}
+	c := s.Client(t, "u1")
+	err := c.Post(context.Background(), "/user/stream_users", &pbuser.StreamUsersReq{UserIDPrefix: "u"}, nil)
+	if apiErr, ok := err.(*APIError); !ok || apiErr.ErrCode != errs.NoPermissionError {
+		t.Fatalf("got error %v, want NoPermissionError", err)
+	}
+	if users := streamUsers(t, c, &pbuser.StreamUsersReq{UserIDs: []string{"u1"}}); len(users) != 1 {
+		t.Fatalf("got users %v", users)
+	}
+}
@@ -0,0 +1,95 @@
+// Copyright © 2024 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package e2e
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	pbuser "github.com/openimsdk/openim-project-template/pkg/protocol/user"
+	"github.com/openimsdk/tools/errs"
+)
+
+func TestUserRegisterAndGet(t *testing.T) {
+	s := Start(t)
+	c := s.Client(t, "admin")
+	ctx := context.Background()
+
+	register := &pbuser.UserRegisterReq{Users: []*pbuser.UserInfo{
+		{UserID: "u1", Nickname: "one"},
+		{UserID: "u2", Nickname: "two"},
+	}}
+	if err := c.Post(ctx, "/user/user_register", register, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	var resp pbuser.GetDesignateUsersResp
+	if err := c.Post(ctx, "/user/get_users_info", &pbuser.GetDesignateUsersReq{UserIDs: []string{"u1", "u2"}}, &resp); err != nil {
+		t.Fatal(err)
+	}
+	nicknames := make(map[string]string)
+	for _, user := range resp.UsersInfo {
+		nicknames[user.UserID] = user.Nickname
+	}
+	if len(nicknames) != 2 || nicknames["u1"] != "one" || nicknames["u2"] != "two" {
+		t.Fatalf("got users %v", nicknames)
+	}
+}
+
+func TestUserRegisterErrors(t *testing.T) {
+	s := Start(t)
+	c := s.Client(t, "admin")
+	ctx := context.Background()
+	if err := c.Post(ctx, "/user/user_register", &pbuser.UserRegisterReq{Users: []*pbuser.UserInfo{{UserID: "u1"}}}, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		users []*pbuser.UserInfo
+		code  int
+	}{
+		{name: "Empty", code: errs.ArgsError},
+		{name: "EmptyUserID", users: []*pbuser.UserInfo{{UserID: ""}}, code: errs.ArgsError},
+		{name: "Colon", users: []*pbuser.UserInfo{{UserID: "a:b"}}, code: errs.ArgsError},
+		{name: "Repeated", users: []*pbuser.UserInfo{{UserID: "u2"}, {UserID: "u2"}}, code: errs.ArgsError},
+		{name: "Existing", users: []*pbuser.UserInfo{{UserID: "u1"}}, code: errs.DuplicateKeyError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := c.Post(ctx, "/user/user_register", &pbuser.UserRegisterReq{Users: tt.users}, nil)
+			assertCode(t, err, tt.code)
+		})
+	}
+}
+
+func TestGetUsersInfoNotFound(t *testing.T) {
+	s := Start(t)
+	c := s.Client(t, "admin")
+	err := c.Post(context.Background(), "/user/get_users_info", &pbuser.GetDesignateUsersReq{UserIDs: []string{"missing"}}, nil)
+	assertCode(t, err, errs.RecordNotFoundError)
+}
+
+func assertCode(t *testing.T, err error, code int) {
+	t.Helper()
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("got %v, want api error %d", err, code)
+	}
+	if apiErr.ErrCode != code {
+		t.Fatalf("got %v, want api error %d", apiErr, code)
+	}
+}
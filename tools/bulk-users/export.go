@@ -0,0 +1,88 @@
+// Copyright © 2024 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/openimsdk/openim-project-template/pkg/common/cmd"
+	"github.com/openimsdk/openim-project-template/pkg/common/config"
+	"github.com/openimsdk/openim-project-template/pkg/common/startrpc"
+	"github.com/openimsdk/openim-project-template/pkg/common/storage/database"
+	"github.com/openimsdk/openim-project-template/pkg/common/storage/database/mgo"
+	"github.com/openimsdk/openim-project-template/pkg/common/storage/model"
+	pbuser "github.com/openimsdk/openim-project-template/pkg/protocol/user"
+	"github.com/openimsdk/tools/errs"
+)
+
+// runExport writes the users matching the filters to JSONL, one user a line in userID order,
+// in the format import reads.
+func runExport(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("export", flag.ExitOnError)
+	configDir := flags.String("c", defaultConfigDir, "config directory")
+	out := flags.String("out", "", "JSONL file to write, the standard output by default")
+	var filter database.UserFilter
+	flags.StringVar(&filter.UserIDPrefix, "prefix", "", "export the users whose userID starts with it")
+	flags.StringVar(&filter.Nickname, "nickname", "", "export the users whose nickname contains it")
+	_ = flags.Parse(args)
+
+	var mongoConf config.Mongo
+	if err := loadConfig(*configDir, map[string]any{cmd.MongodbConfigFileName: &mongoConf}); err != nil {
+		return err
+	}
+	if mongoConf.Mode == startrpc.MongoModeMemory {
+		return errs.New("mongo is in memory mode, there is nothing to export").Wrap()
+	}
+	mgoCli, err := mgo.NewClient(ctx, mongoConf.Build())
+	if err != nil {
+		return err
+	}
+	defer mgoCli.GetDB().Client().Disconnect(context.Background())
+	userDB, err := mgo.NewUserMongo(mgoCli.GetDB())
+	if err != nil {
+		return err
+	}
+
+	var w io.Writer = os.Stdout
+	if *out != "" {
+		f, err := os.Create(*out)
+		if err != nil {
+			return errs.WrapMsg(err, "create output failed", "out", *out)
+		}
+		defer f.Close()
+		w = f
+	}
+	buf := bufio.NewWriter(w)
+	encoder := json.NewEncoder(buf)
+	var exported int
+	err = userDB.Scan(ctx, &filter, func(user *model.User) error {
+		exported++
+		return encoder.Encode(&pbuser.UserInfo{UserID: user.UserID, Nickname: user.Nickname})
+	})
+	if err != nil {
+		return err
+	}
+	if err := buf.Flush(); err != nil {
+		return errs.WrapMsg(err, "write output failed")
+	}
+	fmt.Fprintf(os.Stderr, "exported %d users\n", exported)
+	return nil
+}
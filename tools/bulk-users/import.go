@@ -0,0 +1,490 @@
+// Copyright © 2024 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/openimsdk/openim-project-template/pkg/common/cmd"
+	"github.com/openimsdk/openim-project-template/pkg/common/config"
+	kdisc "github.com/openimsdk/openim-project-template/pkg/common/discoveryregister"
+	"github.com/openimsdk/openim-project-template/pkg/common/usercsv"
+	pbuser "github.com/openimsdk/openim-project-template/pkg/protocol/user"
+	"github.com/openimsdk/openim-project-template/pkg/rpcclient"
+	"github.com/openimsdk/tools/errs"
+	"github.com/openimsdk/tools/mcontext"
+	"github.com/openimsdk/tools/mw"
+	"github.com/openimsdk/tools/mw/specialerror"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+const (
+	formatCSV   = "csv"
+	formatJSONL = "jsonl"
+
+	progressInterval = 10 * time.Second
+)
+
+// runImport registers the users of a file in batches sent by concurrent workers.
+//
+// The checkpoint records how many rows from the start of the file are done, a new run with the same
+// checkpoint resumes after them. A batch the RPC rejects is registered again row by row, the rows still
+// rejected are written to the report with the code of the error. A row whose user is already registered
+// with the same nickname counts as imported, so rows in flight when a run stopped are not reported on resume.
+// Errors without a code, such as the RPC being unreachable, stop the import.
+func runImport(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("import", flag.ExitOnError)
+	configDir := flags.String("c", defaultConfigDir, "config directory")
+	file := flags.String("file", "", "CSV file of userID,nickname rows or JSONL file of {\"userID\",\"nickname\"} objects")
+	format := flags.String("format", "", "format of the file, csv or jsonl; by default from its extension")
+	batchSize := flags.Int("batch", 500, "users registered per call")
+	concurrency := flags.Int("concurrency", 4, "calls in flight")
+	checkpointFile := flags.String("checkpoint", "", "file recording the progress, resumed from when it exists")
+	reportFile := flags.String("report", "", "CSV file the rejected rows are appended to, the standard error by default")
+	_ = flags.Parse(args)
+
+	if *file == "" {
+		return errs.New("-file is required").Wrap()
+	}
+	if *batchSize <= 0 || *concurrency <= 0 {
+		return errs.New("batch and concurrency must be positive", "batch", *batchSize, "concurrency", *concurrency).Wrap()
+	}
+	if *format == "" {
+		*format = formatOf(*file)
+	}
+	var (
+		discovery config.Discovery
+		share     config.Share
+	)
+	if err := loadConfig(*configDir, map[string]any{
+		cmd.DiscoveryConfigFilename: &discovery,
+		cmd.ShareFileName:           &share,
+	}); err != nil {
+		return err
+	}
+
+	cp, err := loadCheckpoint(*checkpointFile, *file)
+	if err != nil {
+		return err
+	}
+	rows, err := openRows(*file, *format)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	report, err := openReport(*reportFile)
+	if err != nil {
+		return err
+	}
+	defer report.Close()
+
+	client, err := kdisc.NewDiscoveryRegister(&discovery)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	client.AddOption(mw.GrpcClient(), rpcclient.GrpcStreamClient(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	im := &importer{
+		client:      rpcclient.NewUser(client, share.RpcRegisterName.User).Client,
+		batchSize:   *batchSize,
+		concurrency: *concurrency,
+		checkpoint:  cp,
+		report:      report,
+	}
+	return im.run(ctx, rows)
+}
+
+// formatOf returns the format of a file from its extension, JSONL unless it is .csv.
+func formatOf(name string) string {
+	if strings.EqualFold(filepath.Ext(name), ".csv") {
+		return formatCSV
+	}
+	return formatJSONL
+}
+
+type row struct {
+	// num is the number of the row in the file, from 1, a CSV header not counted.
+	num  int
+	user *pbuser.UserInfo
+}
+
+type batch struct {
+	index int
+	rows  []row
+}
+
+type failure struct {
+	row  row
+	code int
+	msg  string
+}
+
+type batchResult struct {
+	batch    batch
+	failures []failure
+	err      error
+}
+
+type importer struct {
+	client      pbuser.UserClient
+	batchSize   int
+	concurrency int
+	checkpoint  *checkpoint
+	report      *report
+
+	imported, failed int
+}
+
+func (im *importer) run(ctx context.Context, rows *rowReader) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	batches := make(chan batch)
+	results := make(chan batchResult)
+
+	var readErr error
+	skip := im.checkpoint.Rows
+	go func() {
+		defer close(batches)
+		readErr = im.read(ctx, rows, skip, batches)
+	}()
+	var wg sync.WaitGroup
+	for i := 0; i < im.concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for b := range batches {
+				results <- im.register(ctx, b)
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	// Batches complete in any order, the checkpoint and the report only move past the first pending one.
+	var (
+		firstErr     error
+		pending      = make(map[int]batchResult)
+		next         int
+		lastProgress = time.Now()
+	)
+	for res := range results {
+		if res.err != nil {
+			if firstErr == nil {
+				firstErr = res.err
+				cancel()
+			}
+			continue
+		}
+		pending[res.batch.index] = res
+		for firstErr == nil {
+			done, ok := pending[next]
+			if !ok {
+				break
+			}
+			delete(pending, next)
+			next++
+			if err := im.commit(done); err != nil {
+				firstErr = err
+				cancel()
+			}
+		}
+		if time.Since(lastProgress) >= progressInterval {
+			lastProgress = time.Now()
+			fmt.Fprintf(os.Stderr, "imported %d users, %d rejected, %d rows done\n", im.imported, im.failed, im.checkpoint.Rows)
+		}
+	}
+	fmt.Fprintf(os.Stderr, "imported %d users, %d rejected, %d rows done\n", im.imported, im.failed, im.checkpoint.Rows)
+	if firstErr != nil {
+		return firstErr
+	}
+	return readErr
+}
+
+// read sends the rows after the first skip in batches.
+func (im *importer) read(ctx context.Context, rows *rowReader, skip int, batches chan<- batch) error {
+	b := batch{}
+	send := func() bool {
+		select {
+		case batches <- b:
+			b = batch{index: b.index + 1}
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}
+	for {
+		r, err := rows.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if r.num <= skip {
+			continue
+		}
+		b.rows = append(b.rows, r)
+		if len(b.rows) == im.batchSize && !send() {
+			return nil
+		}
+	}
+	if len(b.rows) > 0 {
+		send()
+	}
+	return nil
+}
+
+// register registers the users of b, row by row when the batch is rejected.
+func (im *importer) register(ctx context.Context, b batch) batchResult {
+	users := make([]*pbuser.UserInfo, 0, len(b.rows))
+	for _, r := range b.rows {
+		users = append(users, r.user)
+	}
+	callCtx := mcontext.SetOperationID(ctx, fmt.Sprintf("bulk-import-%d-%d", b.rows[0].num, time.Now().UnixMilli()))
+	_, err := im.client.UserRegister(callCtx, &pbuser.UserRegisterReq{Users: users})
+	if err == nil {
+		return batchResult{batch: b}
+	}
+	if errCode(err) == nil {
+		return batchResult{batch: b, err: err}
+	}
+	res := batchResult{batch: b}
+	for _, r := range b.rows {
+		f, err := im.registerRow(ctx, r)
+		if err != nil {
+			return batchResult{batch: b, err: err}
+		}
+		if f != nil {
+			res.failures = append(res.failures, *f)
+		}
+	}
+	return res
+}
+
+func (im *importer) registerRow(ctx context.Context, r row) (*failure, error) {
+	ctx = mcontext.SetOperationID(ctx, fmt.Sprintf("bulk-import-%d-%d", r.num, time.Now().UnixMilli()))
+	_, err := im.client.UserRegister(ctx, &pbuser.UserRegisterReq{Users: []*pbuser.UserInfo{r.user}})
+	if err == nil {
+		return nil, nil
+	}
+	code := errCode(err)
+	if code == nil {
+		return nil, err
+	}
+	if code.Code() == errs.DuplicateKeyError {
+		resp, err := im.client.GetDesignateUsers(ctx, &pbuser.GetDesignateUsersReq{UserIDs: []string{r.user.UserID}})
+		if err == nil && len(resp.UsersInfo) == 1 && resp.UsersInfo[0].Nickname == r.user.Nickname {
+			return nil, nil
+		}
+	}
+	return &failure{row: r, code: code.Code(), msg: err.Error()}, nil
+}
+
+// commit reports the failures of a batch and moves the checkpoint past it.
+func (im *importer) commit(res batchResult) error {
+	for _, f := range res.failures {
+		if err := im.report.Write(f); err != nil {
+			return err
+		}
+	}
+	if err := im.report.Flush(); err != nil {
+		return err
+	}
+	im.failed += len(res.failures)
+	im.imported += len(res.batch.rows) - len(res.failures)
+	im.checkpoint.Rows = res.batch.rows[len(res.batch.rows)-1].num
+	return im.checkpoint.save()
+}
+
+func errCode(err error) errs.CodeError {
+	return specialerror.ErrCode(errs.Unwrap(err))
+}
+
+// checkpoint is the progress of the import of a file, saved as JSON.
+type checkpoint struct {
+	path string
+	File string `json:"file"`
+	// Rows is the number of rows done from the start of the file.
+	Rows int `json:"rows"`
+}
+
+// loadCheckpoint reads the checkpoint at path, a new one when it does not exist. An empty path keeps it in memory.
+func loadCheckpoint(path string, file string) (*checkpoint, error) {
+	cp := &checkpoint{path: path, File: file}
+	if path == "" {
+		return cp, nil
+	}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return cp, nil
+	}
+	if err != nil {
+		return nil, errs.WrapMsg(err, "read checkpoint failed", "checkpoint", path)
+	}
+	if err := json.Unmarshal(data, cp); err != nil {
+		return nil, errs.WrapMsg(err, "decode checkpoint failed", "checkpoint", path)
+	}
+	if cp.File != file {
+		return nil, errs.New("checkpoint is of another file", "checkpoint", path, "file", cp.File).Wrap()
+	}
+	fmt.Fprintf(os.Stderr, "resuming after row %d\n", cp.Rows)
+	return cp, nil
+}
+
+// save replaces the checkpoint file, through a rename so that it is never partially written.
+func (c *checkpoint) save() error {
+	if c.path == "" {
+		return nil
+	}
+	data, err := json.Marshal(c)
+	if err != nil {
+		return errs.WrapMsg(err, "encode checkpoint failed")
+	}
+	tmp := c.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return errs.WrapMsg(err, "write checkpoint failed", "checkpoint", tmp)
+	}
+	if err := os.Rename(tmp, c.path); err != nil {
+		return errs.WrapMsg(err, "replace checkpoint failed", "checkpoint", c.path)
+	}
+	return nil
+}
+
+// report is the CSV of the rejected rows: row,userID,errCode,errMsg.
+type report struct {
+	file *os.File
+	w    *csv.Writer
+}
+
+func openReport(path string) (*report, error) {
+	if path == "" {
+		return &report{w: csv.NewWriter(os.Stderr)}, nil
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, errs.WrapMsg(err, "open report failed", "report", path)
+	}
+	r := &report{file: f, w: csv.NewWriter(f)}
+	if info, err := f.Stat(); err == nil && info.Size() == 0 {
+		_ = r.w.Write([]string{"row", "userID", "errCode", "errMsg"})
+	}
+	return r, nil
+}
+
+func (r *report) Write(f failure) error {
+	if err := r.w.Write([]string{strconv.Itoa(f.row.num), f.row.user.UserID, strconv.Itoa(f.code), f.msg}); err != nil {
+		return errs.WrapMsg(err, "write report failed")
+	}
+	return nil
+}
+
+func (r *report) Flush() error {
+	r.w.Flush()
+	if err := r.w.Error(); err != nil {
+		return errs.WrapMsg(err, "write report failed")
+	}
+	return nil
+}
+
+func (r *report) Close() error {
+	if r.file == nil {
+		return nil
+	}
+	return r.file.Close()
+}
+
+// rowReader reads the users of a CSV or JSONL file.
+type rowReader struct {
+	file    *os.File
+	csv     *usercsv.Reader
+	scanner *bufio.Scanner
+	num     int
+	line    int
+}
+
+func openRows(path string, format string) (*rowReader, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, errs.WrapMsg(err, "open file failed", "file", path)
+	}
+	r := &rowReader{file: f}
+	switch format {
+	case formatCSV:
+		r.csv = usercsv.NewReader(bufio.NewReader(f))
+	case formatJSONL:
+		r.scanner = bufio.NewScanner(f)
+		r.scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	default:
+		f.Close()
+		return nil, errs.New("unknown format", "format", format).Wrap()
+	}
+	return r, nil
+}
+
+// Next returns the next row, io.EOF at the end of the file.
+func (r *rowReader) Next() (row, error) {
+	if r.csv != nil {
+		return r.nextCSV()
+	}
+	return r.nextJSONL()
+}
+
+func (r *rowReader) nextCSV() (row, error) {
+	user, err := r.csv.Read()
+	if err != nil {
+		return row{}, err
+	}
+	r.num++
+	return row{num: r.num, user: user}, nil
+}
+
+func (r *rowReader) nextJSONL() (row, error) {
+	for r.scanner.Scan() {
+		r.line++
+		line := strings.TrimSpace(r.scanner.Text())
+		if line == "" {
+			continue
+		}
+		var user pbuser.UserInfo
+		if err := json.Unmarshal([]byte(line), &user); err != nil {
+			return row{}, errs.WrapMsg(err, "decode line failed", "line", r.line)
+		}
+		r.num++
+		return row{num: r.num, user: &user}, nil
+	}
+	if err := r.scanner.Err(); err != nil {
+		return row{}, errs.WrapMsg(err, "read jsonl failed")
+	}
+	return row{}, io.EOF
+}
+
+func (r *rowReader) Close() error {
+	return r.file.Close()
+}
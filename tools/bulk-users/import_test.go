@@ -0,0 +1,196 @@
+// Copyright © 2024 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	pbuser "github.com/openimsdk/openim-project-template/pkg/protocol/user"
+	"github.com/openimsdk/tools/errs"
+	"google.golang.org/grpc"
+)
+
+// memoryUserClient registers the users in memory, calling hook first when it is set.
+type memoryUserClient struct {
+	pbuser.UserClient
+
+	lock  sync.Mutex
+	users map[string]string
+	calls [][]string
+	hook  func(users []*pbuser.UserInfo)
+}
+
+func (m *memoryUserClient) UserRegister(ctx context.Context, req *pbuser.UserRegisterReq, opts ...grpc.CallOption) (*pbuser.UserRegisterResp, error) {
+	if m.hook != nil {
+		m.hook(req.Users)
+	}
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	userIDs := make([]string, 0, len(req.Users))
+	for _, user := range req.Users {
+		userIDs = append(userIDs, user.UserID)
+	}
+	m.calls = append(m.calls, userIDs)
+	for _, user := range req.Users {
+		if _, ok := m.users[user.UserID]; ok {
+			return nil, errs.NewCodeError(errs.DuplicateKeyError, "userID registered").Wrap()
+		}
+	}
+	for _, user := range req.Users {
+		m.users[user.UserID] = user.Nickname
+	}
+	return &pbuser.UserRegisterResp{}, nil
+}
+
+func (m *memoryUserClient) GetDesignateUsers(ctx context.Context, req *pbuser.GetDesignateUsersReq, opts ...grpc.CallOption) (*pbuser.GetDesignateUsersResp, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	resp := &pbuser.GetDesignateUsersResp{}
+	for _, userID := range req.UserIDs {
+		if nickname, ok := m.users[userID]; ok {
+			resp.UsersInfo = append(resp.UsersInfo, &pbuser.UserInfo{UserID: userID, Nickname: nickname})
+		}
+	}
+	return resp, nil
+}
+
+func writeFile(t *testing.T, dir string, name string, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func readCheckpoint(t *testing.T, path string) *checkpoint {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	var cp checkpoint
+	if err := json.Unmarshal(data, &cp); err != nil {
+		t.Fatal(err)
+	}
+	return &cp
+}
+
+func newTestImporter(t *testing.T, client pbuser.UserClient, file string, checkpointFile string, reportFile string, batchSize int, concurrency int) (*importer, *rowReader) {
+	t.Helper()
+	cp, err := loadCheckpoint(checkpointFile, file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows, err := openRows(file, formatOf(file))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { rows.Close() })
+	report, err := openReport(reportFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { report.Close() })
+	return &importer{client: client, batchSize: batchSize, concurrency: concurrency, checkpoint: cp, report: report}, rows
+}
+
+func TestImportCommitsCheckpointInOrder(t *testing.T) {
+	dir := t.TempDir()
+	file := writeFile(t, dir, "users.csv", "u1,one\nu2,two\nu3,three\n")
+	checkpointFile := filepath.Join(dir, "users.checkpoint")
+
+	// The first batch completes last, the checkpoint must not move past it before.
+	var (
+		later sync.WaitGroup
+		early bool
+	)
+	later.Add(2)
+	client := &memoryUserClient{users: make(map[string]string)}
+	client.hook = func(users []*pbuser.UserInfo) {
+		if users[0].UserID != "u1" {
+			later.Done()
+			return
+		}
+		later.Wait()
+		// Lets the results of the later batches reach the commit loop.
+		time.Sleep(50 * time.Millisecond)
+		_, err := os.Stat(checkpointFile)
+		early = err == nil
+	}
+	im, rows := newTestImporter(t, client, file, checkpointFile, filepath.Join(dir, "report.csv"), 1, 3)
+	if err := im.run(context.Background(), rows); err != nil {
+		t.Fatal(err)
+	}
+	if early {
+		t.Error("checkpoint saved before the first batch completed")
+	}
+	if cp := readCheckpoint(t, checkpointFile); cp == nil || cp.Rows != 3 {
+		t.Errorf("final checkpoint %+v, want 3 rows", cp)
+	}
+	if im.imported != 3 || im.failed != 0 {
+		t.Errorf("imported %d, failed %d", im.imported, im.failed)
+	}
+}
+
+func TestImportResumesAfterCheckpoint(t *testing.T) {
+	dir := t.TempDir()
+	file := writeFile(t, dir, "users.jsonl", `{"userID":"u1","nickname":"one"}
+{"userID":"u2","nickname":"two"}
+{"userID":"u3","nickname":"three"}
+{"userID":"u4","nickname":"four"}
+`)
+	checkpointFile := writeFile(t, dir, "users.checkpoint", `{"file":"`+file+`","rows":1}`)
+	reportFile := filepath.Join(dir, "report.csv")
+
+	// u2 was registered by the run that stopped before saving its checkpoint, u3 by someone else.
+	client := &memoryUserClient{users: map[string]string{"u1": "one", "u2": "two", "u3": "other"}}
+	im, rows := newTestImporter(t, client, file, checkpointFile, reportFile, 10, 1)
+	if err := im.run(context.Background(), rows); err != nil {
+		t.Fatal(err)
+	}
+	for _, call := range client.calls {
+		if call[0] == "u1" {
+			t.Errorf("row before the checkpoint sent again: %v", call)
+		}
+	}
+	if im.imported != 2 || im.failed != 1 {
+		t.Errorf("imported %d, failed %d, want 2 and 1", im.imported, im.failed)
+	}
+	if client.users["u4"] != "four" {
+		t.Errorf("u4 not registered")
+	}
+	report, err := os.ReadFile(reportFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(report)), "\n")
+	if len(lines) != 2 || !strings.HasPrefix(lines[1], "3,u3,") {
+		t.Errorf("report %q, want only row 3", report)
+	}
+	if cp := readCheckpoint(t, checkpointFile); cp == nil || cp.Rows != 4 {
+		t.Errorf("final checkpoint %+v, want 4 rows", cp)
+	}
+}
@@ -0,0 +1,76 @@
+// Copyright © 2024 OpenIM. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// bulk-users imports users from CSV or JSONL files through the user RPC and exports them from Mongo to JSONL.
+//
+//	bulk-users import -c config -file users.csv -checkpoint users.checkpoint -report users.errors.csv
+//	bulk-users export -c config -out users.jsonl -prefix customer-
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+
+	"github.com/openimsdk/openim-project-template/pkg/common/cmd"
+	"github.com/openimsdk/openim-project-template/pkg/common/config"
+	"github.com/openimsdk/tools/system/program"
+)
+
+const usage = `usage: bulk-users <command> [flags]
+
+commands:
+  import  register the users of a CSV or JSONL file through the user RPC
+  export  write the users of Mongo to JSONL
+
+Run bulk-users <command> -h for the flags of a command.
+`
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	var err error
+	switch os.Args[1] {
+	case "import":
+		err = runImport(ctx, os.Args[2:])
+	case "export":
+		err = runExport(ctx, os.Args[2:])
+	default:
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+	if err != nil {
+		program.ExitWithError(err)
+	}
+}
+
+// defaultConfigDir is the config directory of the repository when run from tools/bulk-users.
+var defaultConfigDir = filepath.Join("..", "..", "config")
+
+// loadConfig loads the config files of the map from configDir, as the services do.
+func loadConfig(configDir string, configMap map[string]any) error {
+	for fileName, configStruct := range configMap {
+		if err := config.Load(configDir, fileName, cmd.ConfigEnvPrefixMap[fileName], configStruct); err != nil {
+			return err
+		}
+	}
+	return nil
+}